@@ -46,6 +46,8 @@ const (
 	ArgImageSlug = "image-slug"
 	// ArgIPAddress is an IP address argument.
 	ArgIPAddress = "ip-address"
+	// ArgFQDN is a fully qualified domain name argument.
+	ArgFQDN = "fqdn"
 	// ArgDropletName is a droplet name argument.
 	ArgDropletName = "droplet-name"
 	// ArgResizeDisk is a resize disk argument.
@@ -86,6 +88,10 @@ const (
 	ArgUserData = "user-data"
 	// ArgUserDataFile is a user data file location argument.
 	ArgUserDataFile = "user-data-file"
+	// ArgDropletSpecFile is a droplet spec file argument.
+	ArgDropletSpecFile = "file"
+	// ArgUserDataVar is a user data template variable argument.
+	ArgUserDataVar = "var"
 	// ArgImageName name is an image name argument.
 	ArgImageName = "image-name"
 	// ArgKey is a key argument.
@@ -102,9 +108,54 @@ const (
 	ArgFormat = "format"
 	// ArgNoHeader hides the output header.
 	ArgNoHeader = "no-header"
+	// ArgFilter filters list output by column.
+	ArgFilter = "filter"
+	// ArgSortBy sorts list output by a column.
+	ArgSortBy = "sort-by"
+	// ArgLimit limits the number of rows in list output.
+	ArgLimit = "limit"
+	// ArgWatch polls a command and redraws its output, optionally at a given
+	// interval.
+	ArgWatch = "watch"
+	// ArgTagName is a tag name argument.
+	ArgTagName = "tag-name"
 	// ArgPollTime is how long before the next poll argument.
 	ArgPollTime = "poll-timeout"
+	// ArgDelete is a delete argument.
+	ArgDelete = "delete"
+	// ArgForce forces an action without asking for confirmation.
+	ArgForce = "force"
+	// ArgPluginSHA256 is the SHA-256 checksum of a plugin archive.
+	ArgPluginSHA256 = "sha256"
+	// ArgCommandFile is a file of doctl command lines.
+	ArgCommandFile = "file"
+	// ArgContinueOnError continues running commands after one fails.
+	ArgContinueOnError = "continue-on-error"
+	// ArgParallel is how many commands to run at once.
+	ArgParallel = "parallel"
+	// ArgChannel is a release channel argument.
+	ArgChannel = "channel"
+	// ArgVersion is a release version argument.
+	ArgVersion = "version"
 
 	// ArgOutput is an output type argument.
 	ArgOutput = "output"
+	// ArgNoCache bypasses the response cache.
+	ArgNoCache = "no-cache"
+	// ArgQuery is a JMESPath query applied to command output.
+	ArgQuery = "query"
+	// ArgUpdateCheck enables checking for newer releases.
+	ArgUpdateCheck = "update-check"
+	// ArgUpdateURL is where the latest release is looked up.
+	ArgUpdateURL = "update-url"
+	// ArgCredentialStore is where access tokens are stored.
+	ArgCredentialStore = "credential-store"
+	// ArgCredentialHelper is the credential helper command of the helper store.
+	ArgCredentialHelper = "credential-helper"
+	// ArgCredentialFile is the path of the encrypted credentials file.
+	ArgCredentialFile = "credential-file"
+	// ArgTraceFile is the path of a HAR file HTTP traffic is recorded to.
+	ArgTraceFile = "trace-file"
+	// ArgTraceUnredacted disables redaction of secrets in traces.
+	ArgTraceUnredacted = "trace-unredacted"
 )
@@ -0,0 +1,250 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/digitalocean/doctl/pkg/har"
+)
+
+const (
+	// EnvRecord is the environment variable naming a cassette file to record
+	// API requests and responses to.
+	EnvRecord = "DOCTL_RECORD"
+
+	// EnvReplay is the environment variable naming a cassette file to serve
+	// API responses from, instead of the network.
+	EnvReplay = "DOCTL_REPLAY"
+)
+
+// Cassette is a recording of API requests and their responses.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// CassetteRequest is a recorded request. Requests are matched on their
+// method, path, including the query, and normalised body.
+type CassetteRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// CassetteResponse is a recorded response.
+type CassetteResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// LoadCassette reads the cassette at path.
+func LoadCassette(path string) (*Cassette, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Cassette
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %v", path, err)
+	}
+
+	return &c, nil
+}
+
+// Save writes the cassette to path.
+func (c *Cassette) Save(path string) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, b, 0600)
+}
+
+// cassetteTransport records requests to, or replays responses from, a
+// cassette. Secrets are redacted before requests are recorded or matched.
+type cassetteTransport struct {
+	wrap     http.RoundTripper
+	path     string
+	replay   bool
+	cassette *Cassette
+
+	mu   sync.Mutex
+	used map[int]bool
+}
+
+// newCassetteTransport returns a transport which records to, or replays
+// from, the cassettes named by DOCTL_RECORD and DOCTL_REPLAY, or nil when
+// neither is set.
+func newCassetteTransport(wrap http.RoundTripper) (*cassetteTransport, error) {
+	if path := os.Getenv(EnvReplay); path != "" {
+		c, err := LoadCassette(path)
+		if err != nil {
+			return nil, err
+		}
+
+		return &cassetteTransport{path: path, replay: true, cassette: c, used: map[int]bool{}}, nil
+	}
+
+	if path := os.Getenv(EnvRecord); path != "" {
+		return &cassetteTransport{wrap: wrap, path: path, cassette: &Cassette{}}, nil
+	}
+
+	return nil, nil
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+		req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	}
+
+	cr := CassetteRequest{
+		Method: req.Method,
+		Path:   normalisePath(req.URL),
+		Body:   normaliseBody(body),
+	}
+
+	if t.replay {
+		return t.play(req, cr)
+	}
+
+	resp, err := t.wrap.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	cr.Header = har.RedactHeaders(req.Header)
+	interaction := Interaction{
+		Request: cr,
+		Response: CassetteResponse{
+			Status: resp.StatusCode,
+			Header: har.RedactHeaders(resp.Header),
+			Body:   string(har.RedactBody(respBody)),
+		},
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.cassette.Interactions = append(t.cassette.Interactions, interaction)
+	if err := t.cassette.Save(t.path); err != nil {
+		return nil, fmt.Errorf("unable to save cassette %s: %v", t.path, err)
+	}
+
+	return resp, nil
+}
+
+// play serves the first unused matching interaction. Once all matching
+// interactions have been used, the last is served again, so repeated
+// requests such as polling still work.
+func (t *cassetteTransport) play(req *http.Request, cr CassetteRequest) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	match := -1
+	for i, in := range t.cassette.Interactions {
+		if in.Request.Method != cr.Method || in.Request.Path != cr.Path ||
+			normaliseBody([]byte(in.Request.Body)) != cr.Body {
+			continue
+		}
+
+		match = i
+		if !t.used[i] {
+			break
+		}
+	}
+
+	if match < 0 {
+		return nil, fmt.Errorf("no response recorded in %s for %s %s", t.path, cr.Method, cr.Path)
+	}
+	t.used[match] = true
+
+	r := t.cassette.Interactions[match].Response
+	header := http.Header{}
+	for k, v := range r.Header {
+		header[k] = v
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}, nil
+}
+
+// normalisePath returns the path and query of u, with query parameters
+// sorted and secrets redacted.
+func normalisePath(u *url.URL) string {
+	p := u.EscapedPath()
+	if q := u.Query(); len(q) > 0 {
+		p += "?" + har.RedactQuery(q).Encode()
+	}
+
+	return p
+}
+
+// normaliseBody redacts secrets from JSON bodies and formats them
+// consistently, so bodies match regardless of field order or whitespace.
+func normaliseBody(b []byte) string {
+	b = har.RedactBody(bytes.TrimSpace(b))
+
+	var v interface{}
+	if json.Unmarshal(b, &v) != nil {
+		return string(b)
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		return string(b)
+	}
+
+	return string(out)
+}
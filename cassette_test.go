@@ -0,0 +1,102 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doit
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"droplet":{"id":1,"user_data":"secret"},"echo":` + string(b) + `}`))
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "cassette")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+
+	os.Setenv(EnvRecord, path)
+	defer os.Unsetenv(EnvRecord)
+
+	rec, err := newCassetteTransport(http.DefaultTransport)
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("POST", ts.URL+"/v2/droplets?page=1", strings.NewReader(`{"name": "a", "size": "512mb"}`))
+	req.Header.Set("Authorization", "Bearer token")
+	resp, err := rec.RoundTrip(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	c, err := LoadCassette(path)
+	assert.NoError(t, err)
+	if assert.Len(t, c.Interactions, 1) {
+		in := c.Interactions[0]
+		assert.Equal(t, "/v2/droplets?page=1", in.Request.Path)
+		assert.Equal(t, `{"name":"a","size":"512mb"}`, in.Request.Body)
+		assert.Equal(t, "Bearer [REDACTED]", in.Request.Header.Get("Authorization"))
+		assert.Equal(t, http.StatusCreated, in.Response.Status)
+		assert.NotContains(t, in.Response.Body, "secret")
+	}
+
+	os.Unsetenv(EnvRecord)
+	os.Setenv(EnvReplay, path)
+	defer os.Unsetenv(EnvReplay)
+
+	play, err := newCassetteTransport(nil)
+	assert.NoError(t, err)
+	ts.Close()
+
+	req, _ = http.NewRequest("POST", "https://api.example.com/v2/droplets?page=1", strings.NewReader(`{"size":"512mb","name":"a"}`))
+	resp, err = play.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	b, _ := ioutil.ReadAll(resp.Body)
+	assert.Contains(t, string(b), `"id":1`)
+
+	req, _ = http.NewRequest("POST", "https://api.example.com/v2/droplets?page=1", strings.NewReader(`{"name":"b"}`))
+	_, err = play.RoundTrip(req)
+	assert.Error(t, err)
+}
+
+func TestCassetteReplayOrder(t *testing.T) {
+	ct := &cassetteTransport{
+		replay: true,
+		used:   map[int]bool{},
+		cassette: &Cassette{Interactions: []Interaction{
+			{Request: CassetteRequest{Method: "GET", Path: "/v2/actions/1"}, Response: CassetteResponse{Status: 200, Body: "in-progress"}},
+			{Request: CassetteRequest{Method: "GET", Path: "/v2/actions/1"}, Response: CassetteResponse{Status: 200, Body: "completed"}},
+		}},
+	}
+
+	for _, expected := range []string{"in-progress", "completed", "completed"} {
+		req, _ := http.NewRequest("GET", "https://api.example.com/v2/actions/1", nil)
+		resp, err := ct.RoundTrip(req)
+		assert.NoError(t, err)
+		b, _ := ioutil.ReadAll(resp.Body)
+		assert.Equal(t, expected, string(b))
+	}
+}
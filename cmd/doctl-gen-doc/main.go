@@ -26,30 +26,111 @@ import (
 	"time"
 
 	"github.com/digitalocean/doctl/commands"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"github.com/spf13/cobra/doc"
 )
 
 var (
-	outputDir  = flag.String("outputDir", "", "output directory")
-	pageLookup = map[string]string{}
+	outputDir    = flag.String("outputDir", "", "output directory for the Hugo site pages")
+	manDir       = flag.String("manDir", "", "output directory for man pages")
+	markdownFile = flag.String("markdown", "", "output file for a single page markdown reference")
+	schemaFile   = flag.String("schema", "", "output file for a JSON description of every command")
+	pageLookup   = map[string]string{}
 )
 
 func main() {
 	flag.Parse()
 
+	if *outputDir == "" && *manDir == "" && *markdownFile == "" && *schemaFile == "" {
+		log.Fatal("at least one of -outputDir, -manDir, -markdown or -schema is required")
+	}
+
 	log.SetPrefix("doit: ")
-	cmd := commands.Init()
+	color.NoColor = true
+	cmd := commands.InitBuiltin()
 	cmd.DisableAutoGenTag = true
 
-	if _, err := os.Stat(*outputDir); os.IsNotExist(err) {
-		log.Fatalf("output directory %q does not exist", *outputDir)
+	if *outputDir != "" {
+		if _, err := os.Stat(*outputDir); os.IsNotExist(err) {
+			log.Fatalf("output directory %q does not exist", *outputDir)
+		}
+
+		err := genTree(cmd, *outputDir, filePrepender)
+		if err != nil {
+			log.Fatalf("generate documentation tree: %v", err)
+		}
+	}
+
+	if *manDir != "" {
+		if err := os.MkdirAll(*manDir, 0755); err != nil {
+			log.Fatalf("create man directory: %v", err)
+		}
+
+		header := &doc.GenManHeader{Title: "DOCTL", Section: "1", Source: "doctl", Manual: "doctl manual"}
+		if err := doc.GenManTree(cmd.Command, header, *manDir); err != nil {
+			log.Fatalf("generate man pages: %v", err)
+		}
+	}
+
+	if *markdownFile != "" {
+		if err := writeFile(*markdownFile, func(w io.Writer) error { return genReference(cmd, w) }); err != nil {
+			log.Fatalf("generate markdown reference: %v", err)
+		}
+	}
+
+	if *schemaFile != "" {
+		err := writeFile(*schemaFile, func(w io.Writer) error {
+			b, err := json.MarshalIndent(commands.Schema(cmd), "", "  ")
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(append(b, '\n'))
+			return err
+		})
+		if err != nil {
+			log.Fatalf("generate schema: %v", err)
+		}
 	}
+}
 
-	err := genTree(cmd, *outputDir, filePrepender)
+func writeFile(path string, fn func(io.Writer) error) error {
+	f, err := os.Create(path)
 	if err != nil {
-		log.Fatalf("generate documentation tree: %v", err)
+		return err
 	}
+
+	if err := fn(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// genReference writes the markdown for cmd and its subcommands to a single
+// page, linking between them with anchors.
+func genReference(cmd *commands.Command, w io.Writer) error {
+	if err := doc.GenMarkdownCustom(cmd.Command, w, anchorHandler); err != nil {
+		return err
+	}
+
+	for _, c := range cmd.ChildCommands() {
+		if !c.IsAvailableCommand() || c.IsHelpCommand() {
+			continue
+		}
+		if err := genReference(c, w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// anchorHandler links to a command's heading in the markdown reference.
+func anchorHandler(name string) string {
+	base := strings.TrimSuffix(name, path.Ext(name))
+	return "#" + strings.ToLower(strings.Replace(base, "_", "-", -1))
 }
 
 func filePrepender(section, filename string) string {
@@ -11,37 +11,114 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
+// Command release-doctl prepares a release build directory, writing its
+// manifest, checksums and signature, and publishes it.
 package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/digitalocean/doctl"
-	"github.com/digitalocean/doctl/install"
-)
-
-var (
-	ver    = flag.String("ver", doit.DoitVersion.String(), "doit version")
-	path   = flag.String("path", "", "upload path")
-	user   = flag.String("user", "", "bintray user")
-	apikey = flag.String("apikey", "", "bintray apikey")
+	"github.com/digitalocean/doctl/pkg/release"
+	"golang.org/x/crypto/openpgp"
 )
 
 func main() {
-	flag.Parse()
+	if err := run(os.Args[1:], os.Getenv); err != nil {
+		log.Fatalf("release failed: %v", err)
+	}
+}
+
+func run(args []string, getenv func(string) string) error {
+	fs := flag.NewFlagSet("release-doctl", flag.ContinueOnError)
+
+	var (
+		ver        = fs.String("ver", doit.DoitVersion.String(), "doctl version")
+		path       = fs.String("path", "", "release build directory")
+		publisher  = fs.String("publisher", "github", "where to publish the release: github, s3 or dir")
+		signingKey = fs.String("signing-key", "", "armored OpenPGP private key to sign checksums with; "+
+			"its passphrase is read from RELEASE_SIGNING_PASSPHRASE")
+		prerelease = fs.Bool("prerelease", false, "mark the release as a pre-release")
+
+		githubAPI  = fs.String("github-api", release.GitHubAPIURL, "GitHub API URL")
+		githubRepo = fs.String("github-repo", "digitalocean/doctl", "GitHub repository; the token is read from GITHUB_TOKEN")
+
+		s3Endpoint = fs.String("s3-endpoint", "https://s3.amazonaws.com", "S3-compatible storage URL; "+
+			"credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		s3Region = fs.String("s3-region", "us-east-1", "S3 region")
+		s3Bucket = fs.String("s3-bucket", "", "S3 bucket")
+		s3Prefix = fs.String("s3-prefix", "", "S3 key prefix")
+
+		dir = fs.String("dir", "", "directory to publish to")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
 
 	if *path == "" {
-		log.Fatal("path is required")
+		return fmt.Errorf("path is required")
+	}
+
+	var p release.Publisher
+	switch *publisher {
+	case "github":
+		parts := strings.SplitN(*githubRepo, "/", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid GitHub repository %q, expected <owner>/<repo>", *githubRepo)
+		}
+		token := getenv("GITHUB_TOKEN")
+		if token == "" {
+			return fmt.Errorf("GITHUB_TOKEN is required")
+		}
+		p = &release.GitHubPublisher{APIURL: *githubAPI, Owner: parts[0], Repo: parts[1], Token: token, Prerelease: *prerelease}
+	case "s3":
+		if *s3Bucket == "" {
+			return fmt.Errorf("s3-bucket is required")
+		}
+		p = &release.S3Publisher{
+			Endpoint:  *s3Endpoint,
+			Region:    *s3Region,
+			Bucket:    *s3Bucket,
+			Prefix:    *s3Prefix,
+			AccessKey: getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey: getenv("AWS_SECRET_ACCESS_KEY"),
+		}
+	case "dir":
+		if *dir == "" {
+			return fmt.Errorf("dir is required")
+		}
+		p = &release.DirPublisher{Dir: *dir}
+	default:
+		return fmt.Errorf("unknown publisher %q", *publisher)
 	}
 
-	ui := install.UserInfo{
-		User:   *user,
-		Apikey: *apikey,
+	var signer *openpgp.Entity
+	if *signingKey != "" {
+		f, err := os.Open(*signingKey)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		signer, err = release.ReadSigningKey(f, getenv("RELEASE_SIGNING_PASSPHRASE"))
+		if err != nil {
+			return err
+		}
 	}
 
-	err := install.Upload(ui, *ver, *path)
+	r, err := release.Prepare(*ver, *path, signer)
 	if err != nil {
-		log.Fatalf("upload failed: %v", err)
+		return err
+	}
+
+	for _, f := range r.Files {
+		fmt.Println("publishing", f)
 	}
+
+	return p.Publish(r)
 }
@@ -0,0 +1,82 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/digitalocean/doctl/pkg/release"
+	"github.com/digitalocean/doctl/pkg/release/releasetest"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func testEnv(env map[string]string) func(string) string {
+	return func(k string) string { return env[k] }
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-doctl")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	build := filepath.Join(dir, "build")
+	assert.NoError(t, os.Mkdir(build, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(build, "doctl-1.0.0-linux-amd64.tar.gz"), []byte("linux"), 0644))
+
+	entity, err := openpgp.NewEntity("doctl", "test", "doctl@example.com", nil)
+	assert.NoError(t, err)
+	key, err := os.Create(filepath.Join(dir, "key.asc"))
+	assert.NoError(t, err)
+	w, err := armor.Encode(key, openpgp.PrivateKeyType, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, entity.SerializePrivate(w, nil))
+	w.Close()
+	key.Close()
+
+	gh := releasetest.NewGitHub("token")
+	defer gh.Close()
+
+	err = run([]string{"-ver", "1.0.0", "-path", build, "-signing-key", key.Name(), "-github-api", gh.URL},
+		testEnv(map[string]string{"GITHUB_TOKEN": "token"}))
+	assert.NoError(t, err)
+
+	rel := gh.Release("v1.0.0")
+	if assert.NotNil(t, rel) {
+		assert.Contains(t, rel.Assets, "doctl-1.0.0-linux-amd64.tar.gz")
+		assert.Contains(t, rel.Assets, release.ManifestName("1.0.0"))
+		assert.Contains(t, rel.Assets, release.ChecksumsName("1.0.0")+".asc")
+	}
+
+	s3 := releasetest.NewS3("AKID")
+	defer s3.Close()
+
+	err = run([]string{"-ver", "1.0.0", "-path", build, "-publisher", "s3", "-s3-endpoint", s3.URL, "-s3-bucket", "releases"},
+		testEnv(map[string]string{"AWS_ACCESS_KEY_ID": "AKID", "AWS_SECRET_ACCESS_KEY": "secret"}))
+	assert.NoError(t, err)
+	assert.Equal(t, "linux", string(s3.Object("releases/1.0.0/doctl-1.0.0-linux-amd64.tar.gz")))
+
+	mirror := filepath.Join(dir, "mirror")
+	err = run([]string{"-ver", "1.0.0", "-path", build, "-publisher", "dir", "-dir", mirror}, testEnv(nil))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(mirror, "1.0.0", release.ChecksumsName("1.0.0")))
+	assert.NoError(t, err)
+
+	err = run([]string{"-ver", "1.0.0", "-path", build}, testEnv(nil))
+	assert.Error(t, err)
+}
@@ -28,10 +28,10 @@ func Account() *Command {
 	}
 
 	CmdBuilder(cmd, RunAccountGet, "get", "get account", Writer,
-		aliasOpt("g"), displayerType(&account{}), docCategories("account"))
+		aliasOpt("g"), displayerType(&account{}), singleOpt(), docCategories("account"))
 
 	CmdBuilder(cmd, RunAccountRateLimit, "ratelimit", "get API rate limits", Writer,
-		aliasOpt("rl"), displayerType(&rateLimit{}), docCategories("account"))
+		aliasOpt("rl"), displayerType(&rateLimit{}), singleOpt(), docCategories("account"))
 
 	return cmd
 }
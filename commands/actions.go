@@ -36,10 +36,10 @@ func Actions() *Command {
 	}
 
 	CmdBuilder(cmd, RunCmdActionGet, "get ACTIONID", "get action", Writer,
-		aliasOpt("g"), displayerType(&action{}), docCategories("action"))
+		aliasOpt("g"), displayerType(&action{}), singleOpt(), docCategories("action"))
 
 	cmdActionList := CmdBuilder(cmd, RunCmdActionList, "list", "list actions", Writer,
-		aliasOpt("ls"), displayerType(&action{}), docCategories("action"))
+		aliasOpt("ls"), displayerType(&action{}), listOpt(), docCategories("action"))
 	AddStringFlag(cmdActionList, doit.ArgActionResourceType, "", "Action resource type")
 	AddStringFlag(cmdActionList, doit.ArgActionRegion, "", "Action region")
 	AddStringFlag(cmdActionList, doit.ArgActionAfter, "", "Action completed after in RFC3339 format")
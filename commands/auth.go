@@ -23,10 +23,12 @@ import (
 	"net/url"
 	"os"
 	"runtime"
+	"strings"
 
 	"golang.org/x/crypto/ssh/terminal"
 
 	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/doctl/pkg/credentials"
 	"github.com/bryanl/doit-server"
 	"github.com/bryanl/webbrowser"
 	"github.com/gorilla/websocket"
@@ -60,6 +62,7 @@ func Auth() *Command {
 	}
 
 	CmdBuilder(cmd, RunAuthLogin, "login", "login to DigitalOcean account", Writer, docCategories("account"))
+	CmdBuilder(cmd, RunAuthLogout, "logout", "remove the access token from the credential store", Writer, docCategories("account"))
 
 	return cmd
 }
@@ -78,12 +81,19 @@ func RunAuthLogin(c *CmdConfig) error {
 		return err
 	}
 
-	cf, err := doit.NewConfigFile()
+	store, err := c.Doit.CredentialStore()
 	if err != nil {
 		return err
 	}
 
-	err = cf.Set("access-token", token)
+	err = store.Set(doit.AccessTokenKey, strings.TrimSpace(token))
+	if err != nil {
+		return err
+	}
+
+	// Don't leave a previous token in plain text in the configuration file,
+	// where it would take precedence over the stored one.
+	err = eraseConfigToken(store)
 	if err != nil {
 		return err
 	}
@@ -93,6 +103,51 @@ func RunAuthLogin(c *CmdConfig) error {
 	return nil
 }
 
+// RunAuthLogout runs auth logout. It removes the access token from the
+// credential store and the configuration file.
+func RunAuthLogout(c *CmdConfig) error {
+	store, err := c.Doit.CredentialStore()
+	if err != nil {
+		return err
+	}
+
+	err = store.Erase(doit.AccessTokenKey)
+	if err != nil {
+		return err
+	}
+
+	// A token left in the configuration file would still be used.
+	err = eraseConfigToken(store)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.Out, "removed access token")
+
+	return nil
+}
+
+// newConfigStore opens the configuration file store. Tests replace it so
+// they don't touch the real configuration file.
+var newConfigStore = func() (credentials.Store, error) {
+	return doit.NewConfigStore()
+}
+
+// eraseConfigToken removes a plain text access token from the configuration
+// file, unless the configuration file is the credential store in use.
+func eraseConfigToken(store credentials.Store) error {
+	if _, ok := store.(*doit.ConfigStore); ok {
+		return nil
+	}
+
+	cs, err := newConfigStore()
+	if err != nil {
+		return err
+	}
+
+	return cs.Erase(doit.AccessTokenKey)
+}
+
 type doitServerAuth struct {
 	url         string
 	browserOpen func(u string) error
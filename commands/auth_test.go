@@ -14,15 +14,21 @@ limitations under the License.
 package commands
 
 import (
+	"bytes"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
 	"github.com/bryanl/doit-server"
+	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/doctl/pkg/credentials"
+	"github.com/digitalocean/doctl/pkg/credentials/credentialstest"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 )
@@ -30,7 +36,45 @@ import (
 func TestAuthCommand(t *testing.T) {
 	cmd := Auth()
 	assert.NotNil(t, cmd)
-	assertCommandNames(t, cmd, "login")
+	assertCommandNames(t, cmd, "login", "logout")
+}
+
+func TestAuthLogout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doctl-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := credentialstest.NewStore(filepath.Join(dir, "credentials.json"))
+	assert.NoError(t, store.Set(doit.AccessTokenKey, "token"))
+	assert.NoError(t, store.Set("https://example.com", "other"))
+
+	cfgStore := credentialstest.NewStore(filepath.Join(dir, "config.json"))
+	assert.NoError(t, cfgStore.Set(doit.AccessTokenKey, "plain"))
+
+	origNewConfigStore := newConfigStore
+	newConfigStore = func() (credentials.Store, error) { return cfgStore, nil }
+	defer func() { newConfigStore = origNewConfigStore }()
+
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		config.Doit.(*TestConfig).CredentialStoreFn = func() (credentials.Store, error) {
+			return store, nil
+		}
+
+		var buf bytes.Buffer
+		config.Out = &buf
+
+		assert.NoError(t, RunAuthLogout(config))
+		assert.Equal(t, "removed access token\n", buf.String())
+
+		secrets, err := store.Secrets()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"https://example.com": "other"}, secrets)
+
+		_, err = cfgStore.Get(doit.AccessTokenKey)
+		assert.Equal(t, credentials.ErrNotFound, err)
+	})
 }
 
 func TestAuth_retrieveCredentials(t *testing.T) {
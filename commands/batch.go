@@ -0,0 +1,464 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/digitalocean/doctl"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Batch creates the batch command.
+func Batch() *Command {
+	cmd := CmdBuilder(nil, RunBatch, "batch", "run doctl command lines from a file in one process", Writer)
+	cmd.Long = `batch runs doctl command lines from a file, or stdin, in one process
+
+Lines are written without the doctl prefix, e.g. "compute droplet list". Blank lines and lines starting with #
+are ignored. The commands share one API client and response cache, and the output of each is collected into a
+single JSON document with its status.
+
+--access-token, --trace, --trace-file and --trace-unredacted apply to the whole batch and can't be given on a
+line, and lines can't use --watch.`
+
+	AddStringFlagP(cmd, doit.ArgCommandFile, "f", "", "file of command lines, or - for stdin (default stdin)")
+	AddBoolFlag(cmd, doit.ArgContinueOnError, false, "run the remaining lines after one fails")
+	AddIntFlag(cmd, doit.ArgParallel, 1, "how many independent lines to run at once")
+
+	return cmd
+}
+
+// batchResult is the outcome of one line of a batch.
+type batchResult struct {
+	Line     int         `json:"line"`
+	Command  string      `json:"command"`
+	Status   string      `json:"status"`
+	ExitCode int         `json:"exit_code"`
+	Output   interface{} `json:"output,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+const (
+	batchOK      = "ok"
+	batchError   = "error"
+	batchSkipped = "skipped"
+)
+
+// batchOutput is the JSON document batch writes.
+type batchOutput struct {
+	Results   []*batchResult `json:"results"`
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	Skipped   int            `json:"skipped"`
+}
+
+// RunBatch runs the command lines of a file.
+func RunBatch(c *CmdConfig) error {
+	path, err := c.Doit.GetString(c.NS, doit.ArgCommandFile)
+	if err != nil {
+		return err
+	}
+
+	continueOnError, err := c.Doit.GetBool(c.NS, doit.ArgContinueOnError)
+	if err != nil {
+		return err
+	}
+
+	parallel, err := c.Doit.GetInt(c.NS, doit.ArgParallel)
+	if err != nil {
+		return err
+	}
+	if parallel < 1 {
+		return fmt.Errorf("parallel must be at least 1")
+	}
+
+	in := io.Reader(os.Stdin)
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	b := &batch{
+		root:            DoitCmd,
+		config:          c.Doit,
+		newConfig:       NewCmdConfig,
+		continueOnError: continueOnError,
+		parallel:        parallel,
+	}
+
+	out, err := b.run(in)
+	if err != nil {
+		return err
+	}
+
+	if err := writeJSON(out, c.Out); err != nil {
+		return err
+	}
+
+	if out.Failed > 0 {
+		return fmt.Errorf("%d of %d commands failed", out.Failed, len(out.Results))
+	}
+
+	return nil
+}
+
+// batch runs command lines against a command tree. Each line gets its own
+// flags and output, so lines can run in parallel.
+type batch struct {
+	root            *Command
+	config          doit.Config
+	newConfig       func(ns string, dc doit.Config, out io.Writer, args []string) *CmdConfig
+	continueOnError bool
+	parallel        int
+}
+
+// batchWideFlags configure the API client every line of a batch shares, so
+// can only be given to batch itself.
+var batchWideFlags = []string{
+	"access-token",
+	"trace",
+	doit.ArgTraceFile,
+	doit.ArgTraceUnredacted,
+}
+
+type batchLine struct {
+	number int
+	text   string
+}
+
+func (b *batch) run(in io.Reader) (*batchOutput, error) {
+	var lines []batchLine
+	scanner := bufio.NewScanner(in)
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		lines = append(lines, batchLine{number: n, text: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	out := &batchOutput{Results: make([]*batchResult, len(lines))}
+
+	var (
+		mu     sync.Mutex
+		failed bool
+		wg     sync.WaitGroup
+	)
+	work := make(chan int)
+
+	for w := 0; w < b.parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range work {
+				mu.Lock()
+				skip := failed && !b.continueOnError
+				mu.Unlock()
+
+				r := &batchResult{Line: lines[i].number, Command: lines[i].text, Status: batchSkipped}
+				if !skip {
+					r = b.runLine(lines[i])
+				}
+
+				mu.Lock()
+				if r.Status == batchError {
+					failed = true
+				}
+				out.Results[i] = r
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range lines {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	for _, r := range out.Results {
+		switch r.Status {
+		case batchOK:
+			out.Succeeded++
+		case batchError:
+			out.Failed++
+		default:
+			out.Skipped++
+		}
+	}
+
+	return out, nil
+}
+
+// runLine parses a line's flags into a config of its own and runs it.
+func (b *batch) runLine(line batchLine) *batchResult {
+	r := &batchResult{Line: line.number, Command: line.text}
+
+	var buf bytes.Buffer
+	err := b.execute(line.text, &buf)
+
+	if output := bytes.TrimSpace(buf.Bytes()); len(output) > 0 {
+		if json.Valid(output) {
+			r.Output = json.RawMessage(output)
+		} else {
+			r.Output = string(output)
+		}
+	}
+
+	if err != nil {
+		err = doit.NewAPIError(err)
+		r.Status = batchError
+		r.ExitCode = doit.ExitCode(err)
+		r.Error = err.Error()
+		return r
+	}
+
+	r.Status = batchOK
+	return r
+}
+
+func (b *batch) execute(text string, out io.Writer) error {
+	words, err := splitWords(text)
+	if err != nil {
+		return err
+	}
+	if len(words) > 0 && words[0] == b.root.Name() {
+		words = words[1:]
+	}
+
+	found, rest, err := b.root.Find(words)
+	if err != nil {
+		return err
+	}
+
+	cmd := findCommand(b.root, found)
+	if cmd == nil || cmd.runner == nil || found == b.root.Command {
+		return fmt.Errorf("%q is not a command which can be run", text)
+	}
+	switch cmd.Name() {
+	case "batch", "shell":
+		return fmt.Errorf("%s can't be run in a batch", cmd.Name())
+	}
+
+	fs := pflag.NewFlagSet(found.CommandPath(), pflag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	local := found.LocalFlags()
+	local.VisitAll(func(f *pflag.Flag) { cloneFlag(fs, f) })
+	found.InheritedFlags().VisitAll(func(f *pflag.Flag) { cloneFlag(fs, f) })
+
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+
+	for _, name := range batchWideFlags {
+		if fs.Changed(name) {
+			return fmt.Errorf("--%s applies to the whole batch, so can't be given on a line", name)
+		}
+	}
+	if fs.Changed(doit.ArgWatch) {
+		return fmt.Errorf("--%s can't be used in a batch", doit.ArgWatch)
+	}
+
+	dc := b.config
+	if noCache, _ := fs.GetBool(doit.ArgNoCache); noCache || bypassCache(fs, cmd.uncachedFlags) {
+		dc = doit.Uncached(dc)
+	}
+
+	ns := cmdNS(found)
+	bc := &batchConfig{Config: dc, values: map[string]interface{}{}}
+	fs.Visit(func(f *pflag.Flag) {
+		key := f.Name
+		if local.Lookup(f.Name) != nil {
+			key = ns + "." + f.Name
+		}
+		bc.values[key] = flagValue(fs, f)
+	})
+
+	c := b.newConfig(ns, bc, out, fs.Args())
+	c.single = cmd.single
+
+	err = runPreHooks(found, c)
+	if err == nil {
+		err = runCmd(c, cmd.runner)
+		runPostHooks(found, c, err)
+	}
+
+	return err
+}
+
+// findCommand finds the Command wrapping cmd.
+func findCommand(root *Command, cmd *cobra.Command) *Command {
+	if root.Command == cmd {
+		return root
+	}
+
+	for _, c := range root.ChildCommands() {
+		if found := findCommand(c, cmd); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// cloneFlag defines a flag like f in fs, with a value of its own.
+func cloneFlag(fs *pflag.FlagSet, f *pflag.Flag) {
+	if fs.Lookup(f.Name) != nil {
+		return
+	}
+
+	switch f.Value.Type() {
+	case "bool":
+		fs.BoolP(f.Name, f.Shorthand, f.DefValue == "true", f.Usage)
+	case "int":
+		i, _ := strconv.Atoi(f.DefValue)
+		fs.IntP(f.Name, f.Shorthand, i, f.Usage)
+	case "stringSlice":
+		fs.StringSliceP(f.Name, f.Shorthand, sliceDefault(f), f.Usage)
+	default:
+		fs.StringP(f.Name, f.Shorthand, f.DefValue, f.Usage)
+	}
+
+	fs.Lookup(f.Name).NoOptDefVal = f.NoOptDefVal
+}
+
+func flagValue(fs *pflag.FlagSet, f *pflag.Flag) interface{} {
+	switch f.Value.Type() {
+	case "bool":
+		v, _ := fs.GetBool(f.Name)
+		return v
+	case "int":
+		v, _ := fs.GetInt(f.Name)
+		return v
+	case "stringSlice":
+		v, _ := fs.GetStringSlice(f.Name)
+		return v
+	default:
+		return f.Value.String()
+	}
+}
+
+// batchConfig returns the flags given on a batch line, falling back to the
+// configuration for others.
+type batchConfig struct {
+	doit.Config
+	mu     sync.Mutex
+	values map[string]interface{}
+}
+
+var _ doit.Config = &batchConfig{}
+
+func (bc *batchConfig) key(ns, key string) string {
+	if ns == doit.NSRoot {
+		return key
+	}
+
+	return ns + "." + key
+}
+
+func (bc *batchConfig) get(ns, key string) (interface{}, bool) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	v, ok := bc.values[bc.key(ns, key)]
+	return v, ok
+}
+
+// required reports whether an empty value is missing a required flag.
+func (bc *batchConfig) required(ns, key string) error {
+	if ns != doit.NSRoot && viper.GetBool(requiredKey(bc.key(ns, key))) {
+		return doit.NewMissingArgsErr(bc.key(ns, key))
+	}
+
+	return nil
+}
+
+// Set sets a config key.
+func (bc *batchConfig) Set(ns, key string, val interface{}) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	bc.values[bc.key(ns, key)] = val
+}
+
+// GetString returns a config value as a string.
+func (bc *batchConfig) GetString(ns, key string) (string, error) {
+	v, ok := bc.get(ns, key)
+	if !ok {
+		return bc.Config.GetString(ns, key)
+	}
+
+	s := fmt.Sprint(v)
+	if s == "" {
+		return "", bc.required(ns, key)
+	}
+
+	return s, nil
+}
+
+// GetBool returns a config value as a bool.
+func (bc *batchConfig) GetBool(ns, key string) (bool, error) {
+	v, ok := bc.get(ns, key)
+	if !ok {
+		return bc.Config.GetBool(ns, key)
+	}
+
+	b, _ := v.(bool)
+	return b, nil
+}
+
+// GetInt returns a config value as an int.
+func (bc *batchConfig) GetInt(ns, key string) (int, error) {
+	v, ok := bc.get(ns, key)
+	if !ok {
+		return bc.Config.GetInt(ns, key)
+	}
+
+	i, _ := v.(int)
+	if i < 0 {
+		return 0, bc.required(ns, key)
+	}
+
+	return i, nil
+}
+
+// GetStringSlice returns a config value as a string slice.
+func (bc *batchConfig) GetStringSlice(ns, key string) ([]string, error) {
+	v, ok := bc.get(ns, key)
+	if !ok {
+		return bc.Config.GetStringSlice(ns, key)
+	}
+
+	s, _ := v.([]string)
+	return s, nil
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/doctl/do"
+	"github.com/digitalocean/godo"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func testBatch(config *CmdConfig, calls *int32) *batch {
+	root := &Command{Command: &cobra.Command{Use: "doctl"}}
+	root.PersistentFlags().StringP("output", "o", "text", "output")
+	root.PersistentFlags().String(doit.ArgQuery, "", "query")
+	root.PersistentFlags().String("access-token", "", "token")
+	root.PersistentFlags().Bool("trace", false, "trace")
+	root.PersistentFlags().Bool(doit.ArgNoCache, false, "no cache")
+
+	parent := &Command{Command: &cobra.Command{Use: "test"}}
+	root.AddCommand(parent)
+
+	echo := CmdBuilder(parent, func(c *CmdConfig) error {
+		atomic.AddInt32(calls, 1)
+		region, err := c.Doit.GetString(c.NS, doit.ArgRegionSlug)
+		if err != nil {
+			return err
+		}
+		tags, err := c.Doit.GetStringSlice(c.NS, doit.ArgSSHKeys)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.Out, "%s %s %s", strings.Join(c.Args, ","), region, strings.Join(tags, ","))
+		return nil
+	}, "echo", "echo", Writer)
+	AddStringFlag(echo, doit.ArgRegionSlug, "", "region")
+	AddStringSliceFlag(echo, doit.ArgSSHKeys, []string{}, "keys")
+
+	CmdBuilder(parent, func(c *CmdConfig) error {
+		atomic.AddInt32(calls, 1)
+		return c.Display(&domain{domains: do.Domains{{Domain: &godo.Domain{Name: "example.com"}}}})
+	}, "get", "get", Writer, displayerType(&domain{}), singleOpt())
+
+	CmdBuilder(parent, func(c *CmdConfig) error {
+		atomic.AddInt32(calls, 1)
+		return errors.New("failed")
+	}, "fail", "fail", Writer)
+
+	return &batch{
+		root:   root,
+		config: config.Doit,
+		newConfig: func(ns string, dc doit.Config, out io.Writer, args []string) *CmdConfig {
+			c := *config
+			c.NS, c.Doit, c.Out, c.Args = ns, dc, out, args
+			return &c
+		},
+		parallel: 1,
+	}
+}
+
+const batchScript = `# provision
+doctl test echo a b --region nyc3 --ssh-keys x,y
+
+test echo
+test get -o json
+test fail
+test echo after
+`
+
+func TestBatch(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		var calls int32
+		b := testBatch(config, &calls)
+
+		out, err := b.run(strings.NewReader(batchScript))
+		assert.NoError(t, err)
+		assert.Equal(t, 3, out.Succeeded)
+		assert.Equal(t, 1, out.Failed)
+		assert.Equal(t, 1, out.Skipped)
+		assert.Equal(t, int32(4), calls)
+
+		if !assert.Len(t, out.Results, 5) {
+			return
+		}
+
+		r := out.Results[0]
+		assert.Equal(t, 2, r.Line)
+		assert.Equal(t, batchOK, r.Status)
+		assert.Equal(t, "a,b nyc3 x,y", r.Output)
+
+		// Flags from one line don't apply to the next.
+		assert.Nil(t, out.Results[1].Output)
+
+		b2, err := json.Marshal(out.Results[2].Output)
+		assert.NoError(t, err)
+		assert.Contains(t, string(b2), `"name":"example.com"`)
+
+		assert.Equal(t, batchError, out.Results[3].Status)
+		assert.Equal(t, "failed", out.Results[3].Error)
+		assert.Equal(t, doit.ExitError, out.Results[3].ExitCode)
+
+		assert.Equal(t, batchSkipped, out.Results[4].Status)
+	})
+}
+
+func TestBatchContinueOnError(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		var calls int32
+		b := testBatch(config, &calls)
+		b.continueOnError = true
+
+		out, err := b.run(strings.NewReader(batchScript))
+		assert.NoError(t, err)
+		assert.Equal(t, 4, out.Succeeded)
+		assert.Equal(t, 1, out.Failed)
+		assert.Equal(t, "after", out.Results[4].Output)
+	})
+}
+
+func TestBatchParallel(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		var calls int32
+		b := testBatch(config, &calls)
+		b.parallel = 4
+
+		var script []string
+		for i := 0; i < 20; i++ {
+			script = append(script, fmt.Sprintf("test echo %d --region r%d", i, i))
+		}
+
+		out, err := b.run(strings.NewReader(strings.Join(script, "\n")))
+		assert.NoError(t, err)
+		assert.Equal(t, 20, out.Succeeded)
+		for i, r := range out.Results {
+			assert.Equal(t, fmt.Sprintf("%d r%d", i, i), r.Output)
+		}
+	})
+}
+
+func TestBatchInvalidLines(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		var calls int32
+		b := testBatch(config, &calls)
+		b.continueOnError = true
+
+		out, err := b.run(strings.NewReader("test\ntest echo --unknown\ntest echo \"unterminated\nnope"))
+		assert.NoError(t, err)
+		assert.Equal(t, 4, out.Failed)
+		assert.Equal(t, int32(0), calls)
+	})
+}
+
+func TestBatchLineFlags(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		var calls int32
+		b := testBatch(config, &calls)
+		b.continueOnError = true
+
+		var configs []doit.Config
+		newConfig := b.newConfig
+		b.newConfig = func(ns string, dc doit.Config, out io.Writer, args []string) *CmdConfig {
+			configs = append(configs, dc.(*batchConfig).Config)
+			return newConfig(ns, dc, out, args)
+		}
+
+		script := []string{
+			"test get --query name",
+			"test echo --no-cache",
+			"test get --watch",
+			"test get --watch=10s",
+			"test echo --access-token secret",
+			"test echo --trace",
+		}
+
+		out, err := b.run(strings.NewReader(strings.Join(script, "\n")))
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), calls)
+
+		// Get commands are queried as a single item, as on the command line.
+		assert.Equal(t, "example.com", out.Results[0].Output)
+		assert.Equal(t, batchOK, out.Results[1].Status)
+
+		if assert.Len(t, configs, 2) {
+			assert.True(t, configs[0] == config.Doit)
+			assert.False(t, configs[1] == config.Doit)
+		}
+
+		for _, r := range out.Results[2:4] {
+			assert.Equal(t, batchError, r.Status)
+			assert.Equal(t, "--watch can't be used in a batch", r.Error)
+		}
+		for _, r := range out.Results[4:] {
+			assert.Equal(t, batchError, r.Status)
+			assert.Contains(t, r.Error, "applies to the whole batch")
+		}
+	})
+}
@@ -1,4 +1,3 @@
-
 /*
 Copyright 2016 The Doctl Authors All rights reserved.
 Licensed under the Apache License, Version 2.0 (the "License");
@@ -12,46 +11,34 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package install
+package commands
 
 import (
-	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
+	"github.com/digitalocean/doctl/pkg/cache"
+	"github.com/spf13/cobra"
 )
 
-type UserInfo struct {
-	User, Apikey string
-}
-
-func Upload(ui UserInfo, ver, buildPath string) error {
-	bt := NewBintray(ui.User, ui.Apikey)
-
-	fis, err := ioutil.ReadDir(buildPath)
-	if err != nil {
-		return err
+// Cache creates the cache commands heirarchy.
+func Cache() *Command {
+	cmd := &Command{
+		Command: &cobra.Command{
+			Use:   "cache",
+			Short: "cache commands",
+			Long:  "cache is used to manage the local cache of API responses",
+		},
 	}
 
-	for _, fi := range fis {
-		if fi.IsDir() {
-			continue
-		}
-
-		p := filepath.Join(buildPath, fi.Name())
+	CmdBuilder(cmd, RunCacheClear, "clear", "remove all cached API responses", Writer)
 
-		f, err := os.Open(p)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
+	return cmd
+}
 
-		fmt.Println("uploading", fi.Name())
-		err = bt.Upload(f, ver, fi.Name())
-		if err != nil {
-			return err
-		}
+// RunCacheClear removes all cached API responses.
+func RunCacheClear(c *CmdConfig) error {
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return cache.New(dir).Clear()
 }
@@ -0,0 +1,50 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheCommand(t *testing.T) {
+	cmd := Cache()
+	assert.NotNil(t, cmd)
+	assertCommandNames(t, cmd, "clear")
+}
+
+func TestRunCacheClear(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doctl-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer os.Setenv("XDG_CACHE_HOME", os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CACHE_HOME", dir)
+
+	entry := filepath.Join(dir, "doctl", "account", "entry.json")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(entry), 0700))
+	assert.NoError(t, ioutil.WriteFile(entry, []byte("{}"), 0600))
+
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		err := RunCacheClear(config)
+		assert.NoError(t, err)
+	})
+
+	_, err = os.Stat(filepath.Join(dir, "doctl"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/digitalocean/doctl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDropletListReplay(t *testing.T) {
+	os.Setenv(doit.EnvReplay, "testdata/cassettes/droplet_list.json")
+	defer os.Unsetenv(doit.EnvReplay)
+
+	var buf bytes.Buffer
+	config := NewCmdConfig("droplet.list", &doit.LiveConfig{}, &buf, []string{})
+
+	err := RunDropletList(config)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "replayed-droplet")
+}
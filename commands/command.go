@@ -24,6 +24,13 @@ type Command struct {
 	DocCategories []string
 
 	fmtCols []string
+	isList  bool
+	single  bool
+	runner  CmdRunner
+
+	// uncachedFlags are boolean flags which bypass the response cache when
+	// set.
+	uncachedFlags []string
 
 	childCommands []*Command
 	IsIndex       bool
@@ -41,3 +48,8 @@ func (c *Command) AddCommand(commands ...*Command) {
 func (c *Command) ChildCommands() []*Command {
 	return c.childCommands
 }
+
+// Columns returns the columns the command's output can be formatted with.
+func (c *Command) Columns() []string {
+	return c.fmtCols
+}
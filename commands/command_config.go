@@ -36,6 +36,21 @@ func displayerType(d Displayable) cmdOption {
 	}
 }
 
+// listOpt adds filtering, sorting and limit flags to a list command.
+func listOpt() cmdOption {
+	return func(c *Command) {
+		c.isList = true
+	}
+}
+
+// singleOpt marks a command which displays a single item, so queries
+// apply to the item rather than to a list holding it.
+func singleOpt() cmdOption {
+	return func(c *Command) {
+		c.single = true
+	}
+}
+
 // hiddenCmd make a command hidden.
 func hiddenCmd() cmdOption {
 	return func(c *Command) {
@@ -49,3 +64,11 @@ func docCategories(categories ...string) cmdOption {
 		c.DocCategories = categories
 	}
 }
+
+// uncachedWith makes a command bypass the response cache when any of the
+// given boolean flags are set.
+func uncachedWith(flags ...string) cmdOption {
+	return func(c *Command) {
+		c.uncachedFlags = flags
+	}
+}
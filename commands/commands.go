@@ -36,10 +36,13 @@ type displayer struct {
 	config doit.Config
 	item   Displayable
 	out    io.Writer
+
+	// single is set when item holds the single result of a command.
+	single bool
 }
 
 func (d *displayer) Display() error {
-	output, err := doit.DoitConfig.GetString(doit.NSRoot, "output")
+	output, err := d.config.GetString(doit.NSRoot, "output")
 	if err != nil {
 		return nil
 	}
@@ -48,6 +51,15 @@ func (d *displayer) Display() error {
 		output = "text"
 	}
 
+	query, err := d.config.GetString(doit.NSRoot, doit.ArgQuery)
+	if err != nil {
+		return err
+	}
+
+	if query != "" {
+		return applyQuery(d.item, query, d.single, d.out)
+	}
+
 	switch output {
 	case "json":
 		return d.item.JSON(d.out)
@@ -14,6 +14,7 @@ limitations under the License.
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"sort"
@@ -22,6 +23,7 @@ import (
 	"github.com/digitalocean/doctl"
 	"github.com/digitalocean/doctl/do"
 	domocks "github.com/digitalocean/doctl/do/mocks"
+	"github.com/digitalocean/doctl/pkg/credentials"
 	"github.com/digitalocean/doctl/pkg/runner"
 	"github.com/digitalocean/godo"
 	"github.com/spf13/viper"
@@ -141,6 +143,7 @@ type tcMocks struct {
 	domains           domocks.DomainsService
 	actions           domocks.ActionsService
 	account           domocks.AccountService
+	tags              domocks.TagsService
 }
 
 func withTestClient(t *testing.T, tFn testFn) {
@@ -171,6 +174,7 @@ func withTestClient(t *testing.T, tFn testFn) {
 		Domains:           func() do.DomainsService { return &tm.domains },
 		Actions:           func() do.ActionsService { return &tm.actions },
 		Account:           func() do.AccountService { return &tm.account },
+		Tags:              func() do.TagsService { return &tm.tags },
 	}
 
 	tFn(config, tm)
@@ -187,11 +191,13 @@ func withTestClient(t *testing.T, tFn testFn) {
 	assert.True(t, tm.regions.AssertExpectations(t))
 	assert.True(t, tm.sizes.AssertExpectations(t))
 	assert.True(t, tm.keys.AssertExpectations(t))
+	assert.True(t, tm.tags.AssertExpectations(t))
 }
 
 type TestConfig struct {
-	SSHFn func(user, host, keyPath string, port int) runner.Runner
-	v     *viper.Viper
+	SSHFn             func(user, host, keyPath string, port int) runner.Runner
+	CredentialStoreFn func() (credentials.Store, error)
+	v                 *viper.Viper
 }
 
 var _ doit.Config = &TestConfig{}
@@ -201,6 +207,9 @@ func NewTestConfig() *TestConfig {
 		SSHFn: func(u, h, kp string, p int) runner.Runner {
 			return &doit.MockRunner{}
 		},
+		CredentialStoreFn: func() (credentials.Store, error) {
+			return nil, errors.New("no credential store")
+		},
 		v: viper.New(),
 	}
 }
@@ -211,6 +220,10 @@ func (c *TestConfig) GetGodoClient(trace bool) *godo.Client {
 	return &godo.Client{}
 }
 
+func (c *TestConfig) CredentialStore() (credentials.Store, error) {
+	return c.CredentialStoreFn()
+}
+
 func (c *TestConfig) SSH(user, host, keyPath string, port int) runner.Runner {
 	return c.SSHFn(user, host, keyPath, port)
 }
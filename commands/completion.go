@@ -0,0 +1,517 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/digitalocean/doctl"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Completion creates the completion commands heirarchy.
+func Completion() *Command {
+	cmd := &Command{
+		Command: &cobra.Command{
+			Use:   "completion",
+			Short: "completion commands",
+			Long: `completion is used to generate shell completion scripts
+
+To load completions in bash, add the following to ~/.bashrc:
+
+  source <(doctl completion bash)
+
+In zsh, add the following to ~/.zshrc:
+
+  source <(doctl completion zsh)
+
+In fish, run:
+
+  doctl completion fish > ~/.config/fish/completions/doctl.fish`,
+		},
+	}
+
+	CmdBuilder(cmd, RunCompletionBash, "bash", "generate bash completion script", Writer)
+	CmdBuilder(cmd, RunCompletionZsh, "zsh", "generate zsh completion script", Writer)
+	CmdBuilder(cmd, RunCompletionFish, "fish", "generate fish completion script", Writer)
+
+	CmdBuilder(cmd, RunCompletionComplete, "__complete", "list completion candidates", Writer,
+		hiddenCmd())
+
+	return cmd
+}
+
+const bashCompletion = `# bash completion for doctl
+
+_doctl()
+{
+    local line="${COMP_LINE:0:COMP_POINT}" cur c
+    local -a words
+    read -ra words <<< "$line"
+
+    if [[ "$line" == *" " ]]; then
+        cur=""
+    else
+        cur="${words[${#words[@]}-1]}"
+        unset "words[${#words[@]}-1]"
+    fi
+
+    # bash splits words on ':' and '=', so only the part of each candidate
+    # after the last separator replaces the current word.
+    local prefix="${cur%"${COMP_WORDS[COMP_CWORD]}"}"
+
+    local IFS=$'\n'
+    COMPREPLY=()
+    for c in $("${COMP_WORDS[0]}" completion __complete -- "${words[@]:1}" "$cur" 2>/dev/null); do
+        COMPREPLY+=("${c#"$prefix"}")
+    done
+}
+
+complete -o default -F _doctl doctl
+`
+
+const zshCompletion = `#compdef doctl
+
+_doctl()
+{
+    local -a candidates
+    candidates=(${(f)"$(${words[1]} completion __complete -- "${(@)words[2,CURRENT-1]}" "${words[CURRENT]}" 2>/dev/null)"})
+
+    if (( ${#candidates} )); then
+        compadd -Q -S '' -a candidates
+    else
+        _files
+    fi
+}
+
+if [ "$funcstack[1]" = "_doctl" ]; then
+    _doctl "$@"
+else
+    compdef _doctl doctl
+fi
+`
+
+const fishCompletion = `# fish completion for doctl
+
+function __doctl_complete
+    set -l tokens (commandline -opc)
+    set -l current (commandline -ct)
+    $tokens[1] completion __complete -- $tokens[2..-1] $current 2>/dev/null
+end
+
+complete -c doctl -f -a '(__doctl_complete)'
+`
+
+// RunCompletionBash generates a bash completion script.
+func RunCompletionBash(c *CmdConfig) error {
+	_, err := fmt.Fprint(c.Out, bashCompletion)
+	return err
+}
+
+// RunCompletionZsh generates a zsh completion script.
+func RunCompletionZsh(c *CmdConfig) error {
+	_, err := fmt.Fprint(c.Out, zshCompletion)
+	return err
+}
+
+// RunCompletionFish generates a fish completion script.
+func RunCompletionFish(c *CmdConfig) error {
+	_, err := fmt.Fprint(c.Out, fishCompletion)
+	return err
+}
+
+// RunCompletionComplete lists the candidates for the last of its arguments,
+// given the preceding words of the command line. Lookups are answered from
+// the response cache where possible, and lookup errors are ignored so a
+// failed API call never breaks the user's shell.
+func RunCompletionComplete(c *CmdConfig) error {
+	words := c.Args
+	cur := ""
+	if len(words) > 0 {
+		cur = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	for _, candidate := range complete(DoitCmd.Command, c, words, cur) {
+		fmt.Fprintln(c.Out, candidate)
+	}
+
+	return nil
+}
+
+// completer lists candidate values for a flag or argument.
+type completer func(c *CmdConfig) ([]string, error)
+
+// flagCompleters complete the values of flags by name.
+var flagCompleters = map[string]completer{
+	doit.ArgRegionSlug: completeRegions,
+	doit.ArgSizeSlug:   completeSizes,
+	doit.ArgImage:      completeImages,
+	doit.ArgSSHKeys:    completeKeys,
+	doit.ArgDropletID:  completeDroplets,
+	doit.ArgDomainName: completeDomains,
+	doit.ArgOutput: func(c *CmdConfig) ([]string, error) {
+		return []string{"json", "text"}, nil
+	},
+}
+
+// argCompleters complete positional arguments by the placeholders used in
+// a command's usage line, e.g. "get <droplet>".
+var argCompleters = map[string]completer{
+	"droplet":         completeDroplets,
+	"droplet-id":      completeDroplets,
+	"domain":          completeDomains,
+	"floating-ip":     completeFloatingIPs,
+	"image":           completeImages,
+	"image-id":        completeImages,
+	"image-slug":      completeImages,
+	"image-name":      completeImageNames,
+	"key-id":          completeKeys,
+	"key-fingerprint": completeKeys,
+	"key-name":        completeKeyNames,
+	"plugin":          completePlugins,
+}
+
+var placeholderRe = regexp.MustCompile(`<([^>]+)>`)
+
+// complete returns the candidates for cur, after walking words from root to
+// find the command being completed.
+func complete(root *cobra.Command, c *CmdConfig, words []string, cur string) []string {
+	cmd := root
+	var pending *pflag.Flag
+	var positional int
+
+	for _, w := range words {
+		switch {
+		case pending != nil:
+			pending = nil
+		case w == "--":
+		case strings.HasPrefix(w, "-"):
+			if strings.Contains(w, "=") {
+				continue
+			}
+			if f := lookupFlag(cmd, w); f != nil && !isBoolFlag(f) {
+				pending = f
+			}
+		default:
+			if sub := findSubCommand(cmd, w); sub != nil {
+				cmd = sub
+				positional = 0
+				continue
+			}
+			positional++
+		}
+	}
+
+	var candidates []string
+	prefix := ""
+
+	switch {
+	case pending != nil:
+		candidates = flagValues(c, pending, cur)
+	case strings.HasPrefix(cur, "--") && strings.Contains(cur, "="):
+		i := strings.Index(cur, "=")
+		f := lookupFlag(cmd, cur[:i])
+		if f == nil {
+			return nil
+		}
+		prefix, cur = cur[:i+1], cur[i+1:]
+		candidates = flagValues(c, f, cur)
+	case strings.HasPrefix(cur, "-"):
+		candidates = flagNames(cmd)
+	case cmd.HasSubCommands():
+		for _, sub := range cmd.Commands() {
+			if !sub.Hidden {
+				candidates = append(candidates, sub.Name())
+			}
+		}
+	default:
+		candidates = argValues(c, cmd, positional)
+	}
+
+	return matching(candidates, prefix, cur)
+}
+
+// flagValues lists values for flag f. Values of list flags are completed
+// after the last comma in cur.
+func flagValues(c *CmdConfig, f *pflag.Flag, cur string) []string {
+	fn, ok := flagCompleters[f.Name]
+	if !ok {
+		return nil
+	}
+
+	values, _ := fn(c)
+
+	if f.Value.Type() == "stringSlice" {
+		if i := strings.LastIndex(cur, ","); i >= 0 {
+			for j := range values {
+				values[j] = cur[:i+1] + values[j]
+			}
+		}
+	}
+
+	return values
+}
+
+// argValues lists values for the nth positional argument of cmd.
+func argValues(c *CmdConfig, cmd *cobra.Command, n int) []string {
+	placeholders := placeholderRe.FindAllStringSubmatch(cmd.Use, -1)
+	if len(placeholders) == 0 {
+		return nil
+	}
+
+	if n >= len(placeholders) {
+		if !strings.Contains(cmd.Use, "...") {
+			return nil
+		}
+		n = len(placeholders) - 1
+	}
+
+	var values []string
+	seen := map[uintptr]bool{}
+	for _, name := range strings.Split(placeholders[n][1], "|") {
+		fn, ok := argCompleters[strings.TrimSpace(name)]
+		if !ok || seen[reflect.ValueOf(fn).Pointer()] {
+			continue
+		}
+		seen[reflect.ValueOf(fn).Pointer()] = true
+
+		v, _ := fn(c)
+		values = append(values, v...)
+	}
+
+	return values
+}
+
+// matching returns the sorted, unique candidates which start with cur, each
+// with prefix prepended.
+func matching(candidates []string, prefix, cur string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, candidate := range candidates {
+		candidate = prefix + candidate
+		if candidate == prefix || seen[candidate] || !strings.HasPrefix(candidate, prefix+cur) {
+			continue
+		}
+		seen[candidate] = true
+		out = append(out, candidate)
+	}
+
+	sort.Strings(out)
+	return out
+}
+
+// findSubCommand finds the sub command of cmd named, or aliased, name.
+func findSubCommand(cmd *cobra.Command, name string) *cobra.Command {
+	for _, sub := range cmd.Commands() {
+		if sub.Name() == name || sub.HasAlias(name) {
+			return sub
+		}
+	}
+
+	return nil
+}
+
+// lookupFlag finds a flag given on the command line, e.g. "--region" or
+// "-o", which applies to cmd.
+func lookupFlag(cmd *cobra.Command, arg string) *pflag.Flag {
+	var found *pflag.Flag
+	visitFlags(cmd, func(f *pflag.Flag) {
+		if found != nil {
+			return
+		}
+		if arg == "--"+f.Name || (f.Shorthand != "" && arg == "-"+f.Shorthand) {
+			found = f
+		}
+	})
+
+	return found
+}
+
+// flagNames lists the flags which apply to cmd.
+func flagNames(cmd *cobra.Command) []string {
+	var names []string
+	visitFlags(cmd, func(f *pflag.Flag) {
+		if !f.Hidden {
+			names = append(names, "--"+f.Name)
+		}
+	})
+
+	return names
+}
+
+// visitFlags visits the local flags of cmd and the persistent flags of it
+// and its parents.
+func visitFlags(cmd *cobra.Command, fn func(*pflag.Flag)) {
+	cmd.Flags().VisitAll(fn)
+	for p := cmd; p != nil; p = p.Parent() {
+		p.PersistentFlags().VisitAll(fn)
+	}
+}
+
+func isBoolFlag(f *pflag.Flag) bool {
+	return f.Value.Type() == "bool" || f.NoOptDefVal != ""
+}
+
+func completeRegions(c *CmdConfig) ([]string, error) {
+	list, err := c.Regions().List()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, r := range list {
+		out = append(out, r.Slug)
+	}
+
+	return out, nil
+}
+
+func completeSizes(c *CmdConfig) ([]string, error) {
+	list, err := c.Sizes().List()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, s := range list {
+		out = append(out, s.Slug)
+	}
+
+	return out, nil
+}
+
+func completeImages(c *CmdConfig) ([]string, error) {
+	list, err := c.Images().List(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, i := range list {
+		if i.Slug != "" {
+			out = append(out, i.Slug)
+		} else {
+			out = append(out, strconv.Itoa(i.ID))
+		}
+	}
+
+	return out, nil
+}
+
+func completeImageNames(c *CmdConfig) ([]string, error) {
+	list, err := c.Images().List(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, i := range list {
+		if i.Name != "" {
+			out = append(out, i.Name)
+		}
+	}
+
+	return out, nil
+}
+
+func completeKeyNames(c *CmdConfig) ([]string, error) {
+	list, err := c.Keys().List()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, k := range list {
+		out = append(out, k.Name)
+	}
+
+	return out, nil
+}
+
+func completeKeys(c *CmdConfig) ([]string, error) {
+	list, err := c.Keys().List()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, k := range list {
+		out = append(out, k.Fingerprint)
+	}
+
+	return out, nil
+}
+
+func completeDroplets(c *CmdConfig) ([]string, error) {
+	list, err := c.Droplets().List()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, d := range list {
+		out = append(out, d.Name, strconv.Itoa(d.ID))
+	}
+
+	return out, nil
+}
+
+func completeDomains(c *CmdConfig) ([]string, error) {
+	list, err := c.Domains().List()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, d := range list {
+		out = append(out, d.Name)
+	}
+
+	return out, nil
+}
+
+func completeFloatingIPs(c *CmdConfig) ([]string, error) {
+	list, err := c.FloatingIPs().List()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, fip := range list {
+		out = append(out, fip.IP)
+	}
+
+	return out, nil
+}
+
+func completePlugins(c *CmdConfig) ([]string, error) {
+	plugs, err := searchPlugins()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, p := range plugs {
+		out = append(out, p.Name)
+	}
+
+	return out, nil
+}
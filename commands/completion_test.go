@@ -0,0 +1,216 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/digitalocean/doctl/do"
+	"github.com/digitalocean/doctl/pkg/cache"
+	"github.com/digitalocean/godo"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func testCompletionRoot() *cobra.Command {
+	root := &cobra.Command{Use: "doctl"}
+	root.PersistentFlags().StringP("output", "o", "text", "output format")
+	root.PersistentFlags().StringP("access-token", "t", "", "API access token")
+	root.AddCommand(computeCmd().Command)
+	return root
+}
+
+func TestCompletionCommand(t *testing.T) {
+	cmd := Completion()
+	assert.NotNil(t, cmd)
+	assertCommandNames(t, cmd, "__complete", "bash", "fish", "zsh")
+}
+
+func TestRunCompletionScripts(t *testing.T) {
+	runners := map[string]CmdRunner{
+		"complete -o default -F _doctl doctl": RunCompletionBash,
+		"compdef _doctl doctl":                RunCompletionZsh,
+		"complete -c doctl":                   RunCompletionFish,
+	}
+
+	for want, fn := range runners {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			var buf bytes.Buffer
+			config.Out = &buf
+
+			err := fn(config)
+			assert.NoError(t, err)
+			assert.Contains(t, buf.String(), want)
+			assert.Contains(t, buf.String(), "completion __complete --")
+		})
+	}
+}
+
+func TestCompleteCommands(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		got := complete(testCompletionRoot(), config, nil, "com")
+		assert.Equal(t, []string{"compute"}, got)
+
+		got = complete(testCompletionRoot(), config, []string{"compute"}, "dr")
+		assert.Equal(t, []string{"droplet", "droplet-action"}, got)
+
+		got = complete(testCompletionRoot(), config, []string{"-o", "json", "compute", "d"}, "l")
+		assert.Equal(t, []string{"list"}, got)
+	})
+}
+
+func TestCompleteFlags(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		got := complete(testCompletionRoot(), config, []string{"compute", "droplet", "create"}, "--re")
+		assert.Equal(t, []string{"--region"}, got)
+
+		got = complete(testCompletionRoot(), config, []string{"compute", "droplet", "create"}, "--ou")
+		assert.Equal(t, []string{"--output"}, got)
+
+		got = complete(testCompletionRoot(), config, []string{"-o"}, "j")
+		assert.Equal(t, []string{"json"}, got)
+	})
+}
+
+func TestCompleteFlagValues(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.regions.On("List").Return(testRegionList, nil)
+		tm.sizes.On("List").Return(testSizeList, nil)
+		tm.images.On("List", false).Return(do.Images{
+			{Image: &godo.Image{ID: 1, Slug: "ubuntu-16-04-x64"}},
+			{Image: &godo.Image{ID: 2, Name: "snapshot"}},
+		}, nil)
+		tm.keys.On("List").Return(testKeyList, nil)
+
+		create := []string{"compute", "droplet", "create", "web"}
+
+		got := complete(testCompletionRoot(), config, append(create, "--region"), "")
+		assert.Equal(t, []string{"dev0"}, got)
+
+		got = complete(testCompletionRoot(), config, create, "--size=sm")
+		assert.Equal(t, []string{"--size=small"}, got)
+
+		got = complete(testCompletionRoot(), config, append(create, "--image"), "")
+		assert.Equal(t, []string{"2", "ubuntu-16-04-x64"}, got)
+
+		got = complete(testCompletionRoot(), config, append(create, "--ssh-keys"), "1,3b")
+		assert.Equal(t, []string{"1," + testKey.Fingerprint}, got)
+	})
+}
+
+func TestCompleteArgs(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.droplets.On("List").Return(testDropletList, nil)
+		tm.domains.On("List").Return(testDomainList, nil)
+		tm.floatingIPs.On("List").Return(testFloatingIPList, nil)
+
+		got := complete(testCompletionRoot(), config, []string{"compute", "droplet", "get"}, "a")
+		assert.Equal(t, []string{"a-droplet", "another-droplet"}, got)
+
+		got = complete(testCompletionRoot(), config, []string{"compute", "droplet", "delete", "1"}, "")
+		assert.Equal(t, []string{"1", "3", "a-droplet", "another-droplet"}, got)
+
+		got = complete(testCompletionRoot(), config, []string{"compute", "droplet", "get", "1"}, "")
+		assert.Empty(t, got)
+
+		got = complete(testCompletionRoot(), config, []string{"compute", "domain", "get"}, "ex")
+		assert.Equal(t, []string{"example.com"}, got)
+
+		got = complete(testCompletionRoot(), config, []string{"compute", "floating-ip", "get"}, "127")
+		assert.Equal(t, []string{"127.0.0.1"}, got)
+	})
+}
+
+func TestCompleteImageAndKeyArgs(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.images.On("List", false).Return(do.Images{
+			{Image: &godo.Image{ID: 1, Slug: "ubuntu-16-04-x64", Name: "16.04 x64", Public: true}},
+			{Image: &godo.Image{ID: 2, Name: "snapshot"}},
+		}, nil)
+		tm.keys.On("List").Return(do.SSHKeys{
+			{Key: &godo.Key{ID: 1, Name: "deploy", Fingerprint: "3b:16"}},
+		}, nil)
+
+		got := complete(testCompletionRoot(), config, []string{"compute", "image", "get"}, "")
+		assert.Equal(t, []string{"16.04 x64", "2", "snapshot", "ubuntu-16-04-x64"}, got)
+
+		got = complete(testCompletionRoot(), config, []string{"compute", "image-action", "transfer"}, "")
+		assert.Equal(t, []string{"2", "ubuntu-16-04-x64"}, got)
+
+		got = complete(testCompletionRoot(), config, []string{"compute", "ssh-key", "get"}, "")
+		assert.Equal(t, []string{"3b:16", "deploy"}, got)
+	})
+}
+
+func TestCompletionCached(t *testing.T) {
+	bodies := map[string]string{
+		"/v2/droplets":     `{"droplets":[{"id":1,"name":"web-1"}],"links":{}}`,
+		"/v2/account/keys": `{"ssh_keys":[{"id":1,"name":"laptop","fingerprint":"aa:bb"}],"links":{}}`,
+		"/v2/domains":      `{"domains":[{"name":"example.com"}],"links":{}}`,
+		"/v2/floating_ips": `{"floating_ips":[{"ip":"1.2.3.4"}],"links":{}}`,
+	}
+	hits := map[string]int{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, bodies[r.URL.Path])
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "doctl-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client := godo.NewClient(&http.Client{Transport: cache.New(dir).Transport(http.DefaultTransport)})
+	client.BaseURL, err = url.Parse(ts.URL + "/")
+	assert.NoError(t, err)
+
+	c := &CmdConfig{
+		Keys:        func() do.KeysService { return do.NewKeysService(client) },
+		Droplets:    func() do.DropletsService { return do.NewDropletsService(client) },
+		Domains:     func() do.DomainsService { return do.NewDomainsService(client) },
+		FloatingIPs: func() do.FloatingIPsService { return do.NewFloatingIPsService(client) },
+	}
+
+	cases := []struct {
+		fn       completer
+		expected []string
+	}{
+		{fn: completeDroplets, expected: []string{"web-1", "1"}},
+		{fn: completeKeys, expected: []string{"aa:bb"}},
+		{fn: completeKeyNames, expected: []string{"laptop"}},
+		{fn: completeDomains, expected: []string{"example.com"}},
+		{fn: completeFloatingIPs, expected: []string{"1.2.3.4"}},
+	}
+
+	for i := 0; i < 2; i++ {
+		for _, tc := range cases {
+			out, err := tc.fn(c)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, out)
+		}
+	}
+
+	for path := range bodies {
+		assert.Equal(t, 1, hits[path], path)
+	}
+}
@@ -14,6 +14,8 @@ limitations under the License.
 package commands
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -23,6 +25,7 @@ import (
 	"github.com/digitalocean/doctl/do"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -40,6 +43,12 @@ var Token string
 // Output holds the global output format.
 var Output string
 
+// Query holds the global JMESPath output query.
+var Query string
+
+// NoCache bypasses the response cache.
+var NoCache bool
+
 // Verbose toggles verbose output.
 var Verbose bool
 
@@ -51,13 +60,36 @@ var Writer = os.Stdout
 // Trace toggles http tracing output.
 var Trace bool
 
+// TraceFile is the HAR file http traffic is recorded to.
+var TraceFile string
+
+// TraceUnredacted disables redaction of secrets in traces.
+var TraceUnredacted bool
+
+// errOperationAborted is returned when a user declines to confirm an action.
+var errOperationAborted = errors.New("operation aborted")
+
+// retrieveUserInput prompts the user with a message and returns their reply.
+var retrieveUserInput = func(message string) (string, error) {
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Fprint(os.Stderr, message)
+	return reader.ReadString('\n')
+}
+
 func init() {
 	viper.SetConfigType("yaml")
 
 	DoitCmd.PersistentFlags().StringVarP(&Token, "access-token", "t", "", "DigitalOcean API V2 Access Token")
 	DoitCmd.PersistentFlags().StringVarP(&Output, "output", "o", "text", "output formt [text|json]")
+	DoitCmd.PersistentFlags().StringVarP(&Query, doit.ArgQuery, "", "",
+		"JMESPath query to apply to the JSON output. Get commands are queried as a single object")
+	DoitCmd.PersistentFlags().BoolVarP(&NoCache, doit.ArgNoCache, "", false, "don't use cached API responses")
 	DoitCmd.PersistentFlags().BoolVarP(&Verbose, "verbose", "v", false, "verbose output")
-	DoitCmd.PersistentFlags().BoolVarP(&Trace, "trace", "", false, "verbose output")
+	DoitCmd.PersistentFlags().BoolVarP(&Trace, "trace", "", false, "print HTTP requests and responses to stderr")
+	DoitCmd.PersistentFlags().StringVarP(&TraceFile, doit.ArgTraceFile, "", "",
+		"record HTTP requests and responses to a HAR file")
+	DoitCmd.PersistentFlags().BoolVarP(&TraceUnredacted, doit.ArgTraceUnredacted, "", false,
+		"don't redact access tokens and user data in traces")
 }
 
 // LoadConfig loads out configuration.
@@ -75,8 +107,18 @@ func LoadConfig() error {
 	return viper.ReadConfig(r)
 }
 
-// Init initializes the root command.
+// Init initializes the root command, including the commands of any plugins
+// found on the PATH.
 func Init() *Command {
+	InitBuiltin()
+	addPluginCommands(DoitCmd)
+
+	return DoitCmd
+}
+
+// InitBuiltin initializes the root command with only doctl's own commands,
+// so the tree doesn't depend on the plugins installed.
+func InitBuiltin() *Command {
 	initializeConfig()
 	addCommands()
 
@@ -87,7 +129,11 @@ func Init() *Command {
 func addCommands() {
 	DoitCmd.AddCommand(Account())
 	DoitCmd.AddCommand(Auth())
+	DoitCmd.AddCommand(Batch())
+	DoitCmd.AddCommand(Cache())
+	DoitCmd.AddCommand(Completion())
 	DoitCmd.AddCommand(computeCmd())
+	DoitCmd.AddCommand(Shell())
 	DoitCmd.AddCommand(Version())
 }
 
@@ -122,10 +168,20 @@ func initFlags() {
 	viper.BindEnv("access-token", "DIGITALOCEAN_ACCESS_TOKEN")
 	viper.BindPFlag("access-token", DoitCmd.PersistentFlags().Lookup("access-token"))
 	viper.BindPFlag("output", DoitCmd.PersistentFlags().Lookup("output"))
+	viper.BindPFlag(doit.ArgQuery, DoitCmd.PersistentFlags().Lookup(doit.ArgQuery))
+	viper.BindPFlag(doit.ArgNoCache, DoitCmd.PersistentFlags().Lookup(doit.ArgNoCache))
+	viper.BindPFlag(doit.ArgTraceFile, DoitCmd.PersistentFlags().Lookup(doit.ArgTraceFile))
+	viper.BindPFlag(doit.ArgTraceUnredacted, DoitCmd.PersistentFlags().Lookup(doit.ArgTraceUnredacted))
+	viper.BindEnv(doit.ArgUpdateCheck, "DOCTL_UPDATE_CHECK")
+	viper.BindEnv(doit.ArgUpdateURL, "DOCTL_UPDATE_URL")
+	viper.BindEnv(doit.ArgCredentialStore, "DOCTL_CREDENTIAL_STORE")
+	viper.BindEnv(doit.ArgCredentialHelper, "DOCTL_CREDENTIAL_HELPER")
+	viper.BindEnv(doit.ArgCredentialFile, "DOCTL_CREDENTIAL_FILE")
 }
 
 func loadDefaultSettings() {
 	viper.SetDefault("output", "text")
+	viper.SetDefault(doit.ArgUpdateCheck, true)
 }
 
 // InitializeConfig initializes the doit configuration.
@@ -141,6 +197,10 @@ func initializeConfig() {
 	if DoitCmd.PersistentFlags().Lookup("output").Changed {
 		viper.Set("output", Output)
 	}
+
+	if DoitCmd.PersistentFlags().Lookup(doit.ArgQuery).Changed {
+		viper.Set(doit.ArgQuery, Query)
+	}
 }
 
 type flagOpt func(c *Command, name, key string)
@@ -162,8 +222,13 @@ func requiredKey(key string) string {
 
 // AddStringFlag adds a string flag to a command.
 func AddStringFlag(cmd *Command, name, dflt, desc string, opts ...flagOpt) {
+	AddStringFlagP(cmd, name, "", dflt, desc, opts...)
+}
+
+// AddStringFlagP adds a string flag with a shorthand to a command.
+func AddStringFlagP(cmd *Command, name, shorthand, dflt, desc string, opts ...flagOpt) {
 	fn := flagName(cmd, name)
-	cmd.Flags().String(name, dflt, desc)
+	cmd.Flags().StringP(name, shorthand, dflt, desc)
 
 	for _, o := range opts {
 		o(cmd, name, fn)
@@ -246,6 +311,17 @@ type CmdConfig struct {
 	Domains           func() do.DomainsService
 	Actions           func() do.ActionsService
 	Account           func() do.AccountService
+	Tags              func() do.TagsService
+
+	// single is set when the command displays a single item.
+	single bool
+
+	// displayFn replaces the default display when set.
+	displayFn func(Displayable) error
+
+	// displayed is the last item displayed, which post-command hooks
+	// receive as the command's result.
+	displayed Displayable
 }
 
 // NewCmdConfig creates an instance of a CmdConfig.
@@ -270,41 +346,79 @@ func NewCmdConfig(ns string, dc doit.Config, out io.Writer, args []string) *CmdC
 		Domains:           func() do.DomainsService { return do.NewDomainsService(godoClient) },
 		Actions:           func() do.ActionsService { return do.NewActionsService(godoClient) },
 		Account:           func() do.AccountService { return do.NewAccountService(godoClient) },
+		Tags:              func() do.TagsService { return do.NewTagsService(godoClient) },
 	}
 }
 
 // Display displayes the output from a command.
 func (c *CmdConfig) Display(d Displayable) error {
+	d, err := selectRows(c, d)
+	if err != nil {
+		return err
+	}
+	c.displayed = d
+
+	if c.displayFn != nil {
+		return c.displayFn(d)
+	}
+
 	dc := &displayer{
 		ns:     c.NS,
 		config: c.Doit,
 		item:   d,
 		out:    c.Out,
+		single: c.single,
 	}
 
 	return dc.Display()
 }
 
+// runCmd runs cr once, or repeatedly when watching.
+func runCmd(c *CmdConfig, cr CmdRunner) error {
+	interval, err := watchInterval(c)
+	if err != nil {
+		return err
+	}
+
+	if interval > 0 {
+		return watch(c, cr, interval)
+	}
+
+	return cr(c)
+}
+
 // CmdBuilder builds a new command.
 func CmdBuilder(parent *Command, cr CmdRunner, cliText, desc string, out io.Writer, options ...cmdOption) *Command {
+	c := &Command{runner: cr}
+
 	cc := &cobra.Command{
 		Use:   cliText,
 		Short: desc,
 		Long:  desc,
 		Run: func(cmd *cobra.Command, args []string) {
-			c := NewCmdConfig(
+			dc := doit.DoitConfig
+			if bypassCache(cmd.Flags(), c.uncachedFlags) {
+				dc = doit.Uncached(dc)
+			}
+
+			config := NewCmdConfig(
 				cmdNS(cmd),
-				doit.DoitConfig,
+				dc,
 				out,
 				args,
 			)
+			config.single = c.single
 
-			err := cr(c)
+			err := runPreHooks(cmd, config)
+			if err == nil {
+				err = runCmd(config, cr)
+				runPostHooks(cmd, config, err)
+			}
 			checkErr(err, cmd)
 		},
 	}
 
-	c := &Command{Command: cc}
+	c.Command = cc
 
 	if parent != nil {
 		parent.AddCommand(c)
@@ -321,5 +435,52 @@ func CmdBuilder(parent *Command, cr CmdRunner, cliText, desc string, out io.Writ
 		AddBoolFlag(c, doit.ArgNoHeader, false, "hide headers")
 	}
 
+	if c.isList {
+		AddStringSliceFlag(c, doit.ArgFilter, []string{},
+			"Filter rows by column as key=value. Also supports !=, <, <=, > and >=")
+		AddStringFlag(c, doit.ArgSortBy, "", "Column to sort rows by, prefix with - to sort descending")
+		AddIntFlag(c, doit.ArgLimit, 0, "Maximum number of rows to show")
+	}
+
+	if c.fmtCols != nil && (c.isList || c.single || c.Name() == "list") {
+		AddStringFlag(c, doit.ArgWatch, "",
+			"Poll and redraw the output until interrupted, every "+defaultWatchInterval+
+				" or every interval given as --watch=30s or --watch=30")
+		c.Flag(doit.ArgWatch).NoOptDefVal = defaultWatchInterval
+	}
+
 	return c
 }
+
+// bypassCache reports whether cmd must not use cached API responses. Watched
+// commands poll for changes and destructive flows act on what they find, so
+// neither can use stale responses.
+func bypassCache(flags *pflag.FlagSet, uncachedFlags []string) bool {
+	if interval, err := flags.GetString(doit.ArgWatch); err == nil && interval != "" {
+		return true
+	}
+
+	for _, name := range uncachedFlags {
+		if set, err := flags.GetBool(name); err == nil && set {
+			return true
+		}
+	}
+
+	return false
+}
+
+// askForConfirm asks the user to confirm an action. It returns
+// errOperationAborted unless the user answers yes.
+func askForConfirm(message string) error {
+	answer, err := retrieveUserInput(fmt.Sprintf("Are you sure you want to %s (y/N) ? ", message))
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return errOperationAborted
+	}
+}
@@ -16,7 +16,9 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"net"
 	"strconv"
+	"strings"
 
 	"github.com/digitalocean/doctl"
 	"github.com/digitalocean/doctl/do"
@@ -44,10 +46,15 @@ func Domain() *Command {
 		aliasOpt("ls"), displayerType(&domain{}), docCategories("domain"))
 
 	CmdBuilder(cmd, RunDomainGet, "get <domain>", "get domain", Writer,
-		aliasOpt("g"), displayerType(&domain{}), docCategories("domain"))
+		aliasOpt("g"), displayerType(&domain{}), singleOpt(), docCategories("domain"))
 
 	CmdBuilder(cmd, RunDomainDelete, "delete <domain>", "delete droplet", Writer, aliasOpt("g"))
 
+	cmdDomainAudit := CmdBuilder(cmd, RunDomainAudit, "audit [<domain> ...]", "find records pointing at addresses not owned by the account", Writer,
+		displayerType(&recordAudit{}), docCategories("domain"), uncachedWith(doit.ArgDelete))
+	AddBoolFlag(cmdDomainAudit, doit.ArgDelete, false, "Delete dangling records")
+	AddBoolFlag(cmdDomainAudit, doit.ArgForce, false, "Delete dangling records without confirmation")
+
 	cmdRecord := &Command{
 		Command: &cobra.Command{
 			Use:   "records",
@@ -166,6 +173,226 @@ func RunDomainDelete(c *CmdConfig) error {
 	return err
 }
 
+// maxCNAMEDepth is how many CNAME records are followed before a name is
+// considered to point nowhere.
+const maxCNAMEDepth = 8
+
+// danglingRecord is a domain record which points nowhere.
+type danglingRecord struct {
+	Domain string `json:"domain"`
+	do.DomainRecord
+	Reason string `json:"reason"`
+}
+
+// RunDomainAudit finds A, AAAA and CNAME records which point at addresses
+// that are not owned by the account.
+func RunDomainAudit(c *CmdConfig) error {
+	del, err := c.Doit.GetBool(c.NS, doit.ArgDelete)
+	if err != nil {
+		return err
+	}
+
+	force, err := c.Doit.GetBool(c.NS, doit.ArgForce)
+	if err != nil {
+		return err
+	}
+
+	owned, err := ownedAddresses(c)
+	if err != nil {
+		return err
+	}
+
+	ds := c.Domains()
+
+	names := c.Args
+	if len(names) == 0 {
+		domains, err := ds.List()
+		if err != nil {
+			return err
+		}
+
+		for _, d := range domains {
+			names = append(names, d.Name)
+		}
+	}
+
+	ra := &recordAuditor{
+		owned:   owned,
+		records: map[string]do.DomainRecords{},
+	}
+
+	for _, name := range names {
+		records, err := ds.Records(name)
+		if err != nil {
+			return err
+		}
+
+		ra.records[name] = records
+	}
+
+	dangling := ra.audit(names)
+
+	if del && len(dangling) > 0 {
+		if !force {
+			err := askForConfirm(fmt.Sprintf("delete %d dangling record(s)", len(dangling)))
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, dr := range dangling {
+			if err := ds.DeleteRecord(dr.Domain, dr.ID); err != nil {
+				return fmt.Errorf("unable to delete record %d in %s: %v", dr.ID, dr.Domain, err)
+			}
+		}
+	}
+
+	return c.Display(&recordAudit{records: dangling})
+}
+
+// ownedAddresses returns the set of droplet and floating IP addresses owned
+// by the account.
+func ownedAddresses(c *CmdConfig) (map[string]bool, error) {
+	owned := map[string]bool{}
+
+	droplets, err := c.Droplets().List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range droplets {
+		for _, ip := range d.IPs() {
+			if ip != "" {
+				owned[normalizeIP(ip)] = true
+			}
+		}
+	}
+
+	fips, err := c.FloatingIPs().List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fip := range fips {
+		owned[normalizeIP(fip.IP)] = true
+	}
+
+	return owned, nil
+}
+
+// normalizeIP returns the canonical form of an address, so the expanded IPv6
+// addresses the API returns match the compressed ones records usually hold.
+func normalizeIP(s string) string {
+	if ip := net.ParseIP(strings.TrimSpace(s)); ip != nil {
+		return ip.String()
+	}
+
+	return strings.ToLower(s)
+}
+
+type recordAuditor struct {
+	owned   map[string]bool
+	records map[string]do.DomainRecords
+}
+
+func (ra *recordAuditor) audit(domains []string) []danglingRecord {
+	dangling := []danglingRecord{}
+
+	for _, domain := range domains {
+		for _, r := range ra.records[domain] {
+			var reason string
+
+			switch strings.ToUpper(r.Type) {
+			case "A", "AAAA":
+				if !ra.owned[normalizeIP(r.Data)] {
+					reason = "address is not owned by the account"
+				}
+			case "CNAME":
+				if !ra.resolves(cnameTarget(domain, r.Data), 0) {
+					reason = "target does not resolve to an owned address"
+				}
+			}
+
+			if reason != "" {
+				dangling = append(dangling, danglingRecord{
+					Domain:       domain,
+					DomainRecord: r,
+					Reason:       reason,
+				})
+			}
+		}
+	}
+
+	return dangling
+}
+
+// resolves reports whether fqdn resolves to an owned address. Names outside of
+// the audited domains can't be checked, so they are assumed to resolve.
+func (ra *recordAuditor) resolves(fqdn string, depth int) bool {
+	zone := ra.zoneFor(fqdn)
+	if zone == "" {
+		return true
+	}
+
+	if depth > maxCNAMEDepth {
+		return false
+	}
+
+	name := "@"
+	if z := strings.ToLower(zone); fqdn != z {
+		name = strings.TrimSuffix(fqdn, "."+z)
+	}
+
+	for _, r := range ra.records[zone] {
+		if !strings.EqualFold(r.Name, name) {
+			continue
+		}
+
+		switch strings.ToUpper(r.Type) {
+		case "A", "AAAA":
+			if ra.owned[normalizeIP(r.Data)] {
+				return true
+			}
+		case "CNAME":
+			if ra.resolves(cnameTarget(zone, r.Data), depth+1) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// zoneFor returns the most specific audited domain containing fqdn.
+func (ra *recordAuditor) zoneFor(fqdn string) string {
+	var zone string
+	for d := range ra.records {
+		ld := strings.ToLower(d)
+		if fqdn == ld || strings.HasSuffix(fqdn, "."+ld) {
+			if len(d) > len(zone) {
+				zone = d
+			}
+		}
+	}
+
+	return zone
+}
+
+// cnameTarget converts CNAME record data into a lower case fully qualified
+// name without a trailing dot.
+func cnameTarget(domain, data string) string {
+	data = strings.ToLower(data)
+
+	switch {
+	case data == "@":
+		return strings.ToLower(domain)
+	case strings.HasSuffix(data, "."):
+		return strings.TrimSuffix(data, ".")
+	default:
+		return data + "." + strings.ToLower(domain)
+	}
+}
+
 // RunRecordList list records for a domain.
 func RunRecordList(c *CmdConfig) error {
 	if len(c.Args) != 1 {
@@ -34,7 +34,7 @@ var (
 func TestDomainsCommand(t *testing.T) {
 	cmd := Domain()
 	assert.NotNil(t, cmd)
-	assertCommandNames(t, cmd, "create", "list", "get", "delete", "records")
+	assertCommandNames(t, cmd, "audit", "create", "list", "get", "delete", "records")
 }
 
 func TestDomainsCreate(t *testing.T) {
@@ -161,3 +161,111 @@ func TestRecordsUpdate(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestDomainsAudit(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		records := do.DomainRecords{
+			{DomainRecord: &godo.DomainRecord{ID: 1, Type: "A", Name: "@", Data: "8.8.8.8"}},
+			{DomainRecord: &godo.DomainRecord{ID: 2, Type: "A", Name: "old", Data: "10.10.10.10"}},
+			{DomainRecord: &godo.DomainRecord{ID: 3, Type: "A", Name: "fip", Data: "127.0.0.1"}},
+			{DomainRecord: &godo.DomainRecord{ID: 4, Type: "CNAME", Name: "www", Data: "@"}},
+			{DomainRecord: &godo.DomainRecord{ID: 5, Type: "CNAME", Name: "legacy", Data: "old.example.com."}},
+			{DomainRecord: &godo.DomainRecord{ID: 6, Type: "CNAME", Name: "cdn", Data: "cdn.example.net."}},
+			{DomainRecord: &godo.DomainRecord{ID: 7, Type: "MX", Name: "@", Data: "10.10.10.10"}},
+		}
+
+		tm.droplets.On("List").Return(testDropletList, nil)
+		tm.floatingIPs.On("List").Return(testFloatingIPList, nil)
+		tm.domains.On("List").Return(testDomainList, nil)
+		tm.domains.On("Records", "example.com").Return(records, nil)
+
+		owned, err := ownedAddresses(config)
+		assert.NoError(t, err)
+
+		ra := &recordAuditor{
+			owned:   owned,
+			records: map[string]do.DomainRecords{"example.com": records},
+		}
+
+		dangling := ra.audit([]string{"example.com"})
+		var ids []int
+		for _, dr := range dangling {
+			ids = append(ids, dr.ID)
+		}
+		assert.Equal(t, []int{2, 5}, ids)
+
+		err = RunDomainAudit(config)
+		assert.NoError(t, err)
+	})
+}
+
+func TestDomainsAudit_Delete(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		records := do.DomainRecords{
+			{DomainRecord: &godo.DomainRecord{ID: 1, Type: "A", Name: "@", Data: "8.8.8.8"}},
+			{DomainRecord: &godo.DomainRecord{ID: 2, Type: "AAAA", Name: "old", Data: "2001:db8::1"}},
+		}
+
+		tm.droplets.On("List").Return(testDropletList, nil)
+		tm.floatingIPs.On("List").Return(testFloatingIPList, nil)
+		tm.domains.On("Records", "example.com").Return(records, nil)
+		tm.domains.On("DeleteRecord", "example.com", 2).Return(nil)
+
+		config.Args = append(config.Args, "example.com")
+		config.Doit.Set(config.NS, doit.ArgDelete, true)
+		config.Doit.Set(config.NS, doit.ArgForce, true)
+
+		err := RunDomainAudit(config)
+		assert.NoError(t, err)
+	})
+}
+
+func TestDomainsAudit_IPv6(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		droplets := do.Droplets{{Droplet: &godo.Droplet{
+			ID: 1,
+			Networks: &godo.Networks{
+				V6: []godo.NetworkV6{{IPAddress: "2604:A880:0800:0010:0000:0000:02DD:6001", Type: "public"}},
+			},
+		}}}
+		records := do.DomainRecords{
+			{DomainRecord: &godo.DomainRecord{ID: 1, Type: "AAAA", Name: "@", Data: "2604:a880:800:10::2dd:6001"}},
+			{DomainRecord: &godo.DomainRecord{ID: 2, Type: "AAAA", Name: "old", Data: "2001:db8::1"}},
+		}
+
+		tm.droplets.On("List").Return(droplets, nil)
+		tm.floatingIPs.On("List").Return(do.FloatingIPs{}, nil)
+		tm.domains.On("Records", "example.com").Return(records, nil)
+		tm.domains.On("DeleteRecord", "example.com", 2).Return(nil)
+
+		config.Args = append(config.Args, "example.com")
+		config.Doit.Set(config.NS, doit.ArgDelete, true)
+		config.Doit.Set(config.NS, doit.ArgForce, true)
+
+		err := RunDomainAudit(config)
+		assert.NoError(t, err)
+	})
+}
+
+func TestDomainsAudit_DeleteAborted(t *testing.T) {
+	defer func(fn func(string) (string, error)) { retrieveUserInput = fn }(retrieveUserInput)
+	retrieveUserInput = func(string) (string, error) {
+		return "n\n", nil
+	}
+
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		records := do.DomainRecords{
+			{DomainRecord: &godo.DomainRecord{ID: 2, Type: "A", Name: "old", Data: "10.10.10.10"}},
+		}
+
+		tm.droplets.On("List").Return(testDropletList, nil)
+		tm.floatingIPs.On("List").Return(testFloatingIPList, nil)
+		tm.domains.On("Records", "example.com").Return(records, nil)
+
+		config.Args = append(config.Args, "example.com")
+		config.Doit.Set(config.NS, doit.ArgDelete, true)
+
+		err := RunDomainAudit(config)
+		assert.Equal(t, errOperationAborted, err)
+	})
+}
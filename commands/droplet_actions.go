@@ -60,92 +60,92 @@ func DropletAction() *Command {
 	}
 
 	cmdDropletActionGet := CmdBuilder(cmd, RunDropletActionGet, "get", "get droplet action", Writer,
-		aliasOpt("g"), displayerType(&action{}), docCategories("droplet"))
+		aliasOpt("g"), displayerType(&action{}), singleOpt(), docCategories("droplet"))
 	AddIntFlag(cmdDropletActionGet, doit.ArgActionID, 0, "Action ID", requiredOpt())
 
 	cmdDropletActionDisableBackups := CmdBuilder(cmd, RunDropletActionDisableBackups,
-		"disable-backups <droplet-id>", "disable backups", Writer,
+		"disable-backups <droplet>", "disable backups", Writer,
 		displayerType(&action{}), docCategories("droplet"))
 	AddBoolFlag(cmdDropletActionDisableBackups, doit.ArgCommandWait, false, "Wait for action to complete")
 
 	cmdDropletActionReboot := CmdBuilder(cmd, RunDropletActionReboot,
-		"reboot <droplet-id>", "reboot droplet", Writer,
+		"reboot <droplet>", "reboot droplet", Writer,
 		displayerType(&action{}), docCategories("droplet"))
 	AddBoolFlag(cmdDropletActionReboot, doit.ArgCommandWait, false, "Wait for action to complete")
 
 	cmdDropletActionPowerCycle := CmdBuilder(cmd, RunDropletActionPowerCycle,
-		"power-cycle <droplet-id>", "power cycle droplet", Writer,
+		"power-cycle <droplet>", "power cycle droplet", Writer,
 		displayerType(&action{}), docCategories("droplet"))
 	AddBoolFlag(cmdDropletActionPowerCycle, doit.ArgCommandWait, false, "Wait for action to complete")
 
 	cmdDropletActionShutdown := CmdBuilder(cmd, RunDropletActionShutdown,
-		"shutdown <droplet-id>", "shutdown droplet", Writer,
+		"shutdown <droplet>", "shutdown droplet", Writer,
 		displayerType(&action{}), docCategories("droplet"))
 	AddBoolFlag(cmdDropletActionShutdown, doit.ArgCommandWait, false, "Wait for action to complete")
 
 	cmdDropletActionPowerOff := CmdBuilder(cmd, RunDropletActionPowerOff,
-		"power-off <droplet-id>", "power off droplet", Writer,
+		"power-off <droplet>", "power off droplet", Writer,
 		displayerType(&action{}), docCategories("droplet"))
 	AddBoolFlag(cmdDropletActionPowerOff, doit.ArgCommandWait, false, "Wait for action to complete")
 
 	cmdDropletActionPowerOn := CmdBuilder(cmd, RunDropletActionPowerOn,
-		"power-on <droplet-id>", "power on droplet", Writer,
+		"power-on <droplet>", "power on droplet", Writer,
 		displayerType(&action{}), docCategories("droplet"))
 	AddBoolFlag(cmdDropletActionPowerOn, doit.ArgCommandWait, false, "Wait for action to complete")
 
 	cmdDropletActionPasswordReset := CmdBuilder(cmd, RunDropletActionPasswordReset,
-		"power-reset <droplet-id>", "power reset droplet", Writer,
+		"power-reset <droplet>", "power reset droplet", Writer,
 		displayerType(&action{}), docCategories("droplet"))
 	AddBoolFlag(cmdDropletActionPasswordReset, doit.ArgCommandWait, false, "Wait for action to complete")
 
 	cmdDropletActionEnableIPv6 := CmdBuilder(cmd, RunDropletActionEnableIPv6,
-		"enable-ipv6 <droplet-id>", "enable ipv6", Writer,
+		"enable-ipv6 <droplet>", "enable ipv6", Writer,
 		displayerType(&action{}), docCategories("droplet"))
 	AddBoolFlag(cmdDropletActionEnableIPv6, doit.ArgCommandWait, false, "Wait for action to complete")
 
 	cmdDropletActionEnablePrivateNetworking := CmdBuilder(cmd, RunDropletActionEnablePrivateNetworking,
-		"enable-private-networking <droplet-id>", "enable private networking", Writer,
+		"enable-private-networking <droplet>", "enable private networking", Writer,
 		displayerType(&action{}), docCategories("droplet"))
 	AddBoolFlag(cmdDropletActionEnablePrivateNetworking, doit.ArgCommandWait, false, "Wait for action to complete")
 
 	cmdDropletActionUpgrade := CmdBuilder(cmd, RunDropletActionUpgrade,
-		"upgrade <droplet-id>", "upgrade droplet", Writer,
+		"upgrade <droplet>", "upgrade droplet", Writer,
 		displayerType(&action{}), docCategories("droplet"))
 	AddBoolFlag(cmdDropletActionUpgrade, doit.ArgCommandWait, false, "Wait for action to complete")
 
 	cmdDropletActionRestore := CmdBuilder(cmd, RunDropletActionRestore,
-		"restore <droplet-id>", "restore backup", Writer,
+		"restore <droplet>", "restore backup", Writer,
 		displayerType(&action{}), docCategories("droplet"))
-	AddIntFlag(cmdDropletActionRestore, doit.ArgImageID, 0, "Image ID", requiredOpt())
+	AddStringFlag(cmdDropletActionRestore, doit.ArgImageID, "", "Image ID or name", requiredOpt())
 	AddBoolFlag(cmdDropletActionRestore, doit.ArgCommandWait, false, "Wait for action to complete")
 
 	cmdDropletActionResize := CmdBuilder(cmd, RunDropletActionResize,
-		"resize <droplet-id>", "resize droplet", Writer,
+		"resize <droplet>", "resize droplet", Writer,
 		displayerType(&action{}), docCategories("droplet"))
 	AddBoolFlag(cmdDropletActionResize, doit.ArgResizeDisk, false, "Resize disk")
 	AddStringFlag(cmdDropletActionResize, doit.ArgSizeSlug, "", "New size")
 	AddBoolFlag(cmdDropletActionResize, doit.ArgCommandWait, false, "Wait for action to complete")
 
 	cmdDropletActionRebuild := CmdBuilder(cmd, RunDropletActionRebuild,
-		"rebuild <droplet-id>", "rebuild droplet", Writer,
+		"rebuild <droplet>", "rebuild droplet", Writer,
 		displayerType(&action{}), docCategories("droplet"))
-	AddIntFlag(cmdDropletActionRebuild, doit.ArgImageID, 0, "Image ID", requiredOpt())
+	AddStringFlag(cmdDropletActionRebuild, doit.ArgImage, "", "Image ID, slug or name", requiredOpt())
 	AddBoolFlag(cmdDropletActionRebuild, doit.ArgCommandWait, false, "Wait for action to complete")
 
 	cmdDropletActionRename := CmdBuilder(cmd, RunDropletActionRename,
-		"rename <droplet-id>", "rename droplet", Writer,
+		"rename <droplet>", "rename droplet", Writer,
 		displayerType(&action{}), docCategories("droplet"))
 	AddStringFlag(cmdDropletActionRename, doit.ArgDropletName, "", "Droplet name", requiredOpt())
 	AddBoolFlag(cmdDropletActionRename, doit.ArgCommandWait, false, "Wait for action to complete")
 
 	cmdDropletActionChangeKernel := CmdBuilder(cmd, RunDropletActionChangeKernel,
-		"change-kernel <droplet-id>", "change kernel", Writer,
+		"change-kernel <droplet>", "change kernel", Writer,
 		docCategories("droplet"))
 	AddIntFlag(cmdDropletActionChangeKernel, doit.ArgKernelID, 0, "Kernel ID", requiredOpt())
 	AddBoolFlag(cmdDropletActionChangeKernel, doit.ArgCommandWait, false, "Wait for action to complete")
 
 	cmdDropletActionSnapshot := CmdBuilder(cmd, RunDropletActionSnapshot,
-		"snapshot <droplet-id>", "snapshot droplet", Writer,
+		"snapshot <droplet>", "snapshot droplet", Writer,
 		displayerType(&action{}), docCategories("droplet"))
 	AddStringFlag(cmdDropletActionSnapshot, doit.ArgSnapshotName, "", "Snapshot name", requiredOpt())
 	AddBoolFlag(cmdDropletActionSnapshot, doit.ArgCommandWait, false, "Wait for action to complete")
@@ -159,7 +159,7 @@ func RunDropletActionGet(c *CmdConfig) error {
 		if len(c.Args) != 1 {
 			return nil, doit.NewMissingArgsErr(c.NS)
 		}
-		dropletID, err := strconv.Atoi(c.Args[0])
+		dropletID, err := newResolver(c).DropletID(c.Args[0])
 		if err != nil {
 			return nil, err
 		}
@@ -182,7 +182,7 @@ func RunDropletActionDisableBackups(c *CmdConfig) error {
 		if len(c.Args) != 1 {
 			return nil, doit.NewMissingArgsErr(c.NS)
 		}
-		id, err := strconv.Atoi(c.Args[0])
+		id, err := newResolver(c).DropletID(c.Args[0])
 		if err != nil {
 			return nil, err
 		}
@@ -200,7 +200,7 @@ func RunDropletActionReboot(c *CmdConfig) error {
 		if len(c.Args) != 1 {
 			return nil, doit.NewMissingArgsErr(c.NS)
 		}
-		id, err := strconv.Atoi(c.Args[0])
+		id, err := newResolver(c).DropletID(c.Args[0])
 		if err != nil {
 			return nil, err
 		}
@@ -218,7 +218,7 @@ func RunDropletActionPowerCycle(c *CmdConfig) error {
 		if len(c.Args) != 1 {
 			return nil, doit.NewMissingArgsErr(c.NS)
 		}
-		id, err := strconv.Atoi(c.Args[0])
+		id, err := newResolver(c).DropletID(c.Args[0])
 
 		if err != nil {
 			return nil, err
@@ -237,7 +237,11 @@ func RunDropletActionShutdown(c *CmdConfig) error {
 		if len(c.Args) != 1 {
 			return nil, doit.NewMissingArgsErr(c.NS)
 		}
-		id, err := strconv.Atoi(c.Args[0])
+		id, err := newResolver(c).DropletID(c.Args[0])
+
+		if err != nil {
+			return nil, err
+		}
 
 		a, err := das.Shutdown(id)
 		return a, err
@@ -252,7 +256,7 @@ func RunDropletActionPowerOff(c *CmdConfig) error {
 		if len(c.Args) != 1 {
 			return nil, doit.NewMissingArgsErr(c.NS)
 		}
-		id, err := strconv.Atoi(c.Args[0])
+		id, err := newResolver(c).DropletID(c.Args[0])
 
 		if err != nil {
 			return nil, err
@@ -271,7 +275,7 @@ func RunDropletActionPowerOn(c *CmdConfig) error {
 		if len(c.Args) != 1 {
 			return nil, doit.NewMissingArgsErr(c.NS)
 		}
-		id, err := strconv.Atoi(c.Args[0])
+		id, err := newResolver(c).DropletID(c.Args[0])
 
 		if err != nil {
 			return nil, err
@@ -290,7 +294,7 @@ func RunDropletActionPasswordReset(c *CmdConfig) error {
 		if len(c.Args) != 1 {
 			return nil, doit.NewMissingArgsErr(c.NS)
 		}
-		id, err := strconv.Atoi(c.Args[0])
+		id, err := newResolver(c).DropletID(c.Args[0])
 
 		if err != nil {
 			return nil, err
@@ -309,7 +313,7 @@ func RunDropletActionEnableIPv6(c *CmdConfig) error {
 		if len(c.Args) != 1 {
 			return nil, doit.NewMissingArgsErr(c.NS)
 		}
-		id, err := strconv.Atoi(c.Args[0])
+		id, err := newResolver(c).DropletID(c.Args[0])
 
 		if err != nil {
 			return nil, err
@@ -328,7 +332,7 @@ func RunDropletActionEnablePrivateNetworking(c *CmdConfig) error {
 		if len(c.Args) != 1 {
 			return nil, doit.NewMissingArgsErr(c.NS)
 		}
-		id, err := strconv.Atoi(c.Args[0])
+		id, err := newResolver(c).DropletID(c.Args[0])
 
 		if err != nil {
 			return nil, err
@@ -347,7 +351,7 @@ func RunDropletActionUpgrade(c *CmdConfig) error {
 		if len(c.Args) != 1 {
 			return nil, doit.NewMissingArgsErr(c.NS)
 		}
-		id, err := strconv.Atoi(c.Args[0])
+		id, err := newResolver(c).DropletID(c.Args[0])
 
 		if err != nil {
 			return nil, err
@@ -360,19 +364,24 @@ func RunDropletActionUpgrade(c *CmdConfig) error {
 	return performAction(c, fn)
 }
 
-// RunDropletActionRestore restores a droplet using an image id.
+// RunDropletActionRestore restores a droplet using an image id or name.
 func RunDropletActionRestore(c *CmdConfig) error {
 	fn := func(das do.DropletActionsService) (*do.Action, error) {
 		if len(c.Args) != 1 {
 			return nil, doit.NewMissingArgsErr(c.NS)
 		}
-		id, err := strconv.Atoi(c.Args[0])
+		id, err := newResolver(c).DropletID(c.Args[0])
 
 		if err != nil {
 			return nil, err
 		}
 
-		image, err := c.Doit.GetInt(c.NS, doit.ArgImageID)
+		rawImage, err := c.Doit.GetString(c.NS, doit.ArgImageID)
+		if err != nil {
+			return nil, err
+		}
+
+		image, err := newResolver(c).ImageID(rawImage)
 		if err != nil {
 			return nil, err
 		}
@@ -391,7 +400,7 @@ func RunDropletActionResize(c *CmdConfig) error {
 		if len(c.Args) != 1 {
 			return nil, doit.NewMissingArgsErr(c.NS)
 		}
-		id, err := strconv.Atoi(c.Args[0])
+		id, err := newResolver(c).DropletID(c.Args[0])
 
 		if err != nil {
 			return nil, err
@@ -414,13 +423,13 @@ func RunDropletActionResize(c *CmdConfig) error {
 	return performAction(c, fn)
 }
 
-// RunDropletActionRebuild rebuilds a droplet using an image id or slug.
+// RunDropletActionRebuild rebuilds a droplet using an image id, slug or name.
 func RunDropletActionRebuild(c *CmdConfig) error {
 	fn := func(das do.DropletActionsService) (*do.Action, error) {
 		if len(c.Args) != 1 {
 			return nil, doit.NewMissingArgsErr(c.NS)
 		}
-		id, err := strconv.Atoi(c.Args[0])
+		id, err := newResolver(c).DropletID(c.Args[0])
 
 		if err != nil {
 			return nil, err
@@ -431,25 +440,54 @@ func RunDropletActionRebuild(c *CmdConfig) error {
 			return nil, err
 		}
 
-		var a *do.Action
 		if i, aerr := strconv.Atoi(image); aerr == nil {
-			a, err = das.RebuildByImageID(id, i)
-		} else {
-			a, err = das.RebuildByImageSlug(id, image)
+			return das.RebuildByImageID(id, i)
 		}
-		return a, err
+
+		if isGlob(image) {
+			i, err := newResolver(c).Image(image)
+			if err != nil {
+				return nil, err
+			}
+			return das.RebuildByImageID(id, i.ID)
+		}
+
+		// Rebuild by slug first, the API rejects unknown slugs, and only
+		// then look the image up by name.
+		a, err := das.RebuildByImageSlug(id, image)
+		if err == nil || !isUnknownImage(err) {
+			return a, err
+		}
+
+		i, rerr := newResolver(c).Image(image)
+		if rerr != nil {
+			if _, ok := rerr.(*NoMatchErr); ok {
+				return nil, err
+			}
+			return nil, rerr
+		}
+		return das.RebuildByImageID(id, i.ID)
 	}
 
 	return performAction(c, fn)
 }
 
+// isUnknownImage reports whether err is the API rejecting an image slug.
+func isUnknownImage(err error) bool {
+	switch doit.NewAPIError(err).(type) {
+	case *doit.NotFoundErr, *doit.ValidationErr:
+		return true
+	}
+	return false
+}
+
 // RunDropletActionRename renames a droplet.
 func RunDropletActionRename(c *CmdConfig) error {
 	fn := func(das do.DropletActionsService) (*do.Action, error) {
 		if len(c.Args) != 1 {
 			return nil, doit.NewMissingArgsErr(c.NS)
 		}
-		id, err := strconv.Atoi(c.Args[0])
+		id, err := newResolver(c).DropletID(c.Args[0])
 
 		if err != nil {
 			return nil, err
@@ -473,7 +511,7 @@ func RunDropletActionChangeKernel(c *CmdConfig) error {
 		if len(c.Args) != 1 {
 			return nil, doit.NewMissingArgsErr(c.NS)
 		}
-		id, err := strconv.Atoi(c.Args[0])
+		id, err := newResolver(c).DropletID(c.Args[0])
 
 		if err != nil {
 			return nil, err
@@ -497,7 +535,7 @@ func RunDropletActionSnapshot(c *CmdConfig) error {
 		if len(c.Args) != 1 {
 			return nil, doit.NewMissingArgsErr(c.NS)
 		}
-		id, err := strconv.Atoi(c.Args[0])
+		id, err := newResolver(c).DropletID(c.Args[0])
 
 		if err != nil {
 			return nil, err
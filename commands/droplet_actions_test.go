@@ -17,6 +17,8 @@ import (
 	"testing"
 
 	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/doctl/do"
+	"github.com/digitalocean/godo"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -162,6 +164,51 @@ func TestDropletActionsRebuildByImageSlug(t *testing.T) {
 	})
 
 }
+
+func TestDropletActionsRebuildByImageName(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		images := do.Images{{Image: &godo.Image{ID: 11, Name: "web-snapshot"}}}
+		tm.dropletActions.On("RebuildByImageSlug", 1, "web-snapshot").Return(nil, testAPIError(422))
+		tm.images.On("GetBySlug", "web-snapshot").Return(nil, testAPIError(404))
+		tm.images.On("List", false).Return(images, nil)
+		tm.dropletActions.On("RebuildByImageID", 1, 11).Return(&testAction, nil)
+
+		config.Args = append(config.Args, "1")
+
+		config.Doit.Set(config.NS, doit.ArgImage, "web-snapshot")
+
+		err := RunDropletActionRebuild(config)
+		assert.NoError(t, err)
+	})
+}
+
+func TestDropletActionsRebuildUnknownImage(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		rebuildErr := testAPIError(422)
+		tm.dropletActions.On("RebuildByImageSlug", 1, "missing").Return(nil, rebuildErr)
+		tm.images.On("GetBySlug", "missing").Return(nil, testAPIError(404))
+		tm.images.On("List", false).Return(do.Images{}, nil)
+
+		config.Args = append(config.Args, "1")
+
+		config.Doit.Set(config.NS, doit.ArgImage, "missing")
+
+		err := RunDropletActionRebuild(config)
+		assert.Equal(t, rebuildErr, err)
+	})
+}
+func TestDropletActionsRebootByName(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.droplets.On("List").Return(testDropletList, nil)
+		tm.dropletActions.On("Reboot", testDroplet.ID).Return(&testAction, nil)
+
+		config.Args = append(config.Args, testDroplet.Name)
+
+		err := RunDropletActionReboot(config)
+		assert.NoError(t, err)
+	})
+}
+
 func TestDropletActionsRename(t *testing.T) {
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
 		tm.dropletActions.On("Rename", 1, "name").Return(&testAction, nil)
@@ -213,6 +260,17 @@ func TestDropletActionsShutdown(t *testing.T) {
 	})
 }
 
+func TestDropletActionsShutdownUnknownName(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.droplets.On("List").Return(testDropletList, nil)
+
+		config.Args = append(config.Args, "missing")
+
+		err := RunDropletActionShutdown(config)
+		assert.IsType(t, &NoMatchErr{}, err)
+	})
+}
+
 func TestDropletActionsSnapshot(t *testing.T) {
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
 		tm.dropletActions.On("Snapshot", 1, "name").Return(&testAction, nil)
@@ -0,0 +1,353 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/doctl/do"
+	"github.com/digitalocean/godo"
+	"gopkg.in/yaml.v2"
+)
+
+// dropletSpec describes a droplet to be created. Specs are read from a YAML
+// file containing a list of them.
+type dropletSpec struct {
+	Name              string            `yaml:"name"`
+	Region            string            `yaml:"region"`
+	Size              string            `yaml:"size"`
+	Image             string            `yaml:"image"`
+	SSHKeys           []string          `yaml:"ssh_keys"`
+	Tags              []string          `yaml:"tags"`
+	Backups           *bool             `yaml:"backups"`
+	IPv6              *bool             `yaml:"ipv6"`
+	PrivateNetworking *bool             `yaml:"private_networking"`
+	UserData          string            `yaml:"user_data"`
+	UserDataFile      string            `yaml:"user_data_file"`
+	Vars              map[string]string `yaml:"vars"`
+}
+
+// userDataContext is the data user data templates are rendered with.
+type userDataContext struct {
+	Name   string
+	Index  int
+	Region string
+	Size   string
+	Image  string
+	Tags   []string
+	Vars   map[string]string
+	Env    map[string]string
+}
+
+func (ds *dropletSpec) createRequest() *godo.DropletCreateRequest {
+	var image godo.DropletCreateImage
+	if i, err := strconv.Atoi(ds.Image); err == nil {
+		image = godo.DropletCreateImage{ID: i}
+	} else {
+		image = godo.DropletCreateImage{Slug: ds.Image}
+	}
+
+	return &godo.DropletCreateRequest{
+		Name:              ds.Name,
+		Region:            ds.Region,
+		Size:              ds.Size,
+		Image:             image,
+		Backups:           boolValue(ds.Backups),
+		IPv6:              boolValue(ds.IPv6),
+		PrivateNetworking: boolValue(ds.PrivateNetworking),
+		SSHKeys:           extractSSHKeys(ds.SSHKeys),
+		UserData:          ds.UserData,
+	}
+}
+
+// dropletSpecDefaults builds a spec from the command flags. When lenient is
+// true, required flags which weren't set are left empty so a spec file can
+// supply them.
+func dropletSpecDefaults(c *CmdConfig, lenient bool) (*dropletSpec, error) {
+	getString := func(key string) (string, error) {
+		s, err := c.Doit.GetString(c.NS, key)
+		if _, ok := err.(*doit.MissingArgsErr); ok && lenient {
+			return "", nil
+		}
+		return s, err
+	}
+
+	spec := &dropletSpec{}
+
+	var err error
+	if spec.Region, err = getString(doit.ArgRegionSlug); err != nil {
+		return nil, err
+	}
+
+	if spec.Size, err = getString(doit.ArgSizeSlug); err != nil {
+		return nil, err
+	}
+
+	if spec.Image, err = getString(doit.ArgImage); err != nil {
+		return nil, err
+	}
+
+	if spec.UserData, err = getString(doit.ArgUserData); err != nil {
+		return nil, err
+	}
+
+	if spec.UserDataFile, err = getString(doit.ArgUserDataFile); err != nil {
+		return nil, err
+	}
+
+	if spec.SSHKeys, err = c.Doit.GetStringSlice(c.NS, doit.ArgSSHKeys); err != nil {
+		return nil, err
+	}
+
+	for key, v := range map[string]**bool{
+		doit.ArgBackups:           &spec.Backups,
+		doit.ArgIPv6:              &spec.IPv6,
+		doit.ArgPrivateNetworking: &spec.PrivateNetworking,
+	} {
+		b, err := c.Doit.GetBool(c.NS, key)
+		if err != nil {
+			return nil, err
+		}
+		*v = &b
+	}
+
+	return spec, nil
+}
+
+// readDropletSpecs reads droplet specs from a file. Settings missing from a
+// spec are taken from the command flags, and user data is rendered as a
+// template for each droplet.
+func readDropletSpecs(c *CmdConfig, path string) ([]dropletSpec, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var specs []dropletSpec
+	if err := yaml.Unmarshal(b, &specs); err != nil {
+		return nil, fmt.Errorf("unable to parse droplet spec file %q: %v", path, err)
+	}
+
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("droplet spec file %q contains no droplets", path)
+	}
+
+	defaults, err := dropletSpecDefaults(c, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Relative paths in the spec file are relative to the file, but a
+	// --user-data-file given on the command line is relative to the working
+	// directory.
+	if defaults.UserDataFile != "" {
+		if defaults.UserDataFile, err = filepath.Abs(defaults.UserDataFile); err != nil {
+			return nil, err
+		}
+	}
+
+	rawVars, err := c.Doit.GetStringSlice(c.NS, doit.ArgUserDataVar)
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := parseUserDataVars(rawVars)
+	if err != nil {
+		return nil, err
+	}
+
+	env := map[string]string{}
+	for _, kv := range os.Environ() {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) == 2 {
+			env[parts[0]] = parts[1]
+		}
+	}
+
+	dir := filepath.Dir(path)
+	for i := range specs {
+		spec := &specs[i]
+		spec.applyDefaults(defaults)
+
+		if err := spec.validate(); err != nil {
+			return nil, fmt.Errorf("droplet spec %d: %v", i, err)
+		}
+
+		tmpl := spec.UserData
+		if tmpl == "" && spec.UserDataFile != "" {
+			f := spec.UserDataFile
+			if !filepath.IsAbs(f) {
+				f = filepath.Join(dir, f)
+			}
+
+			data, err := ioutil.ReadFile(f)
+			if err != nil {
+				return nil, err
+			}
+			tmpl = string(data)
+		}
+
+		ctx := userDataContext{
+			Name:   spec.Name,
+			Index:  i,
+			Region: spec.Region,
+			Size:   spec.Size,
+			Image:  spec.Image,
+			Tags:   spec.Tags,
+			Vars:   map[string]string{},
+			Env:    env,
+		}
+		for k, v := range vars {
+			ctx.Vars[k] = v
+		}
+		for k, v := range spec.Vars {
+			ctx.Vars[k] = v
+		}
+
+		userData, err := renderUserData(tmpl, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("droplet %q: %v", spec.Name, err)
+		}
+
+		spec.UserData = userData
+		spec.UserDataFile = ""
+	}
+
+	return specs, nil
+}
+
+func (ds *dropletSpec) applyDefaults(defaults *dropletSpec) {
+	if ds.Region == "" {
+		ds.Region = defaults.Region
+	}
+	if ds.Size == "" {
+		ds.Size = defaults.Size
+	}
+	if ds.Image == "" {
+		ds.Image = defaults.Image
+	}
+	if ds.SSHKeys == nil {
+		ds.SSHKeys = defaults.SSHKeys
+	}
+	if ds.Backups == nil {
+		ds.Backups = defaults.Backups
+	}
+	if ds.IPv6 == nil {
+		ds.IPv6 = defaults.IPv6
+	}
+	if ds.PrivateNetworking == nil {
+		ds.PrivateNetworking = defaults.PrivateNetworking
+	}
+	if ds.UserData == "" && ds.UserDataFile == "" {
+		ds.UserData = defaults.UserData
+		ds.UserDataFile = defaults.UserDataFile
+	}
+}
+
+func (ds *dropletSpec) validate() error {
+	for _, f := range []struct{ name, value string }{
+		{"name", ds.Name}, {"region", ds.Region}, {"size", ds.Size}, {"image", ds.Image},
+	} {
+		if f.value == "" {
+			return fmt.Errorf("missing %s", f.name)
+		}
+	}
+
+	return nil
+}
+
+// parseUserDataVars parses key=value pairs into a map.
+func parseUserDataVars(in []string) (map[string]string, error) {
+	vars := map[string]string{}
+	for _, kv := range in {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid user data variable %q, expected key=value", kv)
+		}
+		vars[parts[0]] = parts[1]
+	}
+
+	return vars, nil
+}
+
+// renderUserData renders user data as a Go template.
+func renderUserData(text string, ctx userDataContext) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	t, err := template.New("user-data").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse user data template: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, ctx); err != nil {
+		return "", fmt.Errorf("unable to render user data template: %v", err)
+	}
+
+	return buf.String(), nil
+}
+
+// ensureTags creates the tags used by specs that don't exist yet.
+func ensureTags(ts do.TagsService, specs []dropletSpec) error {
+	seen := map[string]bool{}
+	for _, spec := range specs {
+		for _, tag := range spec.Tags {
+			if seen[tag] {
+				continue
+			}
+			seen[tag] = true
+
+			if _, err := ts.Get(tag); err == nil {
+				continue
+			}
+
+			if _, err := ts.Create(&godo.TagCreateRequest{Name: tag}); err != nil {
+				return fmt.Errorf("unable to create tag %q: %v", tag, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// tagDroplet applies tags to a droplet.
+func tagDroplet(ts do.TagsService, id int, tags []string) error {
+	for _, tag := range tags {
+		trr := &godo.TagResourcesRequest{
+			Resources: []godo.Resource{
+				{ID: strconv.Itoa(id), Type: godo.DropletResourceType},
+			},
+		}
+
+		if err := ts.TagResources(tag, trr); err != nil {
+			return fmt.Errorf("unable to tag droplet %d with %q: %v", id, tag, err)
+		}
+	}
+
+	return nil
+}
+
+func boolValue(b *bool) bool {
+	return b != nil && *b
+}
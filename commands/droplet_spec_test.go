@@ -0,0 +1,143 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/doctl/do"
+	"github.com/digitalocean/godo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDropletCreateSpecFile(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		cloudConfig, err := ioutil.ReadFile("../testdata/cloud-config.yml")
+		assert.NoError(t, err)
+
+		web := &godo.DropletMultiCreateRequest{
+			Names:   []string{"web-1"},
+			Region:  "nyc3",
+			Size:    "512mb",
+			Image:   godo.DropletCreateImage{Slug: "ubuntu-14-04-x64"},
+			SSHKeys: []godo.DropletCreateSSHKey{{ID: 1}, {ID: 2}},
+			IPv6:    true,
+			UserData: "#cloud-config\nhostname: web-1\nruncmd:\n" +
+				"  - echo \"0 nyc3 frontend prod\"\n",
+		}
+		etcd := &godo.DropletMultiCreateRequest{
+			Names:    []string{"etcd-1"},
+			Region:   "sfo1",
+			Size:     "1gb",
+			Image:    godo.DropletCreateImage{ID: 42},
+			SSHKeys:  []godo.DropletCreateSSHKey{},
+			UserData: string(cloudConfig),
+		}
+
+		ubuntu := do.Image{Image: &godo.Image{ID: 7, Slug: "ubuntu-14-04-x64"}}
+		tm.images.On("GetBySlug", "ubuntu-14-04-x64").Return(&ubuntu, nil)
+		deployKey := do.SSHKey{Key: &godo.Key{ID: 2, Name: "deploy"}}
+		tm.keys.On("List").Return(do.SSHKeys{deployKey}, nil)
+		tm.tags.On("Get", "web").Return(nil, godo.NewArgError("name", "not found"))
+		tm.tags.On("Create", &godo.TagCreateRequest{Name: "web"}).Return(nil, nil)
+		webDroplet := do.Droplet{Droplet: &godo.Droplet{ID: 1, Name: "web-1"}}
+		etcdDroplet := do.Droplet{Droplet: &godo.Droplet{ID: 3, Name: "etcd-1"}}
+		tm.droplets.On("CreateMultiple", web).Return(do.Droplets{webDroplet}, []godo.LinkAction{}, nil)
+		tm.droplets.On("CreateMultiple", etcd).Return(do.Droplets{etcdDroplet}, []godo.LinkAction{}, nil)
+		tm.tags.On("TagResources", "web", mock.AnythingOfType("*godo.TagResourcesRequest")).Return(nil)
+
+		config.Doit.Set(config.NS, doit.ArgDropletSpecFile, "../testdata/droplets.yml")
+		config.Doit.Set(config.NS, doit.ArgRegionSlug, "sfo1")
+		config.Doit.Set(config.NS, doit.ArgImage, "42")
+		config.Doit.Set(config.NS, doit.ArgUserDataVar, []string{"env=prod", "role=backend"})
+
+		err = RunDropletCreate(config)
+		assert.NoError(t, err)
+	})
+}
+
+func TestDropletCreateSpecFile_WithNames(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		config.Args = append(config.Args, "droplet")
+		config.Doit.Set(config.NS, doit.ArgDropletSpecFile, "../testdata/droplets.yml")
+
+		err := RunDropletCreate(config)
+		assert.Error(t, err)
+	})
+}
+
+func TestDropletCreateSpecFile_MissingSettings(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		config.Doit.Set(config.NS, doit.ArgDropletSpecFile, "../testdata/droplets.yml")
+
+		err := RunDropletCreate(config)
+		assert.Error(t, err)
+	})
+}
+
+func TestReadDropletSpecs_UserDataFileFlag(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doctl-specs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "droplets.yml")
+	spec := "- name: web-1\n  region: nyc3\n  size: 512mb\n  image: \"42\"\n"
+	assert.NoError(t, ioutil.WriteFile(path, []byte(spec), 0644))
+
+	cloudConfig, err := ioutil.ReadFile("../testdata/cloud-config.yml")
+	assert.NoError(t, err)
+
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		config.Doit.Set(config.NS, doit.ArgUserDataFile, "../testdata/cloud-config.yml")
+
+		specs, err := readDropletSpecs(config, path)
+		assert.NoError(t, err)
+		assert.Len(t, specs, 1)
+		assert.Equal(t, string(cloudConfig), specs[0].UserData)
+	})
+}
+
+func Test_renderUserData(t *testing.T) {
+	ctx := userDataContext{
+		Name:  "web-2",
+		Index: 1,
+		Vars:  map[string]string{"role": "web"},
+		Env:   map[string]string{"USER": "sammy"},
+	}
+
+	out, err := renderUserData("{{.Name}} {{.Index}} {{.Vars.role}} {{.Env.USER}}", ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "web-2 1 web sammy", out)
+
+	_, err = renderUserData("{{.Vars.missing}}", ctx)
+	assert.Error(t, err)
+
+	out, err = renderUserData("#cloud-config\n# $private_ipv4\n", ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "#cloud-config\n# $private_ipv4\n", out)
+}
+
+func Test_parseUserDataVars(t *testing.T) {
+	vars, err := parseUserDataVars([]string{"a=1", "b=x=y"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "x=y"}, vars)
+
+	_, err = parseUserDataVars([]string{"novalue"})
+	assert.Error(t, err)
+}
@@ -14,15 +14,18 @@ limitations under the License.
 package commands
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/digitalocean/doctl"
 	"github.com/digitalocean/doctl/do"
+	"github.com/digitalocean/doctl/pkg/cloudinit"
 	"github.com/digitalocean/godo"
 	"github.com/gobwas/glob"
 	"github.com/spf13/cobra"
@@ -41,15 +44,17 @@ func Droplet() *Command {
 		IsIndex:       true,
 	}
 
-	CmdBuilder(cmd, RunDropletActions, "actions <droplet id>", "droplet actions", Writer,
+	CmdBuilder(cmd, RunDropletActions, "actions <droplet>", "droplet actions", Writer,
 		aliasOpt("a"), displayerType(&action{}), docCategories("droplet"))
 
-	CmdBuilder(cmd, RunDropletBackups, "backups <droplet id>", "droplet backups", Writer,
+	CmdBuilder(cmd, RunDropletBackups, "backups <droplet>", "droplet backups", Writer,
 		aliasOpt("b"), displayerType(&image{}), docCategories("droplet"))
 
 	cmdDropletCreate := CmdBuilder(cmd, RunDropletCreate, "create NAME [NAME ...]", "create droplet", Writer,
-		aliasOpt("c"), displayerType(&droplet{}), docCategories("droplet"))
-	AddStringSliceFlag(cmdDropletCreate, doit.ArgSSHKeys, []string{}, "SSH Keys or fingerprints")
+		aliasOpt("c"), displayerType(&dropletCreate{}), docCategories("droplet"))
+	AddStringFlagP(cmdDropletCreate, doit.ArgDropletSpecFile, "f", "", "Droplet spec file")
+	AddStringSliceFlag(cmdDropletCreate, doit.ArgUserDataVar, []string{}, "User data template variables as key=value")
+	AddStringSliceFlag(cmdDropletCreate, doit.ArgSSHKeys, []string{}, "SSH key ids, fingerprints or names")
 	AddStringFlag(cmdDropletCreate, doit.ArgUserData, "", "User data")
 	AddStringFlag(cmdDropletCreate, doit.ArgUserDataFile, "", "User data file")
 	AddBoolFlag(cmdDropletCreate, doit.ArgCommandWait, false, "Wait for droplet to be created")
@@ -60,28 +65,51 @@ func Droplet() *Command {
 	AddBoolFlag(cmdDropletCreate, doit.ArgBackups, false, "Backup droplet")
 	AddBoolFlag(cmdDropletCreate, doit.ArgIPv6, false, "IPv6 support")
 	AddBoolFlag(cmdDropletCreate, doit.ArgPrivateNetworking, false, "Private networking")
-	AddStringFlag(cmdDropletCreate, doit.ArgImage, "", "Droplet image",
+	AddStringFlag(cmdDropletCreate, doit.ArgImage, "", "Droplet image id, slug or name",
 		requiredOpt())
 
-	CmdBuilder(cmd, RunDropletDelete, "delete ID [ID|Name ...]", "Delete droplet by id or name", Writer,
-		aliasOpt("d", "del", "rm"), docCategories("droplet"))
+	cmdDropletDelete := CmdBuilder(cmd, RunDropletDelete, "delete <droplet> [<droplet> ...]",
+		"Delete droplet by id, name or glob", Writer, aliasOpt("d", "del", "rm"), docCategories("droplet"))
+	AddBoolFlag(cmdDropletDelete, doit.ArgForce, false, "Delete every droplet a glob matches without confirmation")
 
-	CmdBuilder(cmd, RunDropletGet, "get", "get droplet", Writer,
-		aliasOpt("g"), displayerType(&droplet{}), docCategories("droplet"))
+	cmdDropletDNSRegister := CmdBuilder(cmd, RunDropletDNSRegister, "dns-register <droplet>",
+		"rename droplet to a FQDN and create its forward DNS records", Writer,
+		displayerType(&domainRecord{}), docCategories("droplet"))
+	AddStringFlag(cmdDropletDNSRegister, doit.ArgFQDN, "", "Fully qualified domain name", requiredOpt())
 
-	CmdBuilder(cmd, RunDropletKernels, "kernels <droplet id>", "droplet kernels", Writer,
+	CmdBuilder(cmd, RunDropletGet, "get <droplet>", "get droplet", Writer,
+		aliasOpt("g"), displayerType(&droplet{}), singleOpt(), docCategories("droplet"))
+
+	CmdBuilder(cmd, RunDropletKernels, "kernels <droplet>", "droplet kernels", Writer,
 		aliasOpt("k"), displayerType(&kernel{}), docCategories("droplet"))
 
 	cmdRunDropletList := CmdBuilder(cmd, RunDropletList, "list [GLOB]", "list droplets", Writer,
-		aliasOpt("ls"), displayerType(&droplet{}), docCategories("droplet"))
+		aliasOpt("ls"), displayerType(&droplet{}), listOpt(), docCategories("droplet"))
+	// godo doesn't report whether backups are enabled, only the backups taken.
+	filter := cmdRunDropletList.Flag(doit.ArgFilter)
+	filter.Usage += ". BackupCount counts backups taken, not whether backups are enabled," +
+		" so is 0 until a droplet's first backup"
 	AddStringFlag(cmdRunDropletList, doit.ArgRegionSlug, "", "Droplet region")
+	AddStringFlag(cmdRunDropletList, doit.ArgTagName, "", "Only list droplets with this tag")
 
-	CmdBuilder(cmd, RunDropletNeighbors, "neighbors <droplet id>", "droplet neighbors", Writer,
+	CmdBuilder(cmd, RunDropletNeighbors, "neighbors <droplet>", "droplet neighbors", Writer,
 		aliasOpt("n"), displayerType(&droplet{}), docCategories("droplet"))
 
-	CmdBuilder(cmd, RunDropletSnapshots, "snapshots <droplet id>", "snapshots", Writer,
+	CmdBuilder(cmd, RunDropletSnapshots, "snapshots <droplet>", "snapshots", Writer,
 		aliasOpt("s"), displayerType(&image{}), docCategories("droplet"))
 
+	cmdUserData := &Command{
+		Command: &cobra.Command{
+			Use:   "user-data",
+			Short: "user data commands",
+			Long:  "user-data is used to check droplet user data",
+		},
+	}
+	cmd.AddCommand(cmdUserData)
+
+	CmdBuilder(cmdUserData, RunDropletUserDataLint, "lint <file>", "check user data for problems", Writer,
+		displayerType(&userDataLint{}), docCategories("droplet"))
+
 	return cmd
 }
 
@@ -90,7 +118,7 @@ func RunDropletActions(c *CmdConfig) error {
 
 	ds := c.Droplets()
 
-	id, err := getDropletIDArg(c.NS, c.Args)
+	id, err := getDropletIDArg(c)
 	if err != nil {
 		return err
 	}
@@ -105,7 +133,7 @@ func RunDropletBackups(c *CmdConfig) error {
 
 	ds := c.Droplets()
 
-	id, err := getDropletIDArg(c.NS, c.Args)
+	id, err := getDropletIDArg(c)
 	if err != nil {
 		return err
 	}
@@ -121,135 +149,343 @@ func RunDropletBackups(c *CmdConfig) error {
 
 // RunDropletCreate creates a droplet.
 func RunDropletCreate(c *CmdConfig) error {
-
-	if len(c.Args) < 1 {
-		return doit.NewMissingArgsErr(c.NS)
-	}
-
-	region, err := c.Doit.GetString(c.NS, doit.ArgRegionSlug)
+	specFile, err := c.Doit.GetString(c.NS, doit.ArgDropletSpecFile)
 	if err != nil {
 		return err
 	}
 
-	size, err := c.Doit.GetString(c.NS, doit.ArgSizeSlug)
+	var specs []dropletSpec
+	if specFile != "" {
+		if len(c.Args) > 0 {
+			return errors.New("droplet names can't be combined with a spec file")
+		}
+
+		specs, err = readDropletSpecs(c, specFile)
+	} else {
+		if len(c.Args) < 1 {
+			return doit.NewMissingArgsErr(c.NS)
+		}
+
+		specs, err = dropletSpecsFromArgs(c)
+	}
 	if err != nil {
 		return err
 	}
 
-	backups, err := c.Doit.GetBool(c.NS, doit.ArgBackups)
-	if err != nil {
-		return err
+	r := newResolver(c)
+	for i, spec := range specs {
+		if err := cloudinit.Validate([]byte(spec.UserData)); err != nil {
+			return fmt.Errorf("droplet %q: %v", spec.Name, err)
+		}
+
+		keys, err := resolveSSHKeys(r, spec.SSHKeys)
+		if err != nil {
+			return fmt.Errorf("droplet %q: %v", spec.Name, err)
+		}
+		specs[i].SSHKeys = keys
+
+		image, err := resolveCreateImage(r, spec.Image)
+		if err != nil {
+			return fmt.Errorf("droplet %q: %v", spec.Name, err)
+		}
+		specs[i].Image = image
 	}
 
-	ipv6, err := c.Doit.GetBool(c.NS, doit.ArgIPv6)
+	wait, err := c.Doit.GetBool(c.NS, doit.ArgCommandWait)
 	if err != nil {
 		return err
 	}
 
-	privateNetworking, err := c.Doit.GetBool(c.NS, doit.ArgPrivateNetworking)
-	if err != nil {
+	if err := ensureTags(c.Tags(), specs); err != nil {
 		return err
 	}
 
-	keys, err := c.Doit.GetStringSlice(c.NS, doit.ArgSSHKeys)
-	if err != nil {
-		return err
+	results, actionIDs := createDroplets(c.Droplets(), specs)
+
+	if wait {
+		waitForDroplets(c, results, actionIDs)
 	}
 
-	sshKeys := extractSSHKeys(keys)
+	ts := c.Tags()
+	failed := 0
+	for _, r := range results {
+		if r.Error == "" && r.Droplet != nil {
+			if err := tagDroplet(ts, r.Droplet.ID, r.tags); err != nil {
+				r.Error = err.Error()
+			}
+		}
 
-	userData, err := c.Doit.GetString(c.NS, doit.ArgUserData)
-	if err != nil {
-		return err
+		if r.Error != "" {
+			failed++
+		}
 	}
 
-	filename, err := c.Doit.GetString(c.NS, doit.ArgUserDataFile)
-	if err != nil {
+	item := &dropletCreate{results: results}
+	if err := c.Display(item); err != nil {
 		return err
 	}
 
-	userData, err = extractUserData(userData, filename)
-	if err != nil {
-		return err
+	if failed > 0 {
+		return fmt.Errorf("unable to create %d of %d droplets", failed, len(results))
 	}
 
-	var createImage godo.DropletCreateImage
+	return nil
+}
 
-	imageStr, err := c.Doit.GetString(c.NS, doit.ArgImage)
-	if i, err := strconv.Atoi(imageStr); err == nil {
-		createImage = godo.DropletCreateImage{ID: i}
-	} else {
-		createImage = godo.DropletCreateImage{Slug: imageStr}
+// dropletMultiCreateLimit is the most droplets created by a single request.
+const dropletMultiCreateLimit = 10
+
+// dropletCreateResult is the outcome of creating a single droplet.
+type dropletCreateResult struct {
+	Name    string      `json:"name"`
+	Droplet *do.Droplet `json:"droplet,omitempty"`
+	Error   string      `json:"error,omitempty"`
+
+	tags []string
+}
+
+// createDroplets creates droplets through the multi-create endpoint. Specs
+// which only differ by name are created together, up to
+// dropletMultiCreateLimit at a time. It returns a result for each spec and the
+// ids of the create actions.
+func createDroplets(ds do.DropletsService, specs []dropletSpec) ([]*dropletCreateResult, []int) {
+	results := []*dropletCreateResult{}
+	actionIDs := []int{}
+
+	for _, batch := range batchDropletSpecs(specs) {
+		batchResults := make([]*dropletCreateResult, len(batch))
+		names := make([]string, len(batch))
+		for i, spec := range batch {
+			batchResults[i] = &dropletCreateResult{Name: spec.Name, tags: spec.Tags}
+			names[i] = spec.Name
+		}
+		results = append(results, batchResults...)
+
+		dcr := batch[0].createRequest()
+		dmcr := &godo.DropletMultiCreateRequest{
+			Names:             names,
+			Region:            dcr.Region,
+			Size:              dcr.Size,
+			Image:             dcr.Image,
+			SSHKeys:           dcr.SSHKeys,
+			Backups:           dcr.Backups,
+			IPv6:              dcr.IPv6,
+			PrivateNetworking: dcr.PrivateNetworking,
+			UserData:          dcr.UserData,
+		}
+
+		droplets, actions, err := ds.CreateMultiple(dmcr)
+		if err != nil {
+			for _, r := range batchResults {
+				r.Error = err.Error()
+			}
+			continue
+		}
+
+		for i := range droplets {
+			d := droplets[i]
+			for _, r := range batchResults {
+				if r.Droplet == nil && r.Name == d.Name {
+					r.Droplet = &d
+					break
+				}
+			}
+		}
+
+		for _, r := range batchResults {
+			if r.Droplet == nil {
+				r.Error = "droplet was not created"
+			}
+		}
+
+		for _, a := range actions {
+			if a.Rel == "create" {
+				actionIDs = append(actionIDs, a.ID)
+			}
+		}
 	}
 
-	wait, err := c.Doit.GetBool(c.NS, doit.ArgCommandWait)
-	if err != nil {
-		return err
+	return results, actionIDs
+}
+
+// batchDropletSpecs groups specs which only differ by name, keeping the order
+// they were first seen in, and splits the groups into batches that can be
+// created with a single request.
+func batchDropletSpecs(specs []dropletSpec) [][]dropletSpec {
+	var keys []string
+	groups := map[string][]dropletSpec{}
+
+	for _, spec := range specs {
+		dcr := spec.createRequest()
+		dcr.Name = ""
+		b, _ := json.Marshal(dcr)
+		key := string(b)
+
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], spec)
 	}
 
-	ds := c.Droplets()
+	var batches [][]dropletSpec
+	for _, key := range keys {
+		group := groups[key]
+		for len(group) > dropletMultiCreateLimit {
+			batches = append(batches, group[:dropletMultiCreateLimit])
+			group = group[dropletMultiCreateLimit:]
+		}
+		batches = append(batches, group)
+	}
 
+	return batches
+}
+
+// waitForDroplets waits for the create actions to finish and refreshes the
+// created droplets.
+func waitForDroplets(c *CmdConfig, results []*dropletCreateResult, actionIDs []int) {
+	var mu sync.Mutex
 	var wg sync.WaitGroup
-	errs := make(chan error, len(c.Args))
-	for _, name := range c.Args {
-		dcr := &godo.DropletCreateRequest{
-			Name:              name,
-			Region:            region,
-			Size:              size,
-			Image:             createImage,
-			Backups:           backups,
-			IPv6:              ipv6,
-			PrivateNetworking: privateNetworking,
-			SSHKeys:           sshKeys,
-			UserData:          userData,
-		}
+	actions := map[int]*do.Action{}
+	var waitErrs []string
 
+	for _, id := range actionIDs {
 		wg.Add(1)
-		go func() {
+		go func(id int) {
 			defer wg.Done()
-			d, err := ds.Create(dcr, wait)
+			a, err := actionWait(c, id, 5)
+
+			mu.Lock()
+			defer mu.Unlock()
 			if err != nil {
-				errs <- err
+				waitErrs = append(waitErrs, fmt.Sprintf("create action %d: %v", id, err))
 				return
 			}
-
-			item := &droplet{droplets: do.Droplets{*d}}
-			c.Display(item)
-		}()
+			actions[a.ResourceID] = a
+		}(id)
 	}
 
 	wg.Wait()
-	close(errs)
+	sort.Strings(waitErrs)
+
+	ds := c.Droplets()
+	for _, r := range results {
+		if r.Droplet == nil {
+			continue
+		}
+
+		// A failed wait can't be tied to a droplet, so droplets without a
+		// finished create action are reported as failed.
+		a, ok := actions[r.Droplet.ID]
+		switch {
+		case ok && a.Status != "completed":
+			r.Error = fmt.Sprintf("create action %d finished with status %q", a.ID, a.Status)
+		case !ok && len(waitErrs) > 0:
+			r.Error = "unable to wait for droplet: " + strings.Join(waitErrs, "; ")
+		}
 
-	for err := range errs {
+		d, err := ds.Get(r.Droplet.ID)
 		if err != nil {
-			return err
+			if r.Error == "" {
+				r.Error = err.Error()
+			}
+			continue
 		}
+
+		r.Droplet = d
 	}
+}
 
-	return nil
+// dropletSpecsFromArgs builds a spec for each droplet name passed as an
+// argument using the settings from the command flags.
+func dropletSpecsFromArgs(c *CmdConfig) ([]dropletSpec, error) {
+	defaults, err := dropletSpecDefaults(c, false)
+	if err != nil {
+		return nil, err
+	}
+
+	userData, err := extractUserData(defaults.UserData, defaults.UserDataFile)
+	if err != nil {
+		return nil, err
+	}
+
+	specs := []dropletSpec{}
+	for _, name := range c.Args {
+		spec := *defaults
+		spec.Name = name
+		spec.UserData = userData
+		spec.UserDataFile = ""
+		specs = append(specs, spec)
+	}
+
+	return specs, nil
 }
 
-func extractSSHKeys(keys []string) []godo.DropletCreateSSHKey {
-	sshKeys := []godo.DropletCreateSSHKey{}
+// splitSSHKeys flattens ssh key flag values, which may be bracketed and
+// comma separated lists.
+func splitSSHKeys(keys []string) []string {
+	out := []string{}
 
 	for _, rawKey := range keys {
 		rawKey = strings.TrimPrefix(rawKey, "[")
 		rawKey = strings.TrimSuffix(rawKey, "]")
 
-		keys := strings.Split(rawKey, ",")
+		out = append(out, strings.Split(rawKey, ",")...)
+	}
 
-		for _, k := range keys {
-			if i, err := strconv.Atoi(k); err == nil {
-				if i > 0 {
-					sshKeys = append(sshKeys, godo.DropletCreateSSHKey{ID: i})
-				}
-				continue
-			}
+	return out
+}
+
+// resolveSSHKeys replaces ssh key names with their ids.
+func resolveSSHKeys(r *resolver, keys []string) ([]string, error) {
+	resolved := []string{}
+	for _, k := range splitSSHKeys(keys) {
+		if k == "" {
+			continue
+		}
+
+		id, err := r.KeyID(k)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, id)
+	}
+
+	return resolved, nil
+}
+
+// resolveCreateImage resolves the image of a droplet to be created by id,
+// slug or name. Images are passed to the API by slug when they have one and
+// by id otherwise.
+func resolveCreateImage(r *resolver, ref string) (string, error) {
+	if _, err := strconv.Atoi(ref); err == nil {
+		return ref, nil
+	}
 
-			if k != "" {
-				sshKeys = append(sshKeys, godo.DropletCreateSSHKey{Fingerprint: k})
+	img, err := r.Image(ref)
+	if err != nil {
+		return "", err
+	}
+
+	if img.Slug != "" {
+		return img.Slug, nil
+	}
+
+	return strconv.Itoa(img.ID), nil
+}
+
+func extractSSHKeys(keys []string) []godo.DropletCreateSSHKey {
+	sshKeys := []godo.DropletCreateSSHKey{}
+
+	for _, k := range splitSSHKeys(keys) {
+		if i, err := strconv.Atoi(k); err == nil {
+			if i > 0 {
+				sshKeys = append(sshKeys, godo.DropletCreateSSHKey{ID: i})
 			}
+			continue
+		}
+
+		if k != "" {
+			sshKeys = append(sshKeys, godo.DropletCreateSSHKey{Fingerprint: k})
 		}
 	}
 
@@ -277,36 +513,53 @@ func RunDropletDelete(c *CmdConfig) error {
 		return doit.NewMissingArgsErr(c.NS)
 	}
 
-	listedDroplets := false
-	list := do.Droplets{}
+	force, err := c.Doit.GetBool(c.NS, doit.ArgForce)
+	if err != nil {
+		return err
+	}
 
-	for _, idStr := range c.Args {
-		id, err := strconv.Atoi(idStr)
+	r := newResolver(c)
+	var ids []int
+	seen := map[int]bool{}
+	confirm := false
+	for _, ref := range c.Args {
+		refIDs, err := r.DropletIDs(ref)
 		if err != nil {
-			if !listedDroplets {
-				list, err = ds.List()
-				if err != nil {
-					return errors.New("unable to build list of droplets")
-				}
-				listedDroplets = true
-			}
+			return err
+		}
 
-			var matchedDroplet *do.Droplet
-			for _, d := range list {
-				if d.Name == idStr {
-					matchedDroplet = &d
-					break
-				}
-			}
+		// A glob can match more droplets than intended, so they are listed
+		// for confirmation.
+		if isGlob(ref) && len(refIDs) > 1 {
+			confirm = true
+		}
 
-			if matchedDroplet == nil {
-				return fmt.Errorf("unable to find droplet with name %q", idStr)
+		for _, id := range refIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
 			}
+		}
+	}
+
+	if confirm && !force {
+		names := map[int]string{}
+		for _, d := range r.droplets {
+			names[d.ID] = d.Name
+		}
+
+		fmt.Fprintln(c.Out, "droplets to delete:")
+		for _, id := range ids {
+			fmt.Fprintf(c.Out, "  %d %s\n", id, names[id])
+		}
 
-			id = matchedDroplet.ID
+		if err := askForConfirm(fmt.Sprintf("delete %d droplets", len(ids))); err != nil {
+			return err
 		}
+	}
 
-		err = ds.Delete(id)
+	for _, id := range ids {
+		err := ds.Delete(id)
 		if err != nil {
 			return fmt.Errorf("unable to delete droplet %d: %v", id, err)
 		}
@@ -317,16 +570,147 @@ func RunDropletDelete(c *CmdConfig) error {
 	return nil
 }
 
-// RunDropletGet returns a droplet.
-func RunDropletGet(c *CmdConfig) error {
-	id, err := getDropletIDArg(c.NS, c.Args)
+// RunDropletDNSRegister renames a droplet to a fully qualified domain name, so
+// its reverse DNS matches, and creates or updates the forward A and AAAA
+// records in the matching domain.
+func RunDropletDNSRegister(c *CmdConfig) error {
+	if len(c.Args) != 1 {
+		return doit.NewMissingArgsErr(c.NS)
+	}
+
+	fqdn, err := c.Doit.GetString(c.NS, doit.ArgFQDN)
 	if err != nil {
 		return err
 	}
 
-	ds := c.Droplets()
+	fqdn = strings.ToLower(strings.TrimSuffix(fqdn, "."))
+	if fqdn == "" {
+		return doit.NewMissingArgsErr(c.NS)
+	}
 
-	d, err := ds.Get(id)
+	d, err := newResolver(c).Droplet(c.Args[0])
+	if err != nil {
+		return err
+	}
+
+	ds := c.Domains()
+
+	domains, err := ds.List()
+	if err != nil {
+		return err
+	}
+
+	domainName, recordName, err := splitFQDN(domains, fqdn)
+	if err != nil {
+		return err
+	}
+
+	if d.Name != fqdn {
+		a, err := c.DropletActions().Rename(d.ID, fqdn)
+		if err != nil {
+			return err
+		}
+
+		a, err = actionWait(c, a.ID, 5)
+		if err != nil {
+			return err
+		}
+
+		if a.Status != "completed" {
+			return fmt.Errorf("rename of droplet %d to %q finished with status %q", d.ID, fqdn, a.Status)
+		}
+	}
+
+	wanted := map[string]string{}
+	if ip, err := d.PublicIPv4(); err == nil && ip != "" {
+		wanted["A"] = ip
+	}
+	if ip, err := d.PublicIPv6(); err == nil && ip != "" {
+		wanted["AAAA"] = ip
+	}
+
+	if len(wanted) == 0 {
+		return fmt.Errorf("droplet %d has no public addresses", d.ID)
+	}
+
+	existing, err := ds.Records(domainName)
+	if err != nil {
+		return err
+	}
+
+	var registered do.DomainRecords
+	for _, rType := range []string{"A", "AAAA"} {
+		ip, ok := wanted[rType]
+		if !ok {
+			continue
+		}
+
+		drer := &godo.DomainRecordEditRequest{
+			Type: rType,
+			Name: recordName,
+			Data: ip,
+		}
+
+		var current *do.DomainRecord
+		for i := range existing {
+			r := existing[i]
+			if r.Type == rType && strings.EqualFold(r.Name, recordName) {
+				current = &r
+				break
+			}
+		}
+
+		var r *do.DomainRecord
+		switch {
+		case current == nil:
+			r, err = ds.CreateRecord(domainName, drer)
+		case current.Data != ip:
+			r, err = ds.EditRecord(domainName, current.ID, drer)
+		default:
+			r = current
+		}
+		if err != nil {
+			return err
+		}
+
+		registered = append(registered, *r)
+	}
+
+	item := &domainRecord{domainRecords: registered}
+	return c.Display(item)
+}
+
+// splitFQDN splits a fully qualified domain name into the most specific
+// domain on the account containing it and the record name within that domain.
+func splitFQDN(domains do.Domains, fqdn string) (string, string, error) {
+	var domainName string
+	for _, d := range domains {
+		name := strings.ToLower(d.Name)
+		if fqdn == name || strings.HasSuffix(fqdn, "."+name) {
+			if len(name) > len(domainName) {
+				domainName = name
+			}
+		}
+	}
+
+	if domainName == "" {
+		return "", "", fmt.Errorf("no domain found for %q", fqdn)
+	}
+
+	if fqdn == domainName {
+		return domainName, "@", nil
+	}
+
+	return domainName, strings.TrimSuffix(fqdn, "."+domainName), nil
+}
+
+// RunDropletGet returns a droplet.
+func RunDropletGet(c *CmdConfig) error {
+	if len(c.Args) != 1 {
+		return doit.NewMissingArgsErr(c.NS)
+	}
+
+	d, err := newResolver(c).Droplet(c.Args[0])
 	if err != nil {
 		return err
 	}
@@ -339,7 +723,7 @@ func RunDropletGet(c *CmdConfig) error {
 func RunDropletKernels(c *CmdConfig) error {
 
 	ds := c.Droplets()
-	id, err := getDropletIDArg(c.NS, c.Args)
+	id, err := getDropletIDArg(c)
 	if err != nil {
 		return err
 	}
@@ -363,6 +747,11 @@ func RunDropletList(c *CmdConfig) error {
 		return err
 	}
 
+	tag, err := c.Doit.GetString(c.NS, doit.ArgTagName)
+	if err != nil {
+		return err
+	}
+
 	matches := []glob.Glob{}
 	for _, globStr := range c.Args {
 		g, err := glob.Compile(globStr)
@@ -375,7 +764,12 @@ func RunDropletList(c *CmdConfig) error {
 
 	var matchedList do.Droplets
 
-	list, err := ds.List()
+	var list do.Droplets
+	if tag != "" {
+		list, err = ds.ListByTag(tag)
+	} else {
+		list, err = ds.List()
+	}
 	if err != nil {
 		return err
 	}
@@ -412,7 +806,7 @@ func RunDropletNeighbors(c *CmdConfig) error {
 
 	ds := c.Droplets()
 
-	id, err := getDropletIDArg(c.NS, c.Args)
+	id, err := getDropletIDArg(c)
 	if err != nil {
 		return err
 	}
@@ -430,7 +824,7 @@ func RunDropletNeighbors(c *CmdConfig) error {
 func RunDropletSnapshots(c *CmdConfig) error {
 
 	ds := c.Droplets()
-	id, err := getDropletIDArg(c.NS, c.Args)
+	id, err := getDropletIDArg(c)
 	if err != nil {
 		return err
 	}
@@ -444,10 +838,42 @@ func RunDropletSnapshots(c *CmdConfig) error {
 	return c.Display(item)
 }
 
-func getDropletIDArg(ns string, args []string) (int, error) {
-	if len(args) != 1 {
-		return 0, doit.NewMissingArgsErr(ns)
+// RunDropletUserDataLint checks a user data file for problems.
+func RunDropletUserDataLint(c *CmdConfig) error {
+	if len(c.Args) != 1 {
+		return doit.NewMissingArgsErr(c.NS)
+	}
+
+	data, err := ioutil.ReadFile(c.Args[0])
+	if err != nil {
+		return err
+	}
+
+	problems := cloudinit.Lint(data)
+
+	item := &userDataLint{problems: problems}
+	if err := c.Display(item); err != nil {
+		return err
+	}
+
+	errs := 0
+	for _, p := range problems {
+		if p.Severity == cloudinit.SeverityError {
+			errs++
+		}
+	}
+
+	if errs > 0 {
+		return fmt.Errorf("%s has %d error(s)", c.Args[0], errs)
+	}
+
+	return nil
+}
+
+func getDropletIDArg(c *CmdConfig) (int, error) {
+	if len(c.Args) != 1 {
+		return 0, doit.NewMissingArgsErr(c.NS)
 	}
 
-	return strconv.Atoi(args[0])
+	return newResolver(c).DropletID(c.Args[0])
 }
@@ -14,7 +14,11 @@ limitations under the License.
 package commands
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"strconv"
 	"testing"
 
@@ -31,12 +35,17 @@ var (
 		Regions: []string{"test0"},
 	}}
 	testImageList = do.Images{testImage}
+
+	testCreateImage = do.Image{Image: &godo.Image{ID: 5, Slug: "image"}}
 )
 
 func TestDropletCommand(t *testing.T) {
 	cmd := Droplet()
 	assert.NotNil(t, cmd)
-	assertCommandNames(t, cmd, "actions", "backups", "create", "delete", "get", "kernels", "list", "neighbors", "snapshots")
+	assertCommandNames(t, cmd, "actions", "backups", "create", "delete", "dns-register", "get", "kernels", "list", "neighbors", "snapshots", "user-data")
+
+	filter := subCommand(t, cmd, "list").Flag(doit.ArgFilter)
+	assert.Contains(t, filter.Usage, "not whether backups are enabled")
 }
 
 func TestDropletActionList(t *testing.T) {
@@ -63,8 +72,9 @@ func TestDropletBackupList(t *testing.T) {
 
 func TestDropletCreate(t *testing.T) {
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-		dcr := &godo.DropletCreateRequest{Name: "droplet", Region: "dev0", Size: "1gb", Image: godo.DropletCreateImage{ID: 0, Slug: "image"}, SSHKeys: []godo.DropletCreateSSHKey{}, Backups: false, IPv6: false, PrivateNetworking: false, UserData: "#cloud-config"}
-		tm.droplets.On("Create", dcr, false).Return(&testDroplet, nil)
+		tm.images.On("GetBySlug", "image").Return(&testCreateImage, nil)
+		dmcr := &godo.DropletMultiCreateRequest{Names: []string{"droplet"}, Region: "dev0", Size: "1gb", Image: godo.DropletCreateImage{ID: 0, Slug: "image"}, SSHKeys: []godo.DropletCreateSSHKey{}, Backups: false, IPv6: false, PrivateNetworking: false, UserData: "#cloud-config"}
+		tm.droplets.On("CreateMultiple", dmcr).Return(do.Droplets{createdDroplet("droplet", 1)}, []godo.LinkAction{}, nil)
 
 		config.Args = append(config.Args, "droplet")
 
@@ -80,8 +90,9 @@ func TestDropletCreate(t *testing.T) {
 
 func TestDropletCreateUserDataFile(t *testing.T) {
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-		dcr := &godo.DropletCreateRequest{Name: "droplet", Region: "dev0", Size: "1gb", Image: godo.DropletCreateImage{ID: 0, Slug: "image"}, SSHKeys: []godo.DropletCreateSSHKey{}, Backups: false, IPv6: false, PrivateNetworking: false, UserData: "#cloud-config\n\ncoreos:\n  etcd2:\n    # generate a new token for each unique cluster from https://discovery.etcd.io/new?size=5\n    # specify the initial size of your cluster with ?size=X\n    discovery: https://discovery.etcd.io/<token>\n    # multi-region and multi-cloud deployments need to use $public_ipv4\n    advertise-client-urls: http://$private_ipv4:2379,http://$private_ipv4:4001\n    initial-advertise-peer-urls: http://$private_ipv4:2380\n    # listen on both the official ports and the legacy ports\n    # legacy ports can be omitted if your application doesn't depend on them\n    listen-client-urls: http://0.0.0.0:2379,http://0.0.0.0:4001\n    listen-peer-urls: http://$private_ipv4:2380\n  units:\n    - name: etcd2.service\n      command: start\n    - name: fleet.service\n      command: start\n"}
-		tm.droplets.On("Create", dcr, false).Return(&testDroplet, nil)
+		tm.images.On("GetBySlug", "image").Return(&testCreateImage, nil)
+		dmcr := &godo.DropletMultiCreateRequest{Names: []string{"droplet"}, Region: "dev0", Size: "1gb", Image: godo.DropletCreateImage{ID: 0, Slug: "image"}, SSHKeys: []godo.DropletCreateSSHKey{}, Backups: false, IPv6: false, PrivateNetworking: false, UserData: "#cloud-config\n\ncoreos:\n  etcd2:\n    # generate a new token for each unique cluster from https://discovery.etcd.io/new?size=5\n    # specify the initial size of your cluster with ?size=X\n    discovery: https://discovery.etcd.io/<token>\n    # multi-region and multi-cloud deployments need to use $public_ipv4\n    advertise-client-urls: http://$private_ipv4:2379,http://$private_ipv4:4001\n    initial-advertise-peer-urls: http://$private_ipv4:2380\n    # listen on both the official ports and the legacy ports\n    # legacy ports can be omitted if your application doesn't depend on them\n    listen-client-urls: http://0.0.0.0:2379,http://0.0.0.0:4001\n    listen-peer-urls: http://$private_ipv4:2380\n  units:\n    - name: etcd2.service\n      command: start\n    - name: fleet.service\n      command: start\n"}
+		tm.droplets.On("CreateMultiple", dmcr).Return(do.Droplets{createdDroplet("droplet", 1)}, []godo.LinkAction{}, nil)
 
 		config.Args = append(config.Args, "droplet")
 
@@ -95,6 +106,185 @@ func TestDropletCreateUserDataFile(t *testing.T) {
 	})
 }
 
+func TestDropletCreateChunked(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.images.On("GetBySlug", "image").Return(&testCreateImage, nil)
+		var names []string
+		var droplets do.Droplets
+		for i := 1; i <= 12; i++ {
+			name := fmt.Sprintf("droplet-%d", i)
+			names = append(names, name)
+			droplets = append(droplets, createdDroplet(name, i))
+		}
+
+		base := godo.DropletMultiCreateRequest{Region: "dev0", Size: "1gb", Image: godo.DropletCreateImage{Slug: "image"}, SSHKeys: []godo.DropletCreateSSHKey{}}
+		first, second := base, base
+		first.Names = names[:10]
+		second.Names = names[10:]
+
+		tm.droplets.On("CreateMultiple", &first).Return(droplets[:10], []godo.LinkAction{}, nil)
+		tm.droplets.On("CreateMultiple", &second).Return(do.Droplets{}, []godo.LinkAction{}, errors.New("rate limited"))
+
+		config.Args = append(config.Args, names...)
+
+		config.Doit.Set(config.NS, doit.ArgRegionSlug, "dev0")
+		config.Doit.Set(config.NS, doit.ArgSizeSlug, "1gb")
+		config.Doit.Set(config.NS, doit.ArgImage, "image")
+
+		err := RunDropletCreate(config)
+		assert.EqualError(t, err, "unable to create 2 of 12 droplets")
+	})
+}
+
+func TestDropletCreateWait(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.images.On("GetBySlug", "image").Return(&testCreateImage, nil)
+		dmcr := &godo.DropletMultiCreateRequest{Names: []string{"one", "two"}, Region: "dev0", Size: "1gb", Image: godo.DropletCreateImage{Slug: "image"}, SSHKeys: []godo.DropletCreateSSHKey{}}
+		links := []godo.LinkAction{{ID: 10, Rel: "create"}, {ID: 11, Rel: "create"}}
+		tm.droplets.On("CreateMultiple", dmcr).Return(do.Droplets{createdDroplet("one", 1), createdDroplet("two", 2)}, links, nil)
+
+		completed := do.Action{Action: &godo.Action{ID: 10, Status: "completed", ResourceID: 1}}
+		errored := do.Action{Action: &godo.Action{ID: 11, Status: "errored", ResourceID: 2}}
+		tm.actions.On("Get", 10).Return(&completed, nil)
+		tm.actions.On("Get", 11).Return(&errored, nil)
+
+		active := createdDroplet("one", 1)
+		active.Status = "active"
+		tm.droplets.On("Get", 1).Return(&active, nil)
+		tm.droplets.On("Get", 2).Return(&testDroplet, nil)
+
+		config.Args = append(config.Args, "one", "two")
+
+		config.Doit.Set(config.NS, doit.ArgRegionSlug, "dev0")
+		config.Doit.Set(config.NS, doit.ArgSizeSlug, "1gb")
+		config.Doit.Set(config.NS, doit.ArgImage, "image")
+		config.Doit.Set(config.NS, doit.ArgCommandWait, true)
+
+		err := RunDropletCreate(config)
+		assert.EqualError(t, err, "unable to create 1 of 2 droplets")
+	})
+}
+
+func TestDropletCreateWaitError(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.images.On("GetBySlug", "image").Return(&testCreateImage, nil)
+		dmcr := &godo.DropletMultiCreateRequest{Names: []string{"one", "two"}, Region: "dev0", Size: "1gb", Image: godo.DropletCreateImage{Slug: "image"}, SSHKeys: []godo.DropletCreateSSHKey{}}
+		links := []godo.LinkAction{{ID: 10, Rel: "create"}, {ID: 11, Rel: "create"}}
+		tm.droplets.On("CreateMultiple", dmcr).Return(do.Droplets{createdDroplet("one", 1), createdDroplet("two", 2)}, links, nil)
+
+		completed := do.Action{Action: &godo.Action{ID: 10, Status: "completed", ResourceID: 1}}
+		tm.actions.On("Get", 10).Return(&completed, nil)
+		tm.actions.On("Get", 11).Return(nil, errors.New("timeout"))
+
+		active := createdDroplet("one", 1)
+		active.Status = "active"
+		tm.droplets.On("Get", 1).Return(&active, nil)
+		tm.droplets.On("Get", 2).Return(&testDroplet, nil)
+
+		var results []*dropletCreateResult
+		config.displayFn = func(d Displayable) error {
+			results = d.(*dropletCreate).results
+			return nil
+		}
+
+		config.Args = append(config.Args, "one", "two")
+
+		config.Doit.Set(config.NS, doit.ArgRegionSlug, "dev0")
+		config.Doit.Set(config.NS, doit.ArgSizeSlug, "1gb")
+		config.Doit.Set(config.NS, doit.ArgImage, "image")
+		config.Doit.Set(config.NS, doit.ArgCommandWait, true)
+
+		err := RunDropletCreate(config)
+		assert.EqualError(t, err, "unable to create 1 of 2 droplets")
+		assert.Len(t, results, 2)
+		assert.Empty(t, results[0].Error)
+		assert.Equal(t, "unable to wait for droplet: create action 11: timeout", results[1].Error)
+	})
+}
+
+func TestDropletCreateNoActions(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.images.On("GetBySlug", "image").Return(&testCreateImage, nil)
+		dmcr := &godo.DropletMultiCreateRequest{Names: []string{"droplet"}, Region: "dev0", Size: "1gb", Image: godo.DropletCreateImage{Slug: "image"}, SSHKeys: []godo.DropletCreateSSHKey{}}
+		tm.droplets.On("CreateMultiple", dmcr).Return(do.Droplets{createdDroplet("droplet", 1)}, nil, nil)
+
+		config.Args = append(config.Args, "droplet")
+
+		config.Doit.Set(config.NS, doit.ArgRegionSlug, "dev0")
+		config.Doit.Set(config.NS, doit.ArgSizeSlug, "1gb")
+		config.Doit.Set(config.NS, doit.ArgImage, "image")
+
+		err := RunDropletCreate(config)
+		assert.NoError(t, err)
+	})
+}
+
+func TestDropletCreateInvalidUserData(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		config.Args = append(config.Args, "droplet")
+
+		config.Doit.Set(config.NS, doit.ArgRegionSlug, "dev0")
+		config.Doit.Set(config.NS, doit.ArgSizeSlug, "1gb")
+		config.Doit.Set(config.NS, doit.ArgImage, "image")
+		config.Doit.Set(config.NS, doit.ArgUserData, "#cloud-config\nruncmd: [\n")
+
+		err := RunDropletCreate(config)
+		assert.Error(t, err)
+	})
+}
+
+func TestDropletUserDataLint(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		config.Args = append(config.Args, "../testdata/cloud-config.yml")
+
+		err := RunDropletUserDataLint(config)
+		assert.NoError(t, err)
+	})
+}
+
+func TestDropletUserDataLint_Errors(t *testing.T) {
+	f, err := ioutil.TempFile("", "doctl-user-data")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString("#cloud-config\nruncmd: reboot\n")
+	assert.NoError(t, err)
+	f.Close()
+
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		config.Args = append(config.Args, f.Name())
+
+		err := RunDropletUserDataLint(config)
+		assert.Error(t, err)
+	})
+}
+
+func TestDropletUserDataLint_UnknownFormat(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		config.Args = append(config.Args, "../testdata/droplets.yml")
+
+		err := RunDropletUserDataLint(config)
+		assert.NoError(t, err)
+	})
+}
+
+func Test_batchDropletSpecs(t *testing.T) {
+	specs := []dropletSpec{
+		{Name: "a", Region: "nyc1"},
+		{Name: "b", Region: "sfo1"},
+		{Name: "c", Region: "nyc1"},
+	}
+
+	batches := batchDropletSpecs(specs)
+	assert.Len(t, batches, 2)
+	assert.Equal(t, []dropletSpec{specs[0], specs[2]}, batches[0])
+	assert.Equal(t, []dropletSpec{specs[1]}, batches[1])
+}
+
+func createdDroplet(name string, id int) do.Droplet {
+	return do.Droplet{Droplet: &godo.Droplet{ID: id, Name: name, Status: "new"}}
+}
+
 func TestDropletDelete(t *testing.T) {
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
 		tm.droplets.On("Delete", 1).Return(nil)
@@ -118,6 +308,153 @@ func TestDropletDeleteByName(t *testing.T) {
 	})
 }
 
+func TestDropletCreateImageByName(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		snapshot := do.Image{Image: &godo.Image{ID: 11, Name: "web-snapshot"}}
+		tm.images.On("GetBySlug", "web-snapshot").Return(nil, testAPIError(404))
+		tm.images.On("List", false).Return(do.Images{snapshot}, nil)
+
+		dmcr := &godo.DropletMultiCreateRequest{Names: []string{"droplet"}, Region: "dev0", Size: "1gb", Image: godo.DropletCreateImage{ID: 11}, SSHKeys: []godo.DropletCreateSSHKey{}}
+		tm.droplets.On("CreateMultiple", dmcr).Return(do.Droplets{createdDroplet("droplet", 1)}, []godo.LinkAction{}, nil)
+
+		config.Args = append(config.Args, "droplet")
+
+		config.Doit.Set(config.NS, doit.ArgRegionSlug, "dev0")
+		config.Doit.Set(config.NS, doit.ArgSizeSlug, "1gb")
+		config.Doit.Set(config.NS, doit.ArgImage, "web-snapshot")
+
+		err := RunDropletCreate(config)
+		assert.NoError(t, err)
+	})
+}
+
+func TestDropletDeleteGlob(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		droplets := do.Droplets{createdDroplet("web-1", 1), createdDroplet("web-2", 2), createdDroplet("db-1", 3)}
+		tm.droplets.On("List").Return(droplets, nil)
+		tm.droplets.On("Delete", 1).Return(nil).Once()
+		tm.droplets.On("Delete", 2).Return(nil).Once()
+
+		config.Args = append(config.Args, "web-*", "web-1")
+		config.Doit.Set(config.NS, doit.ArgForce, true)
+
+		err := RunDropletDelete(config)
+		assert.NoError(t, err)
+	})
+}
+
+func TestDropletDeleteGlobConfirm(t *testing.T) {
+	defer func(fn func(string) (string, error)) { retrieveUserInput = fn }(retrieveUserInput)
+	answer := "n\n"
+	retrieveUserInput = func(string) (string, error) {
+		return answer, nil
+	}
+
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		var buf bytes.Buffer
+		config.Out = &buf
+
+		droplets := do.Droplets{createdDroplet("web-1", 1), createdDroplet("web-2", 2), createdDroplet("db-1", 3)}
+		tm.droplets.On("List").Return(droplets, nil)
+
+		config.Args = append(config.Args, "web-*")
+
+		err := RunDropletDelete(config)
+		assert.Equal(t, errOperationAborted, err)
+		assert.Equal(t, "droplets to delete:\n  1 web-1\n  2 web-2\n", buf.String())
+		tm.droplets.AssertNotCalled(t, "Delete", 1)
+
+		answer = "y\n"
+		tm.droplets.On("Delete", 1).Return(nil).Once()
+		tm.droplets.On("Delete", 2).Return(nil).Once()
+
+		err = RunDropletDelete(config)
+		assert.NoError(t, err)
+	})
+}
+
+func TestDropletDeleteGlobSingleMatch(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		droplets := do.Droplets{createdDroplet("web-1", 1), createdDroplet("db-1", 3)}
+		tm.droplets.On("List").Return(droplets, nil)
+		tm.droplets.On("Delete", 1).Return(nil).Once()
+
+		config.Args = append(config.Args, "web-*")
+
+		err := RunDropletDelete(config)
+		assert.NoError(t, err)
+	})
+}
+
+func TestDropletDeleteGlobNoMatch(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.droplets.On("List").Return(testDropletList, nil)
+
+		config.Args = append(config.Args, "web-*")
+
+		err := RunDropletDelete(config)
+		assert.IsType(t, &NoMatchErr{}, err)
+	})
+}
+
+func TestDropletDNSRegister(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		renameAction := do.Action{Action: &godo.Action{ID: 2, Status: "completed"}}
+		existing := do.DomainRecords{
+			{DomainRecord: &godo.DomainRecord{ID: 7, Type: "A", Name: "mail", Data: "10.0.0.1"}},
+		}
+		drer := &godo.DomainRecordEditRequest{Type: "A", Name: "mail", Data: "8.8.8.8"}
+
+		tm.droplets.On("List").Return(testDropletList, nil)
+		tm.domains.On("List").Return(testDomainList, nil)
+		tm.dropletActions.On("Rename", 1, "mail.example.com").Return(&renameAction, nil)
+		tm.actions.On("Get", 2).Return(&renameAction, nil)
+		tm.domains.On("Records", "example.com").Return(existing, nil)
+		tm.domains.On("EditRecord", "example.com", 7, drer).Return(&testRecord, nil)
+
+		config.Args = append(config.Args, testDroplet.Name)
+		config.Doit.Set(config.NS, doit.ArgFQDN, "mail.example.com.")
+
+		err := RunDropletDNSRegister(config)
+		assert.NoError(t, err)
+	})
+}
+
+func TestDropletDNSRegister_UnknownDomain(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.droplets.On("Get", 1).Return(&testDroplet, nil)
+		tm.domains.On("List").Return(testDomainList, nil)
+
+		config.Args = append(config.Args, "1")
+		config.Doit.Set(config.NS, doit.ArgFQDN, "mail.example.org")
+
+		err := RunDropletDNSRegister(config)
+		assert.Error(t, err)
+	})
+}
+
+func Test_splitFQDN(t *testing.T) {
+	domains := do.Domains{
+		{Domain: &godo.Domain{Name: "example.com"}},
+		{Domain: &godo.Domain{Name: "sub.example.com"}},
+	}
+
+	cases := []struct {
+		fqdn, domain, name string
+	}{
+		{fqdn: "example.com", domain: "example.com", name: "@"},
+		{fqdn: "mail.example.com", domain: "example.com", name: "mail"},
+		{fqdn: "mx.sub.example.com", domain: "sub.example.com", name: "mx"},
+	}
+
+	for _, c := range cases {
+		domain, name, err := splitFQDN(domains, c.fqdn)
+		assert.NoError(t, err)
+		assert.Equal(t, c.domain, domain)
+		assert.Equal(t, c.name, name)
+	}
+}
+
 func TestDropletGet(t *testing.T) {
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
 		tm.droplets.On("Get", testDroplet.ID).Return(&testDroplet, nil)
@@ -171,6 +508,17 @@ func TestDropletsList(t *testing.T) {
 	})
 }
 
+func TestDropletsListByTag(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.droplets.On("ListByTag", "web").Return(testDropletList, nil)
+
+		config.Doit.Set(config.NS, doit.ArgTagName, "web")
+
+		err := RunDropletList(config)
+		assert.NoError(t, err)
+	})
+}
+
 func Test_extractSSHKey(t *testing.T) {
 	cases := []struct {
 		in       string
@@ -18,22 +18,24 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"io/ioutil"
 	"regexp"
 	"testing"
 
+	"github.com/digitalocean/doctl"
 	"github.com/fatih/color"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_checkErr(t *testing.T) {
-	defer func(a func()) { errAction = a }(errAction)
+	defer func(a func(int)) { errAction = a }(errAction)
 	defer func(a io.Writer) { color.Output = a }(color.Output)
 
 	var b bytes.Buffer
 	w := bufio.NewWriter(&b)
 	color.Output = w
 
-	errAction = func() {
+	errAction = func(int) {
 	}
 
 	e := errors.New("an error")
@@ -44,3 +46,26 @@ func Test_checkErr(t *testing.T) {
 	re := regexp.MustCompile(`an error`)
 	assert.True(t, re.Match(b.Bytes()))
 }
+
+func Test_checkErr_ExitCode(t *testing.T) {
+	defer func(a func(int)) { errAction = a }(errAction)
+	defer func(a io.Writer) { color.Output = a }(color.Output)
+	color.Output = ioutil.Discard
+
+	var code int
+	errAction = func(c int) {
+		code = c
+	}
+
+	checkErr(doit.NewMissingArgsErr("test"))
+	assert.Equal(t, doit.ExitMissingArgs, code)
+
+	checkErr(errors.New("an error"))
+	assert.Equal(t, doit.ExitError, code)
+
+	checkErr(&NoMatchErr{Kind: "droplet", Ref: "web-1"})
+	assert.Equal(t, doit.ExitNotFound, code)
+
+	checkErr(&AmbiguousMatchErr{Kind: "droplet", Ref: "web-*", Matches: []string{"web-1 (1)", "web-2 (2)"}})
+	assert.Equal(t, doit.ExitValidation, code)
+}
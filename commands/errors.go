@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/digitalocean/doctl"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -27,8 +28,8 @@ var (
 	colorErr = color.New(color.FgRed).SprintFunc()("Error")
 
 	// errAction specifies what should happen when an error occurs
-	errAction = func() {
-		os.Exit(1)
+	errAction = func(code int) {
+		os.Exit(code)
 	}
 )
 
@@ -37,7 +38,17 @@ type outputErrors struct {
 }
 
 type outputError struct {
-	Detail string `json:"detail"`
+	Detail    string `json:"detail"`
+	ExitCode  int    `json:"exit_code"`
+	Status    int    `json:"status,omitempty"`
+	ID        string `json:"id,omitempty"`
+	Message   string `json:"message,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// apiErrorer is implemented by the typed API errors.
+type apiErrorer interface {
+	API() *doit.APIError
 }
 
 func checkErr(err error, cmd ...*cobra.Command) {
@@ -45,6 +56,9 @@ func checkErr(err error, cmd ...*cobra.Command) {
 		return
 	}
 
+	err = doit.NewAPIError(err)
+	code := doit.ExitCode(err)
+
 	output := viper.GetString("output")
 
 	switch output {
@@ -54,15 +68,22 @@ func checkErr(err error, cmd ...*cobra.Command) {
 		}
 		fmt.Fprintf(color.Output, "\n%s: %v\n", colorErr, err)
 	case "json":
+		oe := outputError{Detail: err.Error(), ExitCode: code}
+		if e, ok := err.(apiErrorer); ok {
+			ae := e.API()
+			oe.Status = ae.Status
+			oe.ID = ae.ID
+			oe.Message = ae.Message
+			oe.RequestID = ae.RequestID
+		}
+
 		es := outputErrors{
-			Errors: []outputError{
-				{Detail: err.Error()},
-			},
+			Errors: []outputError{oe},
 		}
 
 		b, _ := json.Marshal(&es)
 		fmt.Println(string(b))
 	}
 
-	errAction()
+	errAction(code)
 }
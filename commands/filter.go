@@ -0,0 +1,316 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/godo"
+	"github.com/gobwas/glob"
+)
+
+// selectable is a Displayable whose items can be narrowed down. The indexes
+// passed to Select refer to the rows returned by KV.
+type selectable interface {
+	Displayable
+	Select(idx []int) Displayable
+}
+
+// filterOps are the supported filter operators, longest first so "<=" is
+// found before "<".
+var filterOps = []string{"!=", "<=", ">=", "=", "<", ">"}
+
+// rowFilter matches the value of a single KV column.
+type rowFilter struct {
+	key   string
+	op    string
+	value string
+	glob  glob.Glob
+}
+
+// parseRowFilter parses a key=value filter. Besides "=" the operators "!=",
+// "<", "<=", ">" and ">=" are supported. Values compared with "=" or "!="
+// may be globs.
+func parseRowFilter(raw string) (*rowFilter, error) {
+	for _, op := range filterOps {
+		i := strings.Index(raw, op)
+		if i < 1 {
+			continue
+		}
+
+		f := &rowFilter{
+			key:   strings.TrimSpace(raw[:i]),
+			op:    op,
+			value: strings.TrimSpace(raw[i+len(op):]),
+		}
+
+		if op == "=" || op == "!=" {
+			g, err := glob.Compile(f.value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter %q: %v", raw, err)
+			}
+			f.glob = g
+		}
+
+		return f, nil
+	}
+
+	return nil, fmt.Errorf("invalid filter %q, expected key=value", raw)
+}
+
+// match reports whether the filter matches v.
+func (f *rowFilter) match(v interface{}) (bool, error) {
+	switch f.op {
+	case "=":
+		return f.equal(v)
+	case "!=":
+		ok, err := f.equal(v)
+		return !ok, err
+	}
+
+	c, err := compareValue(v, f.value)
+	if err != nil {
+		return false, fmt.Errorf("can't compare %s with %q: %v", f.key, f.value, err)
+	}
+
+	switch f.op {
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+func (f *rowFilter) equal(v interface{}) (bool, error) {
+	if b, ok := v.(bool); ok {
+		want, err := strconv.ParseBool(f.value)
+		if err != nil {
+			return false, fmt.Errorf("%s is true or false", f.key)
+		}
+		return b == want, nil
+	}
+
+	return f.glob.Match(fmt.Sprint(v)), nil
+}
+
+// parseTime parses the timestamps the API returns, or a plain date.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	return time.Parse("2006-01-02", s)
+}
+
+// timeValue returns the time held by a KV value, if any.
+func timeValue(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case *godo.Timestamp:
+		if t != nil {
+			return t.Time, true
+		}
+	case string:
+		if tm, err := parseTime(t); err == nil {
+			return tm, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// compareValue compares a KV value with a string given on the command line.
+// Numbers are compared numerically and timestamps chronologically.
+func compareValue(v interface{}, s string) (int, error) {
+	if a, ok := timeValue(v); ok {
+		b, err := parseTime(s)
+		if err != nil {
+			return 0, err
+		}
+		return compareFloat(float64(a.UnixNano()), float64(b.UnixNano())), nil
+	}
+
+	switch t := v.(type) {
+	case int:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, err
+		}
+		return compareFloat(float64(t), n), nil
+	case float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, err
+		}
+		return compareFloat(t, n), nil
+	default:
+		return strings.Compare(fmt.Sprint(v), s), nil
+	}
+}
+
+// compareValues orders two KV values of the same column.
+func compareValues(a, b interface{}) int {
+	if ta, ok := timeValue(a); ok {
+		if tb, ok := timeValue(b); ok {
+			return compareFloat(float64(ta.UnixNano()), float64(tb.UnixNano()))
+		}
+	}
+
+	switch t := a.(type) {
+	case int:
+		if u, ok := b.(int); ok {
+			return compareFloat(float64(t), float64(u))
+		}
+	case float64:
+		if u, ok := b.(float64); ok {
+			return compareFloat(t, u)
+		}
+	case bool:
+		if u, ok := b.(bool); ok && t != u {
+			if t {
+				return 1
+			}
+			return -1
+		}
+		return 0
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// columnKey finds the KV column named key, ignoring case.
+func columnKey(item Displayable, key string) (string, error) {
+	cm := item.ColMap()
+	for k := range cm {
+		if strings.EqualFold(k, key) {
+			return k, nil
+		}
+	}
+
+	keys := make([]string, 0, len(cm))
+	for k := range cm {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return "", fmt.Errorf("unknown column %q, possible values: %s", key, strings.Join(keys, ","))
+}
+
+// rowSorter sorts row indexes by a KV column.
+type rowSorter struct {
+	rows []map[string]interface{}
+	idx  []int
+	key  string
+	desc bool
+}
+
+func (s *rowSorter) Len() int      { return len(s.idx) }
+func (s *rowSorter) Swap(i, j int) { s.idx[i], s.idx[j] = s.idx[j], s.idx[i] }
+func (s *rowSorter) Less(i, j int) bool {
+	c := compareValues(s.rows[s.idx[i]][s.key], s.rows[s.idx[j]][s.key])
+	if s.desc {
+		return c > 0
+	}
+	return c < 0
+}
+
+// selectRows applies the --filter, --sort-by and --limit flags of a list
+// command to item. Items which can't be narrowed down are returned as is.
+func selectRows(c *CmdConfig, item Displayable) (Displayable, error) {
+	s, ok := item.(selectable)
+	if !ok {
+		return item, nil
+	}
+
+	rawFilters, _ := c.Doit.GetStringSlice(c.NS, doit.ArgFilter)
+	sortBy, _ := c.Doit.GetString(c.NS, doit.ArgSortBy)
+	limit, _ := c.Doit.GetInt(c.NS, doit.ArgLimit)
+
+	if len(rawFilters) == 0 && sortBy == "" && limit <= 0 {
+		return item, nil
+	}
+
+	filters := []*rowFilter{}
+	for _, raw := range rawFilters {
+		f, err := parseRowFilter(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := columnKey(item, f.key)
+		if err != nil {
+			return nil, err
+		}
+		f.key = key
+
+		filters = append(filters, f)
+	}
+
+	rows := item.KV()
+	idx := []int{}
+	for i, r := range rows {
+		matched := true
+		for _, f := range filters {
+			ok, err := f.match(r[f.key])
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			idx = append(idx, i)
+		}
+	}
+
+	if sortBy != "" {
+		desc := strings.HasPrefix(sortBy, "-")
+		key, err := columnKey(item, strings.TrimPrefix(sortBy, "-"))
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Stable(&rowSorter{rows: rows, idx: idx, key: key, desc: desc})
+	}
+
+	if limit > 0 && len(idx) > limit {
+		idx = idx[:limit]
+	}
+
+	return s.Select(idx), nil
+}
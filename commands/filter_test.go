@@ -0,0 +1,121 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/doctl/do"
+	"github.com/digitalocean/godo"
+	"github.com/stretchr/testify/assert"
+)
+
+func filterTestDroplets() *droplet {
+	mk := func(id int, name, status, size, created string, backups []int) do.Droplet {
+		return do.Droplet{Droplet: &godo.Droplet{
+			ID: id, Name: name, Status: status, SizeSlug: size, Created: created,
+			BackupIDs: backups,
+			Image:     &godo.Image{Slug: "ubuntu-14-04-x64", Distribution: "Ubuntu"},
+			Region:    &godo.Region{Slug: "nyc3"},
+			Networks: &godo.Networks{
+				V4: []godo.NetworkV4{{IPAddress: "10.0.0.1", Type: "private"}},
+			},
+		}}
+	}
+
+	return &droplet{droplets: do.Droplets{
+		mk(1, "web-1", "active", "512mb", "2016-03-01T10:00:00Z", nil),
+		mk(2, "web-2", "off", "1gb", "2016-01-15T10:00:00Z", []int{7}),
+		mk(3, "db-1", "active", "2gb", "2016-02-20T10:00:00Z", nil),
+	}}
+}
+
+func selectedIDs(d Displayable) []int {
+	ids := []int{}
+	for _, r := range d.KV() {
+		ids = append(ids, r["ID"].(int))
+	}
+	return ids
+}
+
+func TestSelectRows(t *testing.T) {
+	cases := []struct {
+		name    string
+		filters []string
+		sortBy  string
+		limit   int
+		ids     []int
+	}{
+		{name: "none", ids: []int{1, 2, 3}},
+		{name: "status", filters: []string{"status=active"}, ids: []int{1, 3}},
+		{name: "not status", filters: []string{"Status!=active"}, ids: []int{2}},
+		{name: "glob", filters: []string{"name=web-*"}, ids: []int{1, 2}},
+		{name: "size", filters: []string{"size=1gb"}, ids: []int{2}},
+		{name: "backups", filters: []string{"backupcount>0"}, ids: []int{2}},
+		{name: "private", filters: []string{"privatenetworking=true", "ipv6=false"}, ids: []int{1, 2, 3}},
+		{name: "created after", filters: []string{"created>2016-02-01"}, ids: []int{1, 3}},
+		{name: "created before", filters: []string{"created<2016-02-01T00:00:00Z"}, ids: []int{2}},
+		{name: "sort", sortBy: "created", ids: []int{2, 3, 1}},
+		{name: "sort desc", sortBy: "-name", ids: []int{2, 1, 3}},
+		{name: "limit", filters: []string{"image=Ubuntu*"}, sortBy: "id", limit: 2, ids: []int{1, 2}},
+	}
+
+	for _, c := range cases {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Doit.Set(config.NS, doit.ArgFilter, c.filters)
+			config.Doit.Set(config.NS, doit.ArgSortBy, c.sortBy)
+			config.Doit.Set(config.NS, doit.ArgLimit, c.limit)
+
+			d, err := selectRows(config, filterTestDroplets())
+			if assert.NoError(t, err, c.name) {
+				assert.Equal(t, c.ids, selectedIDs(d), c.name)
+			}
+		})
+	}
+}
+
+func TestSelectRows_Errors(t *testing.T) {
+	cases := []struct {
+		filters []string
+		sortBy  string
+		err     string
+	}{
+		{filters: []string{"status"}, err: `invalid filter "status", expected key=value`},
+		{filters: []string{"colour=red"}, err: "unknown column \"colour\", possible values: BackupCount,Created,Disk,Distribution,ID,IPv6,Image,ImageSlug,Memory,Name,PrivateNetworking,PublicIPv4,Region,Size,Status,VCPUs"},
+		{filters: []string{"memory>lots"}, err: `can't compare Memory with "lots": strconv.ParseFloat: parsing "lots": invalid syntax`},
+		{sortBy: "colour", err: "unknown column \"colour\", possible values: BackupCount,Created,Disk,Distribution,ID,IPv6,Image,ImageSlug,Memory,Name,PrivateNetworking,PublicIPv4,Region,Size,Status,VCPUs"},
+	}
+
+	for _, c := range cases {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Doit.Set(config.NS, doit.ArgFilter, c.filters)
+			config.Doit.Set(config.NS, doit.ArgSortBy, c.sortBy)
+
+			_, err := selectRows(config, filterTestDroplets())
+			assert.EqualError(t, err, c.err)
+		})
+	}
+}
+
+func TestSelectRows_NotSelectable(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		config.Doit.Set(config.NS, doit.ArgLimit, 1)
+
+		item := &domain{}
+		d, err := selectRows(config, item)
+		assert.NoError(t, err)
+		assert.Equal(t, item, d)
+	})
+}
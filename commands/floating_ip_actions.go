@@ -34,15 +34,15 @@ func FloatingIPAction() *Command {
 	}
 
 	CmdBuilder(cmd, RunFloatingIPActionsGet,
-		"get <floating-ip> <action-id>", "get floating-ip action", Writer,
-		displayerType(&action{}), docCategories("floatingip"))
+		"get <floating-ip|droplet> <action-id>", "get floating-ip action", Writer,
+		displayerType(&action{}), singleOpt(), docCategories("floatingip"))
 
 	CmdBuilder(cmd, RunFloatingIPActionsAssign,
-		"assign <floating-ip> <droplet-id>", "assign a floating IP to a droplet", Writer,
+		"assign <floating-ip|droplet> <droplet>", "assign a floating IP to a droplet", Writer,
 		displayerType(&action{}), docCategories("floatingip"))
 
 	CmdBuilder(cmd, RunFloatingIPActionsUnassign,
-		"unassign <floating-ip>", "unassign a floating IP to a droplet", Writer,
+		"unassign <floating-ip|droplet>", "unassign a floating IP to a droplet", Writer,
 		displayerType(&action{}), docCategories("floatingip"))
 
 	return cmd
@@ -54,7 +54,11 @@ func RunFloatingIPActionsGet(c *CmdConfig) error {
 		return doit.NewMissingArgsErr(c.NS)
 	}
 
-	ip := c.Args[0]
+	r := newResolver(c)
+	ip, err := r.FloatingIP(c.Args[0])
+	if err != nil {
+		return err
+	}
 
 	fia := c.FloatingIPActions()
 
@@ -78,11 +82,15 @@ func RunFloatingIPActionsAssign(c *CmdConfig) error {
 		return doit.NewMissingArgsErr(c.NS)
 	}
 
-	ip := c.Args[0]
+	r := newResolver(c)
+	ip, err := r.FloatingIP(c.Args[0])
+	if err != nil {
+		return err
+	}
 
 	fia := c.FloatingIPActions()
 
-	dropletID, err := strconv.Atoi(c.Args[1])
+	dropletID, err := r.DropletID(c.Args[1])
 	if err != nil {
 		return err
 	}
@@ -102,7 +110,11 @@ func RunFloatingIPActionsUnassign(c *CmdConfig) error {
 		return doit.NewMissingArgsErr(c.NS)
 	}
 
-	ip := c.Args[0]
+	r := newResolver(c)
+	ip, err := r.FloatingIP(c.Args[0])
+	if err != nil {
+		return err
+	}
 
 	fia := c.FloatingIPActions()
 
@@ -41,17 +41,17 @@ func FloatingIP() *Command {
 	AddStringFlag(cmdFloatingIPCreate, doit.ArgRegionSlug, "",
 		fmt.Sprintf("Region where to create the floating IP. (mutually exclusive with %s)",
 			doit.ArgDropletID))
-	AddIntFlag(cmdFloatingIPCreate, doit.ArgDropletID, 0,
-		fmt.Sprintf("ID of the droplet to assign the IP to. (mutually exclusive with %s)",
+	AddStringFlag(cmdFloatingIPCreate, doit.ArgDropletID, "",
+		fmt.Sprintf("ID or name of the droplet to assign the IP to. (mutually exclusive with %s)",
 			doit.ArgRegionSlug))
 
-	CmdBuilder(cmd, RunFloatingIPGet, "get <floating-ip>", "get the details of a floating IP", Writer,
-		aliasOpt("g"), displayerType(&floatingIP{}), docCategories("floatingip"))
+	CmdBuilder(cmd, RunFloatingIPGet, "get <floating-ip|droplet>", "get the details of a floating IP", Writer,
+		aliasOpt("g"), displayerType(&floatingIP{}), singleOpt(), docCategories("floatingip"))
 
-	CmdBuilder(cmd, RunFloatingIPDelete, "delete <floating-ip>", "delete a floating IP address", Writer, aliasOpt("d"))
+	CmdBuilder(cmd, RunFloatingIPDelete, "delete <floating-ip|droplet>", "delete a floating IP address", Writer, aliasOpt("d"))
 
 	cmdFloatingIPList := CmdBuilder(cmd, RunFloatingIPList, "list", "list all floating IP addresses", Writer,
-		aliasOpt("ls"), displayerType(&floatingIP{}), docCategories("floatingip"))
+		aliasOpt("ls"), displayerType(&floatingIP{}), listOpt(), docCategories("floatingip"))
 	AddStringFlag(cmdFloatingIPList, doit.ArgRegionSlug, "", "Floating IP region")
 
 	return cmd
@@ -63,16 +63,25 @@ func RunFloatingIPCreate(c *CmdConfig) error {
 
 	// ignore errors since we don't know which one is valid
 	region, _ := c.Doit.GetString(c.NS, doit.ArgRegionSlug)
-	dropletID, _ := c.Doit.GetInt(c.NS, doit.ArgDropletID)
+	droplet, _ := c.Doit.GetString(c.NS, doit.ArgDropletID)
 
-	if region == "" && dropletID == 0 {
+	if region == "" && droplet == "" {
 		return doit.NewMissingArgsErr("region and droplet id can't both be blank")
 	}
 
-	if region != "" && dropletID != 0 {
+	if region != "" && droplet != "" {
 		return fmt.Errorf("specify region or droplet id when creating a floating ip")
 	}
 
+	var dropletID int
+	if droplet != "" {
+		var err error
+		dropletID, err = newResolver(c).DropletID(droplet)
+		if err != nil {
+			return err
+		}
+	}
+
 	req := &godo.FloatingIPCreateRequest{
 		Region:    region,
 		DropletID: dropletID,
@@ -96,12 +105,15 @@ func RunFloatingIPGet(c *CmdConfig) error {
 		return doit.NewMissingArgsErr(c.NS)
 	}
 
-	ip := c.Args[0]
-
-	if len(ip) < 1 {
+	if len(c.Args[0]) < 1 {
 		return errors.New("invalid ip address")
 	}
 
+	ip, err := newResolver(c).FloatingIP(c.Args[0])
+	if err != nil {
+		return err
+	}
+
 	fip, err := fis.Get(ip)
 	if err != nil {
 		return err
@@ -119,7 +131,10 @@ func RunFloatingIPDelete(c *CmdConfig) error {
 		return doit.NewMissingArgsErr(c.NS)
 	}
 
-	ip := c.Args[0]
+	ip, err := newResolver(c).FloatingIP(c.Args[0])
+	if err != nil {
+		return err
+	}
 
 	return fis.Delete(ip)
 }
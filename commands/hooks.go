@@ -0,0 +1,273 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/doctl/pluginhost"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	hookPre  = "pre"
+	hookPost = "post"
+)
+
+// hook is a configured hook. It runs either an executable, which receives
+// the event as JSON on stdin, or a plugin's Hook method.
+type hook struct {
+	// Match is a glob matched against the command namespace, e.g.
+	// "droplet.*". Hooks with no Match run for every command.
+	Match  string   `mapstructure:"match"`
+	Run    string   `mapstructure:"run"`
+	Args   []string `mapstructure:"args"`
+	Plugin string   `mapstructure:"plugin"`
+}
+
+func (h *hook) String() string {
+	if h.Plugin != "" {
+		return "plugin " + h.Plugin
+	}
+	return h.Run
+}
+
+// hooks are configured in the config file as, for example:
+//
+//	hooks:
+//	  pre:
+//	    - match: droplet.create
+//	      run: /usr/local/bin/require-team-tag
+//	  post:
+//	    - match: droplet.delete
+//	      plugin: chat
+type hooks struct {
+	Pre  []hook `mapstructure:"pre"`
+	Post []hook `mapstructure:"post"`
+}
+
+// HookVetoErr is returned when a pre-command hook vetoes a command.
+type HookVetoErr struct {
+	Hook    string
+	Message string
+}
+
+var _ doit.ExitCoder = &HookVetoErr{}
+
+func (e *HookVetoErr) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("command vetoed by hook %s", e.Hook)
+	}
+	return fmt.Sprintf("command vetoed by hook %s: %s", e.Hook, e.Message)
+}
+
+// ExitCode is the exit code for a vetoed command.
+func (e *HookVetoErr) ExitCode() int {
+	return doit.ExitVetoed
+}
+
+// loadHooks reads the configured hooks.
+func loadHooks() (*hooks, error) {
+	var h hooks
+	if err := viper.UnmarshalKey("hooks", &h); err != nil {
+		return nil, fmt.Errorf("invalid hooks configuration: %v", err)
+	}
+
+	return &h, nil
+}
+
+// runPreHooks runs the pre-command hooks matching cmd. The first hook to
+// veto the command stops it.
+func runPreHooks(cmd *cobra.Command, c *CmdConfig) error {
+	if cmd.Hidden {
+		return nil
+	}
+
+	h, err := loadHooks()
+	if err != nil || len(h.Pre) == 0 {
+		return err
+	}
+
+	event := newHookEvent(hookPre, cmd, c)
+	for i := range h.Pre {
+		if !h.Pre[i].matches(c.NS) {
+			continue
+		}
+
+		if err := h.Pre[i].run(c, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runPostHooks runs the post-command hooks matching cmd. They can't change
+// the command's outcome, so their failures are only logged.
+func runPostHooks(cmd *cobra.Command, c *CmdConfig, cmdErr error) {
+	if cmd.Hidden {
+		return
+	}
+
+	h, err := loadHooks()
+	if err != nil || len(h.Post) == 0 {
+		return
+	}
+
+	event := newHookEvent(hookPost, cmd, c)
+	if cmdErr != nil {
+		event.Error = cmdErr.Error()
+	}
+	if c.displayed != nil {
+		var buf bytes.Buffer
+		if err := c.displayed.JSON(&buf); err == nil {
+			event.Result = buf.Bytes()
+		}
+	}
+
+	for i := range h.Post {
+		if !h.Post[i].matches(c.NS) {
+			continue
+		}
+
+		if err := h.Post[i].run(c, event); err != nil {
+			log.Printf("post-command hook %s failed: %v", &h.Post[i], err)
+		}
+	}
+}
+
+func newHookEvent(phase string, cmd *cobra.Command, c *CmdConfig) *pluginhost.HookEvent {
+	return &pluginhost.HookEvent{
+		Phase:   phase,
+		Command: c.NS,
+		Args:    c.Args,
+		Flags:   resolvedFlags(cmd, c),
+	}
+}
+
+// resolvedFlags returns the values of cmd's own flags, whether given on the
+// command line, in the config file or by default. Global flags, such as the
+// access token, are never included.
+func resolvedFlags(cmd *cobra.Command, c *CmdConfig) map[string]interface{} {
+	flags := map[string]interface{}{}
+
+	cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		var v interface{}
+		var err error
+
+		switch f.Value.Type() {
+		case "bool":
+			v, err = c.Doit.GetBool(c.NS, f.Name)
+		case "int":
+			v, err = c.Doit.GetInt(c.NS, f.Name)
+		case "stringSlice":
+			v, err = c.Doit.GetStringSlice(c.NS, f.Name)
+		default:
+			v, err = c.Doit.GetString(c.NS, f.Name)
+		}
+
+		if err == nil {
+			flags[f.Name] = v
+		}
+	})
+
+	return flags
+}
+
+func (h *hook) matches(ns string) bool {
+	if h.Match == "" {
+		return true
+	}
+
+	ok, _ := path.Match(h.Match, ns)
+	return ok
+}
+
+// run runs the hook. A pre hook vetoes the command by returning a
+// HookVetoErr: executables veto by exiting with a non-zero status, plugins
+// by setting Veto in their response.
+func (h *hook) run(c *CmdConfig, event *pluginhost.HookEvent) error {
+	if h.Plugin != "" {
+		return h.runPlugin(c, event)
+	}
+
+	if h.Run == "" {
+		return fmt.Errorf("hook for %q has neither run nor plugin", h.Match)
+	}
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(h.Run, h.Args...)
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), "DOCTL_HOOK_PHASE="+event.Phase, "DOCTL_HOOK_COMMAND="+event.Command)
+
+	err = cmd.Run()
+	if _, ok := err.(*exec.ExitError); ok && event.Phase == hookPre {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		return &HookVetoErr{Hook: h.String(), Message: msg}
+	}
+
+	return err
+}
+
+func (h *hook) runPlugin(c *CmdConfig, event *pluginhost.HookEvent) error {
+	plugs, err := searchPlugins()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range plugs {
+		if p.Name != h.Plugin {
+			continue
+		}
+
+		host, err := newPluginHost(p.Path, pluginServices(c, p))
+		if err != nil {
+			return err
+		}
+		defer host.Close()
+
+		resp, err := host.Hook(p.Name, event)
+		if err != nil {
+			return err
+		}
+
+		if resp.Veto && event.Phase == hookPre {
+			return &HookVetoErr{Hook: h.String(), Message: resp.Message}
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown plugin %q", h.Plugin)
+}
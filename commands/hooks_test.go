@@ -0,0 +1,199 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/doctl/pluginhost"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func withHooks(t *testing.T, config string, fn func()) {
+	var m map[interface{}]interface{}
+	assert.NoError(t, yaml.Unmarshal([]byte(config), &m))
+
+	viper.Set("hooks", m["hooks"])
+	defer viper.Set("hooks", nil)
+
+	fn()
+}
+
+func writeHookScript(t *testing.T, dir, name, body string) string {
+	p := filepath.Join(dir, name)
+	assert.NoError(t, ioutil.WriteFile(p, []byte("#!/bin/sh\n"+body), 0755))
+	return p
+}
+
+func testHookCommand() *cobra.Command {
+	parent := &Command{Command: &cobra.Command{Use: "droplet"}}
+	cmd := CmdBuilder(parent, func(*CmdConfig) error { return nil }, "create", "create droplet", Writer)
+	AddStringFlag(cmd, doit.ArgDropletName, "", "name")
+	AddStringSliceFlag(cmd, doit.ArgSSHKeys, []string{}, "tags")
+	return cmd.Command
+}
+
+func readHookEvent(t *testing.T, p string) *pluginhost.HookEvent {
+	b, err := ioutil.ReadFile(p)
+	assert.NoError(t, err)
+
+	var event pluginhost.HookEvent
+	assert.NoError(t, json.Unmarshal(b, &event))
+	return &event
+}
+
+func TestPreHookVeto(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doctl-hooks")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	script := writeHookScript(t, dir, "policy", `cat > "$1"
+echo "a team tag is required" >&2
+exit 1
+`)
+	eventPath := filepath.Join(dir, "event.json")
+
+	withHooks(t, `
+hooks:
+  pre:
+    - match: droplet.delete
+      run: /bin/false
+    - match: droplet.*
+      run: `+script+`
+      args: [`+eventPath+`]
+`, func() {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.NS = "droplet.create"
+			config.Args = []string{"web"}
+			config.Doit.Set(config.NS, doit.ArgDropletName, "web")
+			config.Doit.Set(config.NS, doit.ArgSSHKeys, []string{"deploy"})
+
+			err := runPreHooks(testHookCommand(), config)
+			assert.EqualError(t, err, "command vetoed by hook "+script+": a team tag is required")
+			assert.Equal(t, doit.ExitVetoed, doit.ExitCode(err))
+
+			event := readHookEvent(t, eventPath)
+			assert.Equal(t, "pre", event.Phase)
+			assert.Equal(t, "droplet.create", event.Command)
+			assert.Equal(t, []string{"web"}, event.Args)
+			assert.Equal(t, "web", event.Flags[doit.ArgDropletName])
+			assert.Equal(t, []interface{}{"deploy"}, event.Flags[doit.ArgSSHKeys])
+			assert.NotContains(t, event.Flags, "access-token")
+		})
+	})
+}
+
+func TestPreHookAllows(t *testing.T) {
+	withHooks(t, `
+hooks:
+  pre:
+    - run: /bin/true
+    - match: domain.*
+      run: /bin/false
+`, func() {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.NS = "droplet.create"
+
+			err := runPreHooks(testHookCommand(), config)
+			assert.NoError(t, err)
+		})
+	})
+}
+
+func TestPostHookResult(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doctl-hooks")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	script := writeHookScript(t, dir, "notify", `cat > "$1"
+exit 1
+`)
+	eventPath := filepath.Join(dir, "event.json")
+
+	withHooks(t, `
+hooks:
+  post:
+    - match: droplet.create
+      run: `+script+`
+      args: [`+eventPath+`]
+`, func() {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.NS = "droplet.create"
+			config.displayed = &droplet{droplets: testDropletList}
+
+			runPostHooks(testHookCommand(), config, errors.New("boom"))
+
+			event := readHookEvent(t, eventPath)
+			assert.Equal(t, "post", event.Phase)
+			assert.Equal(t, "boom", event.Error)
+			assert.Contains(t, string(event.Result), `"name":"a-droplet"`)
+		})
+	})
+}
+
+func TestPluginPreHookVeto(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doctl-hooks")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir)
+	defer os.Setenv("XDG_DATA_HOME", os.Getenv("XDG_DATA_HOME"))
+	os.Setenv("XDG_DATA_HOME", dir)
+	writeHookScript(t, dir, "doit-provider-policy", "")
+
+	h := &fakePluginHost{hookResponse: pluginhost.HookResponse{Veto: true, Message: "not on fridays"}}
+
+	withHooks(t, `
+hooks:
+  pre:
+    - plugin: policy
+`, func() {
+		withFakePluginHost(h, func() {
+			withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+				config.NS = "droplet.create"
+
+				err := runPreHooks(testHookCommand(), config)
+				assert.EqualError(t, err, "command vetoed by hook plugin policy: not on fridays")
+				assert.Len(t, h.events, 1)
+				assert.Equal(t, "droplet.create", h.events[0].Command)
+			})
+		})
+	})
+}
+
+func TestHooksSkipHiddenCommands(t *testing.T) {
+	withHooks(t, `
+hooks:
+  pre:
+    - run: /bin/false
+`, func() {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			cmd := testHookCommand()
+			cmd.Hidden = true
+
+			err := runPreHooks(cmd, config)
+			assert.NoError(t, err)
+		})
+	})
+}
@@ -15,7 +15,6 @@ package commands
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/digitalocean/doctl"
 	"github.com/digitalocean/doctl/do"
@@ -34,12 +33,12 @@ func ImageAction() *Command {
 	}
 
 	cmdImageActionsGet := CmdBuilder(cmd, RunImageActionsGet,
-		"get <image-id>", "get image action", Writer,
-		displayerType(&action{}), docCategories("image"))
+		"get <image>", "get image action", Writer,
+		displayerType(&action{}), singleOpt(), docCategories("image"))
 	AddIntFlag(cmdImageActionsGet, doit.ArgActionID, 0, "action id", requiredOpt())
 
 	cmdImageActionsTransfer := CmdBuilder(cmd, RunImageActionsTransfer,
-		"transfer <image-id>", "transfer image", Writer,
+		"transfer <image>", "transfer image", Writer,
 		displayerType(&action{}), docCategories("image"))
 	AddStringFlag(cmdImageActionsTransfer, doit.ArgRegionSlug, "", "region", requiredOpt())
 	AddBoolFlag(cmdImageActionsTransfer, doit.ArgCommandWait, false, "Wait for action to complete")
@@ -55,7 +54,7 @@ func RunImageActionsGet(c *CmdConfig) error {
 		return doit.NewMissingArgsErr(c.NS)
 	}
 
-	imageID, err := strconv.Atoi(c.Args[0])
+	imageID, err := newResolver(c).ImageID(c.Args[0])
 	if err != nil {
 		return err
 	}
@@ -82,7 +81,7 @@ func RunImageActionsTransfer(c *CmdConfig) error {
 		return doit.NewMissingArgsErr(c.NS)
 	}
 
-	id, err := strconv.Atoi(c.Args[0])
+	id, err := newResolver(c).ImageID(c.Args[0])
 	if err != nil {
 		return err
 	}
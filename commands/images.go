@@ -15,7 +15,6 @@ package commands
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/digitalocean/doctl"
 	"github.com/digitalocean/doctl/do"
@@ -36,32 +35,32 @@ func Images() *Command {
 	}
 
 	cmdImagesList := CmdBuilder(cmd, RunImagesList, "list", "list images", Writer,
-		displayerType(&image{}), docCategories("image"))
+		displayerType(&image{}), listOpt(), docCategories("image"))
 	AddBoolFlag(cmdImagesList, doit.ArgImagePublic, false, "List public images")
 
 	cmdImagesListDistribution := CmdBuilder(cmd, RunImagesListDistribution,
 		"list-distribution", "list distribution images", Writer,
-		displayerType(&image{}), docCategories("image"))
+		displayerType(&image{}), listOpt(), docCategories("image"))
 	AddBoolFlag(cmdImagesListDistribution, doit.ArgImagePublic, false, "List public images")
 
 	cmdImagesListApplication := CmdBuilder(cmd, RunImagesListApplication,
 		"list-application", "list application images", Writer,
-		displayerType(&image{}), docCategories("image"))
+		displayerType(&image{}), listOpt(), docCategories("image"))
 	AddBoolFlag(cmdImagesListApplication, doit.ArgImagePublic, false, "List public images")
 
 	cmdImagesListUser := CmdBuilder(cmd, RunImagesListDistribution,
 		"list-user", "list user images", Writer,
-		displayerType(&image{}), docCategories("image"))
+		displayerType(&image{}), listOpt(), docCategories("image"))
 	AddBoolFlag(cmdImagesListUser, doit.ArgImagePublic, false, "List public images")
 
-	CmdBuilder(cmd, RunImagesGet, "get <image-id|image-slug>", "Get image", Writer,
-		displayerType(&image{}), docCategories("image"))
+	CmdBuilder(cmd, RunImagesGet, "get <image-id|image-slug|image-name>", "Get image", Writer,
+		displayerType(&image{}), singleOpt(), docCategories("image"))
 
-	cmdImagesUpdate := CmdBuilder(cmd, RunImagesUpdate, "update <image-id>", "Update image", Writer,
+	cmdImagesUpdate := CmdBuilder(cmd, RunImagesUpdate, "update <image-id|image-name>", "Update image", Writer,
 		displayerType(&image{}), docCategories("image"))
 	AddStringFlag(cmdImagesUpdate, doit.ArgImageName, "", "Image name", requiredOpt())
 
-	CmdBuilder(cmd, RunImagesDelete, "delete <image-id>", "Delete image", Writer,
+	CmdBuilder(cmd, RunImagesDelete, "delete <image-id|image-name>", "Delete image", Writer,
 		docCategories("image"))
 
 	return cmd
@@ -140,29 +139,17 @@ func RunImagesListUser(c *CmdConfig) error {
 	return c.Display(item)
 }
 
-// RunImagesGet retrieves an image by id or slug.
+// RunImagesGet retrieves an image by id, slug or name.
 func RunImagesGet(c *CmdConfig) error {
-	is := c.Images()
-
 	if len(c.Args) != 1 {
 		return doit.NewMissingArgsErr(c.NS)
 	}
 
-	rawID := c.Args[0]
-
-	var i *do.Image
-	var err error
-
-	if id, cerr := strconv.Atoi(rawID); cerr == nil {
-		i, err = is.GetByID(id)
-	} else {
-		if len(rawID) > 0 {
-			i, err = is.GetBySlug(rawID)
-		} else {
-			err = fmt.Errorf("image identifier is required")
-		}
+	if len(c.Args[0]) < 1 {
+		return fmt.Errorf("image identifier is required")
 	}
 
+	i, err := newResolver(c).Image(c.Args[0])
 	if err != nil {
 		return err
 	}
@@ -179,7 +166,7 @@ func RunImagesUpdate(c *CmdConfig) error {
 		return doit.NewMissingArgsErr(c.NS)
 	}
 
-	id, err := strconv.Atoi(c.Args[0])
+	id, err := newResolver(c).ImageID(c.Args[0])
 	if err != nil {
 		return err
 	}
@@ -207,7 +194,7 @@ func RunImagesDelete(c *CmdConfig) error {
 		return doit.NewMissingArgsErr(c.NS)
 	}
 
-	id, err := strconv.Atoi(c.Args[0])
+	id, err := newResolver(c).ImageID(c.Args[0])
 	if err != nil {
 		return err
 	}
@@ -18,6 +18,7 @@ import (
 	"testing"
 
 	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/doctl/do"
 	"github.com/digitalocean/godo"
 	"github.com/stretchr/testify/assert"
 )
@@ -84,6 +85,18 @@ func TestImagesGetBySlug(t *testing.T) {
 	})
 }
 
+func TestImagesGetByName(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		images := do.Images{{Image: &godo.Image{ID: 11, Name: "web-snapshot"}}}
+		tm.images.On("GetBySlug", "web-snapshot").Return(nil, testAPIError(404))
+		tm.images.On("List", false).Return(images, nil)
+
+		config.Args = append(config.Args, "web-snapshot")
+		err := RunImagesGet(config)
+		assert.NoError(t, err)
+	})
+}
+
 func TestImagesNoID(t *testing.T) {
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
 		err := RunImagesGet(config)
@@ -14,11 +14,16 @@ limitations under the License.
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/digitalocean/doctl/do"
+	"github.com/digitalocean/doctl/pkg/cloudinit"
+	"github.com/digitalocean/doctl/pluginhost"
 )
 
 var (
@@ -111,7 +116,7 @@ type action struct {
 	actions do.Actions
 }
 
-var _ Displayable = &action{}
+var _ selectable = &action{}
 
 func (a *action) JSON(out io.Writer) error {
 	return writeJSON(a.actions, out)
@@ -151,6 +156,15 @@ func (a *action) KV() []map[string]interface{} {
 	return out
 }
 
+func (a *action) Select(idx []int) Displayable {
+	list := make(do.Actions, len(idx))
+	for i, x := range idx {
+		list[i] = a.actions[x]
+	}
+
+	return &action{actions: list}
+}
+
 type domain struct {
 	domains do.Domains
 }
@@ -220,11 +234,48 @@ func (dr *domainRecord) KV() []map[string]interface{} {
 	return out
 }
 
+type recordAudit struct {
+	records []danglingRecord
+}
+
+var _ Displayable = &recordAudit{}
+
+func (ra *recordAudit) JSON(out io.Writer) error {
+	return writeJSON(ra.records, out)
+}
+
+func (ra *recordAudit) Cols() []string {
+	return []string{
+		"Domain", "ID", "Type", "Name", "Data", "Reason",
+	}
+}
+
+func (ra *recordAudit) ColMap() map[string]string {
+	return map[string]string{
+		"Domain": "Domain", "ID": "ID", "Type": "Type", "Name": "Name",
+		"Data": "Data", "Reason": "Reason",
+	}
+}
+
+func (ra *recordAudit) KV() []map[string]interface{} {
+	out := []map[string]interface{}{}
+
+	for _, r := range ra.records {
+		o := map[string]interface{}{
+			"Domain": r.Domain, "ID": r.ID, "Type": r.Type, "Name": r.Name,
+			"Data": r.Data, "Reason": r.Reason,
+		}
+		out = append(out, o)
+	}
+
+	return out
+}
+
 type droplet struct {
 	droplets do.Droplets
 }
 
-var _ Displayable = &droplet{}
+var _ selectable = &droplet{}
 
 func (d *droplet) JSON(out io.Writer) error {
 	return writeJSON(d.droplets, out)
@@ -241,6 +292,9 @@ func (d *droplet) ColMap() map[string]string {
 		"ID": "ID", "Name": "Name", "PublicIPv4": "Public IPv4",
 		"Memory": "Memory", "VCPUs": "VCPUs", "Disk": "Disk",
 		"Region": "Region", "Image": "Image", "Status": "Status",
+		"Size": "Size", "ImageSlug": "Image Slug", "Distribution": "Distribution",
+		"Created": "Created", "IPv6": "IPv6", "PrivateNetworking": "Private Networking",
+		"BackupCount": "Backup Count",
 	}
 }
 
@@ -249,10 +303,14 @@ func (d *droplet) KV() []map[string]interface{} {
 	for _, d := range d.droplets {
 		image := fmt.Sprintf("%s %s", d.Image.Distribution, d.Image.Name)
 		ip, _ := d.PublicIPv4()
+		ips := d.IPs()
 		m := map[string]interface{}{
 			"ID": d.ID, "Name": d.Name, "PublicIPv4": ip,
 			"Memory": d.Memory, "VCPUs": d.Vcpus, "Disk": d.Disk,
 			"Region": d.Region.Slug, "Image": image, "Status": d.Status,
+			"Size": d.SizeSlug, "ImageSlug": d.Image.Slug, "Distribution": d.Image.Distribution,
+			"Created": d.Created, "IPv6": ips[do.InterfacePublicV6] != "",
+			"PrivateNetworking": ips[do.InterfacePrivate] != "", "BackupCount": len(d.BackupIDs),
 		}
 		out = append(out, m)
 	}
@@ -260,11 +318,106 @@ func (d *droplet) KV() []map[string]interface{} {
 	return out
 }
 
+func (d *droplet) Select(idx []int) Displayable {
+	list := make(do.Droplets, len(idx))
+	for i, x := range idx {
+		list[i] = d.droplets[x]
+	}
+
+	return &droplet{droplets: list}
+}
+
+type dropletCreate struct {
+	results []*dropletCreateResult
+}
+
+var _ Displayable = &dropletCreate{}
+
+func (dc *dropletCreate) JSON(out io.Writer) error {
+	return writeJSON(dc.results, out)
+}
+
+func (dc *dropletCreate) Cols() []string {
+	return []string{
+		"ID", "Name", "PublicIPv4", "Region", "Status", "Error",
+	}
+}
+
+func (dc *dropletCreate) ColMap() map[string]string {
+	return map[string]string{
+		"ID": "ID", "Name": "Name", "PublicIPv4": "Public IPv4",
+		"Region": "Region", "Status": "Status", "Error": "Error",
+	}
+}
+
+func (dc *dropletCreate) KV() []map[string]interface{} {
+	out := []map[string]interface{}{}
+	for _, r := range dc.results {
+		var id, ip, region, status string
+		if d := r.Droplet; d != nil {
+			id = fmt.Sprintf("%d", d.ID)
+			ip, _ = d.PublicIPv4()
+			status = d.Status
+			if d.Region != nil {
+				region = d.Region.Slug
+			}
+		}
+
+		m := map[string]interface{}{
+			"ID": id, "Name": r.Name, "PublicIPv4": ip,
+			"Region": region, "Status": status, "Error": r.Error,
+		}
+		out = append(out, m)
+	}
+
+	return out
+}
+
+type userDataLint struct {
+	problems []cloudinit.Problem
+}
+
+var _ Displayable = &userDataLint{}
+
+func (udl *userDataLint) JSON(out io.Writer) error {
+	return writeJSON(udl.problems, out)
+}
+
+func (udl *userDataLint) Cols() []string {
+	return []string{
+		"Line", "Severity", "Message",
+	}
+}
+
+func (udl *userDataLint) ColMap() map[string]string {
+	return map[string]string{
+		"Line": "Line", "Severity": "Severity", "Message": "Message",
+	}
+}
+
+func (udl *userDataLint) KV() []map[string]interface{} {
+	out := []map[string]interface{}{}
+
+	for _, p := range udl.problems {
+		line := ""
+		if p.Line > 0 {
+			line = fmt.Sprintf("%d", p.Line)
+		}
+
+		o := map[string]interface{}{
+			"Line": line, "Severity": p.Severity, "Message": p.Message,
+		}
+		out = append(out, o)
+	}
+
+	return out
+}
+
 type floatingIP struct {
 	floatingIPs do.FloatingIPs
 }
 
-var _ Displayable = &floatingIP{}
+var _ selectable = &floatingIP{}
 
 func (fi *floatingIP) JSON(out io.Writer) error {
 	return writeJSON(fi.floatingIPs, out)
@@ -303,11 +456,20 @@ func (fi *floatingIP) KV() []map[string]interface{} {
 	return out
 }
 
+func (fi *floatingIP) Select(idx []int) Displayable {
+	list := make(do.FloatingIPs, len(idx))
+	for i, x := range idx {
+		list[i] = fi.floatingIPs[x]
+	}
+
+	return &floatingIP{floatingIPs: list}
+}
+
 type image struct {
 	images do.Images
 }
 
-var _ Displayable = &image{}
+var _ selectable = &image{}
 
 func (gi *image) JSON(out io.Writer) error {
 	return writeJSON(gi.images, out)
@@ -346,6 +508,15 @@ func (gi *image) KV() []map[string]interface{} {
 	return out
 }
 
+func (gi *image) Select(idx []int) Displayable {
+	list := make(do.Images, len(idx))
+	for i, x := range idx {
+		list[i] = gi.images[x]
+	}
+
+	return &image{images: list}
+}
+
 type kernel struct {
 	kernels do.Kernels
 }
@@ -386,7 +557,7 @@ type key struct {
 	keys do.SSHKeys
 }
 
-var _ Displayable = &key{}
+var _ selectable = &key{}
 
 func (ke *key) JSON(out io.Writer) error {
 	return writeJSON(ke.keys, out)
@@ -418,6 +589,15 @@ func (ke *key) KV() []map[string]interface{} {
 	return out
 }
 
+func (ke *key) Select(idx []int) Displayable {
+	list := make(do.SSHKeys, len(idx))
+	for i, x := range idx {
+		list[i] = ke.keys[x]
+	}
+
+	return &key{keys: list}
+}
+
 type region struct {
 	regions do.Regions
 }
@@ -529,3 +709,105 @@ func (p *plugin) KV() []map[string]interface{} {
 
 	return out
 }
+
+type pluginResult struct {
+	raw   json.RawMessage
+	items []map[string]interface{}
+	cols  []string
+}
+
+var _ Displayable = &pluginResult{}
+
+func (p *pluginResult) JSON(out io.Writer) error {
+	return writeJSON(p.raw, out)
+}
+
+func (p *pluginResult) Cols() []string {
+	if len(p.cols) > 0 {
+		return p.cols
+	}
+
+	seen := map[string]bool{}
+	var cols []string
+	for _, item := range p.items {
+		var keys []string
+		for k := range item {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		cols = append(cols, keys...)
+	}
+
+	return cols
+}
+
+func (p *pluginResult) ColMap() map[string]string {
+	m := map[string]string{}
+	for _, col := range p.Cols() {
+		m[col] = strings.Title(strings.Replace(col, "_", " ", -1))
+	}
+
+	return m
+}
+
+func (p *pluginResult) KV() []map[string]interface{} {
+	return p.items
+}
+
+type installedPlugin struct {
+	entries     []pluginhost.IndexEntry
+	permissions map[string][]string
+}
+
+var _ Displayable = &installedPlugin{}
+
+func (p *installedPlugin) JSON(out io.Writer) error {
+	return writeJSON(p.entries, out)
+}
+
+func (p *installedPlugin) Cols() []string {
+	cols := []string{
+		"Name", "Version", "Source", "Checksum", "Installed", "Path",
+	}
+
+	if p.permissions != nil {
+		cols = append(cols, "Permissions")
+	}
+
+	return cols
+}
+
+func (p *installedPlugin) ColMap() map[string]string {
+	return map[string]string{
+		"Name":        "Name",
+		"Version":     "Version",
+		"Source":      "Source",
+		"Checksum":    "Checksum",
+		"Installed":   "Installed",
+		"Path":        "Path",
+		"Permissions": "Approved Permissions",
+	}
+}
+
+func (p *installedPlugin) KV() []map[string]interface{} {
+	out := []map[string]interface{}{}
+
+	for _, e := range p.entries {
+		o := map[string]interface{}{
+			"Name":        e.Name,
+			"Version":     e.Version,
+			"Source":      e.Source,
+			"Checksum":    e.Checksum,
+			"Installed":   e.Installed,
+			"Path":        e.Path,
+			"Permissions": strings.Join(p.permissions[e.Name], ","),
+		}
+
+		out = append(out, o)
+	}
+
+	return out
+}
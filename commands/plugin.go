@@ -14,15 +14,38 @@ limitations under the License.
 package commands
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/doctl/pkg/cache"
 	"github.com/digitalocean/doctl/pluginhost"
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
+// pluginHost is the part of pluginhost.Host commands use.
+type pluginHost interface {
+	Invoke(method string, opts *pluginhost.CallOptions) (json.RawMessage, error)
+	Describe(name string) (*pluginhost.Description, error)
+	Hook(name string, event *pluginhost.HookEvent) (*pluginhost.HookResponse, error)
+	Close() error
+}
+
+// newPluginHost starts the plugin at path, which may call services.
+var newPluginHost = func(path string, services *pluginhost.Services) (pluginHost, error) {
+	return pluginhost.NewHost(path, services)
+}
+
 // Plugin creates the plugin commands heirarchy.
 func Plugin() *Command {
 	cmd := &Command{
@@ -37,7 +60,32 @@ func Plugin() *Command {
 	CmdBuilder(cmd, RunPluginList, "list", "list plugins", Writer,
 		aliasOpt("ls"))
 
-	CmdBuilder(cmd, RunPluginRun, "run", "run plugin", Writer)
+	CmdBuilder(cmd, RunPluginRun, "run <plugin> [<method> [<arg> ...]]", "run plugin", Writer)
+
+	cmdPluginInstall := CmdBuilder(cmd, RunPluginInstall, "install <archive>",
+		"install a plugin from a .tar.gz or .zip archive path or URL", Writer,
+		displayerType(&installedPlugin{}))
+	AddStringFlag(cmdPluginInstall, doit.ArgPluginSHA256, "",
+		"SHA-256 checksum of the archive, instead of fetching <archive>.sha256")
+
+	cmdPluginUpgrade := CmdBuilder(cmd, RunPluginUpgrade, "upgrade <plugin> [<archive>]",
+		"upgrade an installed plugin from its original, or a new, archive", Writer,
+		displayerType(&installedPlugin{}))
+	AddStringFlag(cmdPluginUpgrade, doit.ArgPluginSHA256, "",
+		"SHA-256 checksum of the archive, instead of fetching <archive>.sha256")
+
+	CmdBuilder(cmd, RunPluginRemove, "remove <plugin>", "remove an installed plugin", Writer,
+		aliasOpt("rm"))
+
+	CmdBuilder(cmd, RunPluginInfo, "info <plugin>", "show an installed plugin", Writer,
+		displayerType(&installedPlugin{}))
+
+	cmdPluginApprove := CmdBuilder(cmd, RunPluginApprove, "approve <plugin>",
+		"allow a plugin to use the permissions it requests", Writer)
+	AddBoolFlag(cmdPluginApprove, doit.ArgForce, false, "Approve the permissions without confirmation")
+
+	CmdBuilder(cmd, RunPluginRevoke, "revoke <plugin>",
+		"remove all permissions from a plugin", Writer)
 
 	return cmd
 }
@@ -69,11 +117,6 @@ func RunPluginRun(c *CmdConfig) error {
 		pluginArgs = c.Args[1:]
 	}
 
-	host, err := pluginhost.NewHost(selectedPlugin.Path)
-	if err != nil {
-		return err
-	}
-
 	var method string
 	var methodArgs []string
 
@@ -87,19 +130,205 @@ func RunPluginRun(c *CmdConfig) error {
 		methodArgs = pluginArgs[1:]
 	}
 
-	if len(pluginArgs) > 1 {
-		methodArgs = pluginArgs[1:]
+	host, err := newPluginHost(selectedPlugin.Path, pluginServices(c, *selectedPlugin))
+	if err != nil {
+		return err
+	}
+	defer host.Close()
+
+	opts := &pluginhost.CallOptions{Args: methodArgs}
+	result, err := host.Invoke(selectedPlugin.Name+"."+strings.Title(method), opts)
+	if err != nil {
+		return err
+	}
+
+	return displayPluginResult(c, result, nil)
+}
+
+// RunPluginApprove is a command for approving the permissions a plugin
+// requests.
+func RunPluginApprove(c *CmdConfig) error {
+	p, err := findPlugin(c)
+	if err != nil {
+		return err
+	}
+
+	d, err := describePlugin(p)
+	if err != nil {
+		return err
+	}
+
+	if len(d.Permissions) == 0 {
+		fmt.Fprintf(c.Out, "plugin %s doesn't request any permissions\n", p.Name)
+		return nil
+	}
+
+	force, err := c.Doit.GetBool(c.NS, doit.ArgForce)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Out, "plugin %s requests these permissions:\n", p.Name)
+	for _, perm := range d.Permissions {
+		fmt.Fprintf(c.Out, "  %s\n", perm)
+	}
+
+	if !force {
+		if err := askForConfirm(fmt.Sprintf("allow plugin %s to use them", p.Name)); err != nil {
+			return err
+		}
+	}
+
+	if err := setPluginPermissions(p, d.Permissions); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Out, "plugin %s may now use: %s\n", p.Name, strings.Join(d.Permissions, ", "))
+	return nil
+}
+
+// RunPluginRevoke is a command for removing a plugin's permissions.
+func RunPluginRevoke(c *CmdConfig) error {
+	p, err := findPlugin(c)
+	if err != nil {
+		return err
+	}
+
+	return setPluginPermissions(p, nil)
+}
+
+// findPlugin finds the plugin named by the first argument.
+func findPlugin(c *CmdConfig) (plugDesc, error) {
+	if len(c.Args) != 1 {
+		return plugDesc{}, doit.NewMissingArgsErr(c.NS)
+	}
+
+	plugs, err := searchPlugins()
+	if err != nil {
+		return plugDesc{}, err
 	}
 
-	results, err := host.Call(selectedPlugin.Name+"."+strings.Title(method), methodArgs...)
+	for _, p := range plugs {
+		if p.Name == c.Args[0] {
+			return p, nil
+		}
+	}
+
+	return plugDesc{}, fmt.Errorf("unknown plugin %q", c.Args[0])
+}
+
+// pluginPermissionsKey is the configuration key holding the permissions
+// approved for each plugin.
+const pluginPermissionsKey = "plugin-permissions"
+
+// pluginApproval records the permissions approved for a plugin binary. They
+// don't carry over to a binary at another path, or one which has changed.
+type pluginApproval struct {
+	Path        string   `mapstructure:"path" yaml:"path"`
+	SHA256      string   `mapstructure:"sha256" yaml:"sha256"`
+	Permissions []string `mapstructure:"permissions" yaml:"permissions"`
+}
+
+// writeConfig saves a value to the configuration file.
+var writeConfig = func(key string, val interface{}) error {
+	cf, err := doit.NewConfigFile()
 	if err != nil {
 		return err
 	}
 
-	fmt.Fprintln(c.Out, results)
+	return cf.Set(key, val)
+}
+
+// pluginApprovals returns the approvals in the configuration. Approvals
+// which can't be read, such as those made by name only, are skipped so the
+// plugin has to be approved again.
+func pluginApprovals() map[string]pluginApproval {
+	approvals := map[string]pluginApproval{}
+	for name, v := range viper.GetStringMap(pluginPermissionsKey) {
+		var a pluginApproval
+		if err := mapstructure.Decode(v, &a); err == nil && a.Path != "" {
+			approvals[name] = a
+		}
+	}
+
+	return approvals
+}
+
+func setPluginPermissions(p plugDesc, permissions []string) error {
+	approvals := pluginApprovals()
+
+	if len(permissions) == 0 {
+		delete(approvals, p.Name)
+	} else {
+		sum, err := pluginSum(p.Path)
+		if err != nil {
+			return err
+		}
+		approvals[p.Name] = pluginApproval{Path: p.Path, SHA256: sum, Permissions: permissions}
+	}
+
+	if err := writeConfig(pluginPermissionsKey, approvals); err != nil {
+		return err
+	}
+
+	perms := map[string]interface{}{}
+	for name, a := range approvals {
+		perms[name] = a
+	}
+	viper.Set(pluginPermissionsKey, perms)
 	return nil
 }
 
+// approvedPermissions returns the permissions approved for plugin p, if it
+// is the binary which was approved.
+func approvedPermissions(p plugDesc) []string {
+	a, ok := pluginApprovals()[p.Name]
+	if !ok {
+		return nil
+	}
+
+	if sum, err := pluginSum(p.Path); err != nil || a.Path != p.Path || sum != a.SHA256 {
+		fmt.Fprintf(os.Stderr, "plugin %s has changed since its permissions were approved, "+
+			"use \"doctl compute plugin approve %s\" to approve them again\n", p.Name, p.Name)
+		return nil
+	}
+
+	return a.Permissions
+}
+
+// pluginSum returns the SHA-256 checksum of the plugin binary at path.
+func pluginSum(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// pluginServices are the services plugin p may call back into, limited to
+// the permissions the user approved.
+func pluginServices(c *CmdConfig, p plugDesc) *pluginhost.Services {
+	services := map[string]interface{}{
+		"account":             c.Account(),
+		"actions":             c.Actions(),
+		"domains":             c.Domains(),
+		"droplet_actions":     c.DropletActions(),
+		"droplets":            c.Droplets(),
+		"floating_ip_actions": c.FloatingIPActions(),
+		"floating_ips":        c.FloatingIPs(),
+		"image_actions":       c.ImageActions(),
+		"images":              c.Images(),
+		"keys":                c.Keys(),
+		"regions":             c.Regions(),
+		"sizes":               c.Sizes(),
+		"tags":                c.Tags(),
+	}
+
+	return pluginhost.NewServices(services, approvedPermissions(p))
+}
+
 // RunPluginList is a command for listing available plugins.
 func RunPluginList(c *CmdConfig) error {
 	plugs, err := searchPlugins()
@@ -116,20 +345,32 @@ type plugDesc struct {
 	Name string `json:"name"`
 }
 
+// searchPlugins finds the plugins installed by doctl and those in PATH.
+// Installed plugins take precedence over plugins of the same name in PATH.
 func searchPlugins() ([]plugDesc, error) {
 	envPath := os.Getenv("PATH")
 	paths := strings.Split(envPath, string(os.PathListSeparator))
 
+	if store, err := pluginStore(); err == nil {
+		paths = append([]string{store.BinDir()}, paths...)
+	}
+
 	var plugs []plugDesc
+	seen := map[string]bool{}
 
 	for _, p := range paths {
-		matches, err := filepath.Glob(filepath.Join(p, "doit-provider-*"))
+		matches, err := filepath.Glob(filepath.Join(p, pluginhost.PluginPrefix+"*"))
 		if err != nil {
 			return nil, err
 		}
 
 		for _, pluginPath := range matches {
 			name := pluginName(pluginPath)
+			if seen[name] || strings.HasSuffix(name, ".part") {
+				continue
+			}
+			seen[name] = true
+
 			plugs = append(plugs, plugDesc{Path: pluginPath, Name: name})
 		}
 	}
@@ -139,5 +380,371 @@ func searchPlugins() ([]plugDesc, error) {
 
 func pluginName(p string) string {
 	base := filepath.Base(p)
-	return strings.TrimPrefix(base, "doit-provider-")
+	return strings.TrimPrefix(base, pluginhost.PluginPrefix)
+}
+
+// pluginStore returns the store doctl installs plugins in.
+func pluginStore() (*pluginhost.Store, error) {
+	dir, err := pluginhost.DefaultStoreDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return pluginhost.NewStore(dir), nil
+}
+
+// RunPluginInstall is a command for installing a plugin.
+func RunPluginInstall(c *CmdConfig) error {
+	if len(c.Args) != 1 {
+		return doit.NewMissingArgsErr(c.NS)
+	}
+
+	store, err := pluginStore()
+	if err != nil {
+		return err
+	}
+
+	pkg, err := openPlugin(c, c.Args[0])
+	if err != nil {
+		return err
+	}
+
+	entry, err := store.InstallPackage(pkg)
+	if err != nil {
+		return err
+	}
+
+	return c.Display(&installedPlugin{entries: []pluginhost.IndexEntry{*entry}})
+}
+
+// RunPluginUpgrade is a command for upgrading an installed plugin.
+func RunPluginUpgrade(c *CmdConfig) error {
+	if len(c.Args) < 1 || len(c.Args) > 2 {
+		return doit.NewMissingArgsErr(c.NS)
+	}
+
+	store, err := pluginStore()
+	if err != nil {
+		return err
+	}
+
+	current, err := store.Get(c.Args[0])
+	if err != nil {
+		return err
+	}
+
+	source := current.Source
+	if len(c.Args) == 2 {
+		source = c.Args[1]
+	}
+
+	// Nothing is written until the archive is known to hold this plugin.
+	pkg, err := openPlugin(c, source)
+	if err != nil {
+		return err
+	}
+
+	if pkg.Name != current.Name {
+		return fmt.Errorf("archive %s contains plugin %q, not %q", source, pkg.Name, current.Name)
+	}
+
+	if pkg.Checksum == current.Checksum {
+		fmt.Fprintf(c.Out, "plugin %s is up to date (%s)\n", pkg.Name, pkg.Version)
+		return nil
+	}
+
+	entry, err := store.InstallPackage(pkg)
+	if err != nil {
+		return err
+	}
+
+	return c.Display(&installedPlugin{entries: []pluginhost.IndexEntry{*entry}})
+}
+
+// openPlugin fetches the plugin archive at source, and verifies it with the
+// checksum given by flag or published alongside it.
+func openPlugin(c *CmdConfig, source string) (*pluginhost.Package, error) {
+	sum, err := c.Doit.GetString(c.NS, doit.ArgPluginSHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	if sum == "" {
+		tmp, err := ioutil.TempFile("", "doctl-plugin-sha256")
+		if err != nil {
+			return nil, err
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+
+		if err := pluginhost.Fetch(source+".sha256", tmp.Name()); err != nil {
+			return nil, fmt.Errorf("unable to retrieve checksum for %s, use --%s to provide it: %v",
+				source, doit.ArgPluginSHA256, err)
+		}
+
+		b, err := ioutil.ReadFile(tmp.Name())
+		if err != nil {
+			return nil, err
+		}
+		sum = string(b)
+	}
+
+	return pluginhost.OpenPackage(source, strings.NewReader(sum))
+}
+
+// RunPluginRemove is a command for removing an installed plugin.
+func RunPluginRemove(c *CmdConfig) error {
+	if len(c.Args) != 1 {
+		return doit.NewMissingArgsErr(c.NS)
+	}
+
+	store, err := pluginStore()
+	if err != nil {
+		return err
+	}
+
+	name := c.Args[0]
+	if err := store.Remove(name); err != nil {
+		return err
+	}
+
+	if _, ok := pluginApprovals()[name]; ok {
+		return setPluginPermissions(plugDesc{Name: name}, nil)
+	}
+
+	return nil
+}
+
+// RunPluginInfo is a command for showing an installed plugin.
+func RunPluginInfo(c *CmdConfig) error {
+	if len(c.Args) != 1 {
+		return doit.NewMissingArgsErr(c.NS)
+	}
+
+	store, err := pluginStore()
+	if err != nil {
+		return err
+	}
+
+	entry, err := store.Get(c.Args[0])
+	if err != nil {
+		return err
+	}
+
+	p := plugDesc{Name: entry.Name, Path: entry.Path}
+	item := &installedPlugin{
+		entries:     []pluginhost.IndexEntry{*entry},
+		permissions: map[string][]string{entry.Name: approvedPermissions(p)},
+	}
+	return c.Display(item)
+}
+
+// addPluginCommands mounts the commands described by each discovered plugin
+// under a command named after the plugin, e.g. "doctl myplugin deploy".
+// Plugins which can't be described, or whose name is taken, are skipped;
+// they can still be run with "doctl plugin run".
+func addPluginCommands(root *Command) {
+	plugs, err := searchPlugins()
+	if err != nil {
+		return
+	}
+
+	for _, p := range plugs {
+		if findSubCommand(root.Command, p.Name) != nil {
+			continue
+		}
+
+		d, err := describePlugin(p)
+		if err != nil || len(d.Commands) == 0 {
+			continue
+		}
+
+		root.AddCommand(pluginCommand(p, d))
+	}
+}
+
+// pluginCommand builds the command heirarchy for plugin p.
+func pluginCommand(p plugDesc, d *pluginhost.Description) *Command {
+	short := d.Short
+	if short == "" {
+		short = fmt.Sprintf("%s plugin commands", p.Name)
+	}
+
+	cmd := &Command{
+		Command: &cobra.Command{
+			Use:   p.Name,
+			Short: short,
+			Long:  d.Long,
+		},
+	}
+
+	for _, cd := range d.Commands {
+		use := cd.Use
+		if use == "" {
+			use = cd.Name
+		}
+
+		sub := CmdBuilder(cmd, pluginRunner(p, cd), use, cd.Short, Writer)
+		if cd.Long != "" {
+			sub.Long = cd.Long
+		}
+		sub.Aliases = cd.Aliases
+
+		for _, f := range cd.Flags {
+			addPluginFlag(sub, f)
+		}
+	}
+
+	return cmd
+}
+
+func addPluginFlag(cmd *Command, f pluginhost.FlagDescription) {
+	var opts []flagOpt
+	if f.Required {
+		opts = append(opts, requiredOpt())
+	}
+
+	switch f.Type {
+	case "bool":
+		def, _ := strconv.ParseBool(f.Default)
+		AddBoolFlag(cmd, f.Name, def, f.Usage, opts...)
+	case "int":
+		def, _ := strconv.Atoi(f.Default)
+		AddIntFlag(cmd, f.Name, def, f.Usage, opts...)
+	case "stringSlice":
+		var def []string
+		if f.Default != "" {
+			def = strings.Split(f.Default, ",")
+		}
+		AddStringSliceFlag(cmd, f.Name, def, f.Usage, opts...)
+	default:
+		AddStringFlagP(cmd, f.Name, f.Shorthand, f.Default, f.Usage, opts...)
+	}
+}
+
+// pluginRunner runs the plugin method behind command cd, passing it the
+// command's arguments and flag values.
+func pluginRunner(p plugDesc, cd pluginhost.CommandDescription) CmdRunner {
+	return func(c *CmdConfig) error {
+		flags := map[string]interface{}{}
+		for _, f := range cd.Flags {
+			var v interface{}
+			var err error
+
+			switch f.Type {
+			case "bool":
+				v, err = c.Doit.GetBool(c.NS, f.Name)
+			case "int":
+				v, err = c.Doit.GetInt(c.NS, f.Name)
+			case "stringSlice":
+				v, err = c.Doit.GetStringSlice(c.NS, f.Name)
+			default:
+				v, err = c.Doit.GetString(c.NS, f.Name)
+			}
+			if err != nil {
+				return err
+			}
+
+			flags[f.Name] = v
+		}
+
+		method := cd.Method
+		if method == "" {
+			name := cd.Name
+			if name == "" {
+				name = strings.Fields(cd.Use)[0]
+			}
+			method = strings.Title(name)
+		}
+
+		host, err := newPluginHost(p.Path, pluginServices(c, p))
+		if err != nil {
+			return err
+		}
+		defer host.Close()
+
+		opts := &pluginhost.CallOptions{Args: c.Args, Flags: flags}
+		result, err := host.Invoke(p.Name+"."+method, opts)
+		if err != nil {
+			return err
+		}
+
+		return displayPluginResult(c, result, cd.Columns)
+	}
+}
+
+// displayPluginResult prints a plugin's result. Strings are printed as is;
+// objects and lists of objects are displayed like any other resource.
+func displayPluginResult(c *CmdConfig, result json.RawMessage, cols []string) error {
+	var s string
+	if err := json.Unmarshal(result, &s); err == nil {
+		fmt.Fprintln(c.Out, s)
+		return nil
+	}
+
+	var items []map[string]interface{}
+	if err := json.Unmarshal(result, &items); err != nil {
+		var item map[string]interface{}
+		if err := json.Unmarshal(result, &item); err != nil {
+			return fmt.Errorf("unable to decode plugin result: %v", err)
+		}
+		items = []map[string]interface{}{item}
+	}
+
+	return c.Display(&pluginResult{raw: result, items: items, cols: cols})
+}
+
+// pluginDescription is a plugin description cached on disk. It is reused
+// while the plugin binary is unchanged.
+type pluginDescription struct {
+	ModTime     time.Time               `json:"mod_time"`
+	Size        int64                   `json:"size"`
+	Description *pluginhost.Description `json:"description"`
+}
+
+// describePlugin returns the commands plugin p provides. Starting every
+// plugin each time doctl runs would be slow, so descriptions are cached.
+func describePlugin(p plugDesc) (*pluginhost.Description, error) {
+	fi, err := os.Stat(p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cachePath string
+	if dir, err := cache.DefaultDir(); err == nil {
+		sum := sha256.Sum256([]byte(p.Path))
+		cachePath = filepath.Join(dir, "plugins", hex.EncodeToString(sum[:8])+".json")
+
+		if b, err := ioutil.ReadFile(cachePath); err == nil {
+			var pd pluginDescription
+			if json.Unmarshal(b, &pd) == nil && pd.ModTime.Equal(fi.ModTime()) && pd.Size == fi.Size() {
+				return pd.Description, nil
+			}
+		}
+	}
+
+	host, err := newPluginHost(p.Path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer host.Close()
+
+	// Plugins which predate Describe, or failed to describe themselves, have
+	// no commands. That is cached too, so they aren't started on every run;
+	// they are described again once the binary changes.
+	d, err := host.Describe(p.Name)
+	if err != nil {
+		d = &pluginhost.Description{}
+	}
+
+	if cachePath != "" {
+		pd := pluginDescription{ModTime: fi.ModTime(), Size: fi.Size(), Description: d}
+		if b, err := json.Marshal(&pd); err == nil {
+			if os.MkdirAll(filepath.Dir(cachePath), 0700) == nil {
+				ioutil.WriteFile(cachePath, b, 0600)
+			}
+		}
+	}
+
+	return d, nil
 }
@@ -0,0 +1,436 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/doctl/pluginhost"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePluginHost struct {
+	description *pluginhost.Description
+	result      string
+	described   int
+	describeErr error
+	method      string
+	opts        *pluginhost.CallOptions
+
+	events       []*pluginhost.HookEvent
+	hookResponse pluginhost.HookResponse
+}
+
+func (h *fakePluginHost) Invoke(method string, opts *pluginhost.CallOptions) (json.RawMessage, error) {
+	h.method = method
+	h.opts = opts
+	return json.RawMessage(h.result), nil
+}
+
+func (h *fakePluginHost) Describe(name string) (*pluginhost.Description, error) {
+	h.described++
+	if h.describeErr != nil {
+		return nil, h.describeErr
+	}
+	return h.description, nil
+}
+
+func (h *fakePluginHost) Hook(name string, event *pluginhost.HookEvent) (*pluginhost.HookResponse, error) {
+	h.events = append(h.events, event)
+	return &h.hookResponse, nil
+}
+
+func (h *fakePluginHost) Close() error {
+	return nil
+}
+
+func withFakePluginHost(h *fakePluginHost, fn func()) {
+	og := newPluginHost
+	defer func() {
+		newPluginHost = og
+	}()
+
+	newPluginHost = func(string, *pluginhost.Services) (pluginHost, error) {
+		return h, nil
+	}
+
+	fn()
+}
+
+var testPluginDescription = &pluginhost.Description{
+	Short: "deploy apps",
+	Commands: []pluginhost.CommandDescription{
+		{
+			Use:   "deploy <app>",
+			Short: "deploy an app",
+			Flags: []pluginhost.FlagDescription{
+				{Name: "env", Usage: "environment", Required: true},
+				{Name: "replicas", Type: "int", Default: "2"},
+				{Name: "wait", Type: "bool"},
+			},
+			Columns: []string{"id", "status"},
+		},
+		{Name: "status", Method: "AppStatus", Aliases: []string{"st"}},
+	},
+}
+
+func TestPluginCommandMounting(t *testing.T) {
+	cmd := pluginCommand(plugDesc{Name: "myplugin"}, testPluginDescription)
+	assert.Equal(t, "myplugin", cmd.Name())
+	assert.Equal(t, "deploy apps", cmd.Short)
+	assertCommandNames(t, cmd, "deploy", "status")
+
+	deploy := findSubCommand(cmd.Command, "deploy")
+	assert.NotNil(t, deploy.Flags().Lookup("env"))
+	assert.Equal(t, "2", deploy.Flags().Lookup("replicas").DefValue)
+	assert.Equal(t, "bool", deploy.Flags().Lookup("wait").Value.Type())
+
+	assert.NotNil(t, findSubCommand(cmd.Command, "st"))
+}
+
+func TestPluginRunner(t *testing.T) {
+	h := &fakePluginHost{result: `[{"id": 1, "status": "running", "extra": true}]`}
+	p := plugDesc{Name: "myplugin", Path: "/bin/doit-provider-myplugin"}
+
+	withFakePluginHost(h, func() {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = []string{"web"}
+			config.Doit.Set(config.NS, "env", "prod")
+			config.Doit.Set(config.NS, "replicas", 3)
+
+			err := pluginRunner(p, testPluginDescription.Commands[0])(config)
+			assert.NoError(t, err)
+
+			assert.Equal(t, "myplugin.Deploy", h.method)
+			assert.Equal(t, []string{"web"}, h.opts.Args)
+			assert.Equal(t, map[string]interface{}{"env": "prod", "replicas": 3, "wait": false}, h.opts.Flags)
+
+			assert.Contains(t, buf.String(), "Status")
+			assert.Contains(t, buf.String(), "running")
+			assert.NotContains(t, buf.String(), "Extra")
+		})
+	})
+}
+
+func TestPluginRunnerStringResult(t *testing.T) {
+	h := &fakePluginHost{result: `"all good"`}
+	p := plugDesc{Name: "myplugin", Path: "/bin/doit-provider-myplugin"}
+
+	withFakePluginHost(h, func() {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			var buf bytes.Buffer
+			config.Out = &buf
+
+			err := pluginRunner(p, testPluginDescription.Commands[1])(config)
+			assert.NoError(t, err)
+
+			assert.Equal(t, "myplugin.AppStatus", h.method)
+			assert.Equal(t, "all good\n", buf.String())
+		})
+	})
+}
+
+func TestPluginResultDisplayable(t *testing.T) {
+	raw := json.RawMessage(`{"name": "web", "public_ip": "127.0.0.1"}`)
+	pr := &pluginResult{raw: raw, items: []map[string]interface{}{{"name": "web", "public_ip": "127.0.0.1"}}}
+
+	assert.Equal(t, []string{"name", "public_ip"}, pr.Cols())
+	assert.Equal(t, "Public Ip", pr.ColMap()["public_ip"])
+
+	var buf bytes.Buffer
+	assert.NoError(t, pr.JSON(&buf))
+	assert.Contains(t, buf.String(), `"public_ip": "127.0.0.1"`)
+}
+
+func TestDescribePluginCached(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doctl-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer os.Setenv("XDG_CACHE_HOME", os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CACHE_HOME", dir)
+
+	path := filepath.Join(dir, "doit-provider-myplugin")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\n"), 0755))
+
+	h := &fakePluginHost{description: testPluginDescription}
+	p := plugDesc{Name: "myplugin", Path: path}
+
+	withFakePluginHost(h, func() {
+		d, err := describePlugin(p)
+		assert.NoError(t, err)
+		assert.Len(t, d.Commands, 2)
+
+		d, err = describePlugin(p)
+		assert.NoError(t, err)
+		assert.Len(t, d.Commands, 2)
+		assert.Equal(t, 1, h.described)
+
+		assert.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\nexit 0\n"), 0755))
+		_, err = describePlugin(p)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, h.described)
+	})
+}
+
+func TestDescribePluginErrorCached(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doctl-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer os.Setenv("XDG_CACHE_HOME", os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CACHE_HOME", dir)
+
+	path := filepath.Join(dir, "doit-provider-myplugin")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\n"), 0755))
+
+	h := &fakePluginHost{description: testPluginDescription, describeErr: errors.New("plugin crashed")}
+	p := plugDesc{Name: "myplugin", Path: path}
+
+	withFakePluginHost(h, func() {
+		d, err := describePlugin(p)
+		assert.NoError(t, err)
+		assert.Empty(t, d.Commands)
+
+		h.describeErr = nil
+		d, err = describePlugin(p)
+		assert.NoError(t, err)
+		assert.Empty(t, d.Commands)
+		assert.Equal(t, 1, h.described)
+
+		assert.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\nexit 0\n"), 0755))
+		d, err = describePlugin(p)
+		assert.NoError(t, err)
+		assert.Len(t, d.Commands, 2)
+		assert.Equal(t, 2, h.described)
+	})
+}
+
+func TestRunPluginApprove(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doctl-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer os.Setenv("XDG_CACHE_HOME", os.Getenv("XDG_CACHE_HOME"))
+	os.Setenv("XDG_CACHE_HOME", dir)
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir)
+	defer os.Setenv("XDG_DATA_HOME", os.Getenv("XDG_DATA_HOME"))
+	os.Setenv("XDG_DATA_HOME", dir)
+
+	path := filepath.Join(dir, "doit-provider-myplugin")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\n"), 0755))
+
+	written := map[string]interface{}{}
+	ogWriteConfig := writeConfig
+	defer func() {
+		writeConfig = ogWriteConfig
+		viper.Set(pluginPermissionsKey, nil)
+	}()
+	writeConfig = func(key string, val interface{}) error {
+		written[key] = val
+		return nil
+	}
+
+	h := &fakePluginHost{description: &pluginhost.Description{
+		Commands:    testPluginDescription.Commands,
+		Permissions: []string{"droplets.read"},
+	}}
+
+	defer func(fn func(string) (string, error)) { retrieveUserInput = fn }(retrieveUserInput)
+	answer := "n\n"
+	retrieveUserInput = func(string) (string, error) {
+		return answer, nil
+	}
+
+	withFakePluginHost(h, func() {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			var out bytes.Buffer
+			config.Out = &out
+			config.Args = []string{"myplugin"}
+
+			err := RunPluginApprove(config)
+			assert.Equal(t, errOperationAborted, err)
+			assert.Contains(t, out.String(), "  droplets.read\n")
+			assert.Empty(t, written)
+
+			answer = "y\n"
+			err = RunPluginApprove(config)
+			assert.NoError(t, err)
+
+			sum, err := pluginSum(path)
+			assert.NoError(t, err)
+			approval := pluginApproval{Path: path, SHA256: sum, Permissions: []string{"droplets.read"}}
+			assert.Equal(t, map[string]pluginApproval{"myplugin": approval}, written[pluginPermissionsKey])
+
+			p := plugDesc{Name: "myplugin", Path: path}
+			assert.NotNil(t, pluginServices(config, p))
+			assert.True(t, pluginhost.Allowed(approvedPermissions(p), "droplets", "List"))
+
+			// Approvals don't carry over to another binary of the same name.
+			other := filepath.Join(dir, "bin", "doit-provider-myplugin")
+			assert.NoError(t, os.MkdirAll(filepath.Dir(other), 0755))
+			assert.NoError(t, ioutil.WriteFile(other, []byte("#!/bin/sh\n"), 0755))
+			assert.Empty(t, approvedPermissions(plugDesc{Name: "myplugin", Path: other}))
+
+			// Or to the binary once it changes.
+			assert.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\nexit 0\n"), 0755))
+			assert.Empty(t, approvedPermissions(p))
+
+			err = RunPluginRevoke(config)
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]pluginApproval{}, written[pluginPermissionsKey])
+
+			config.Args = []string{"missing"}
+			err = RunPluginApprove(config)
+			assert.EqualError(t, err, `unknown plugin "missing"`)
+		})
+	})
+}
+
+func writePluginArchive(t *testing.T, path, name, body string) {
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(body))}))
+	_, err = tw.Write([]byte(body))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+
+	b, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	sum := sha256.Sum256(b)
+	assert.NoError(t, ioutil.WriteFile(path+".sha256", []byte(hex.EncodeToString(sum[:])+"  "+filepath.Base(path)), 0644))
+}
+
+func TestRunPluginInstallLifecycle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doctl-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer os.Setenv("XDG_DATA_HOME", os.Getenv("XDG_DATA_HOME"))
+	os.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", filepath.Join(dir, "empty"))
+
+	writePluginArchive(t, filepath.Join(dir, "doit-provider-foo-1.0.0.tar.gz"), "doit-provider-foo", "v1")
+	writePluginArchive(t, filepath.Join(dir, "doit-provider-foo-1.1.0.tar.gz"), "doit-provider-foo", "v2")
+	writePluginArchive(t, filepath.Join(dir, "doit-provider-bar-2.0.0.tar.gz"), "doit-provider-bar", "bar")
+
+	ts := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer ts.Close()
+
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		var buf bytes.Buffer
+		config.Out = &buf
+
+		config.Args = []string{filepath.Join(dir, "doit-provider-foo-1.0.0.tar.gz")}
+		err := RunPluginInstall(config)
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), "1.0.0")
+
+		plugs, err := searchPlugins()
+		assert.NoError(t, err)
+		assert.Equal(t, []plugDesc{{Name: "foo", Path: filepath.Join(dir, "data", "doctl", "plugins", "bin", "doit-provider-foo")}}, plugs)
+
+		buf.Reset()
+		config.Args = []string{"foo"}
+		err = RunPluginUpgrade(config)
+		assert.NoError(t, err)
+		assert.Equal(t, "plugin foo is up to date (1.0.0)\n", buf.String())
+
+		// An archive of another plugin is rejected before anything is written.
+		config.Args = []string{"foo", filepath.Join(dir, "doit-provider-bar-2.0.0.tar.gz")}
+		err = RunPluginUpgrade(config)
+		assert.EqualError(t, err, fmt.Sprintf("archive %s contains plugin %q, not %q", config.Args[1], "bar", "foo"))
+
+		plugs, err = searchPlugins()
+		assert.NoError(t, err)
+		assert.Len(t, plugs, 1)
+
+		buf.Reset()
+		config.Args = []string{"foo", ts.URL + "/doit-provider-foo-1.1.0.tar.gz"}
+		err = RunPluginUpgrade(config)
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), "1.1.0")
+
+		b, err := ioutil.ReadFile(plugs[0].Path)
+		assert.NoError(t, err)
+		assert.Equal(t, "v2", string(b))
+
+		buf.Reset()
+		config.Args = []string{"foo"}
+		err = RunPluginInfo(config)
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), ts.URL+"/doit-provider-foo-1.1.0.tar.gz")
+
+		err = RunPluginRemove(config)
+		assert.NoError(t, err)
+
+		plugs, err = searchPlugins()
+		assert.NoError(t, err)
+		assert.Empty(t, plugs)
+
+		err = RunPluginInfo(config)
+		assert.EqualError(t, err, `plugin "foo" is not installed`)
+	})
+}
+
+func TestRunPluginInstallBadChecksum(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doctl-plugin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer os.Setenv("XDG_DATA_HOME", os.Getenv("XDG_DATA_HOME"))
+	os.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+
+	archive := filepath.Join(dir, "foo.tar.gz")
+	writePluginArchive(t, archive, "doit-provider-foo", "foo")
+
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		config.Args = []string{archive}
+		config.Doit.Set(config.NS, "sha256", strings.Repeat("0", 64))
+
+		err := RunPluginInstall(config)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid checksum")
+
+		config.Doit.Set(config.NS, "sha256", "")
+		config.Args = []string{filepath.Join(dir, "missing.tar.gz")}
+		err = RunPluginInstall(config)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "unable to retrieve checksum")
+	})
+}
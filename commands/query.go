@@ -0,0 +1,72 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+// applyQuery evaluates a JMESPath expression against the JSON form of item
+// and writes the result to out. Scalars are written as plain text and
+// everything else as JSON. If unwrap is set and item holds a single
+// resource, the expression is evaluated against that resource rather than a
+// list of one.
+func applyQuery(item Displayable, query string, unwrap bool, out io.Writer) error {
+	expr, err := jmespath.Compile(query)
+	if err != nil {
+		return fmt.Errorf("invalid query %q: %v", query, err)
+	}
+
+	var buf bytes.Buffer
+	if err := item.JSON(&buf); err != nil {
+		return err
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		return err
+	}
+
+	if l, ok := data.([]interface{}); ok && unwrap && len(l) == 1 {
+		data = l[0]
+	}
+
+	result, err := expr.Search(data)
+	if err != nil {
+		return fmt.Errorf("unable to evaluate query %q: %v", query, err)
+	}
+
+	switch v := result.(type) {
+	case nil:
+		return nil
+	case string:
+		_, err = fmt.Fprintln(out, v)
+	case float64:
+		_, err = fmt.Fprintln(out, strconv.FormatFloat(v, 'f', -1, 64))
+	case bool:
+		_, err = fmt.Fprintln(out, v)
+	default:
+		if err = writeJSON(v, out); err == nil {
+			_, err = fmt.Fprintln(out)
+		}
+	}
+
+	return err
+}
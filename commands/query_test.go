@@ -0,0 +1,88 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/doctl/do"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyQuery(t *testing.T) {
+	cases := []struct {
+		query    string
+		unwrap   bool
+		expected string
+	}{
+		{query: "networks.v4[0].ip_address", unwrap: true, expected: "8.8.8.8\n"},
+		{query: "[0].networks.v4[0].ip_address", expected: "8.8.8.8\n"},
+		{query: "id", unwrap: true, expected: "1\n"},
+		{query: "contains(name, 'droplet')", unwrap: true, expected: "true\n"},
+		{query: "[].name", expected: "[\n  \"a-droplet\"\n]\n"},
+		{query: "missing", unwrap: true, expected: ""},
+	}
+
+	for _, c := range cases {
+		var buf bytes.Buffer
+		item := &droplet{droplets: do.Droplets{testDroplet}}
+
+		err := applyQuery(item, c.query, c.unwrap, &buf)
+		assert.NoError(t, err, c.query)
+		assert.Equal(t, c.expected, buf.String(), c.query)
+	}
+}
+
+func TestApplyQuery_Invalid(t *testing.T) {
+	var buf bytes.Buffer
+	item := &droplet{droplets: do.Droplets{testDroplet}}
+
+	err := applyQuery(item, "networks.[", true, &buf)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid query "networks.["`)
+}
+
+func TestDisplayQuerySingle(t *testing.T) {
+	cases := []struct {
+		single   bool
+		query    string
+		expected string
+	}{
+		{single: true, query: "id", expected: "1\n"},
+		{single: false, query: "[0].id", expected: "1\n"},
+		{single: false, query: "id", expected: ""},
+	}
+
+	for _, c := range cases {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.single = c.single
+			config.Doit.Set(doit.NSRoot, doit.ArgQuery, c.query)
+
+			err := config.Display(&droplet{droplets: do.Droplets{testDroplet}})
+			assert.NoError(t, err, c.query)
+			assert.Equal(t, c.expected, buf.String(), c.query)
+		})
+	}
+}
+
+func TestSingleOpt(t *testing.T) {
+	cmd := Droplet()
+
+	assert.True(t, subCommand(t, cmd, "get").single)
+	assert.False(t, subCommand(t, cmd, "list").single)
+}
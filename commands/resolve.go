@@ -0,0 +1,368 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/doctl/do"
+)
+
+// fingerprintRe matches the MD5 fingerprint of an SSH key.
+var fingerprintRe = regexp.MustCompile(`^([0-9a-fA-F]{2}:){15}[0-9a-fA-F]{2}$`)
+
+// AmbiguousMatchErr is returned when a reference matches more than one
+// resource.
+type AmbiguousMatchErr struct {
+	Kind    string
+	Ref     string
+	Matches []string
+}
+
+var _ doit.ExitCoder = &AmbiguousMatchErr{}
+
+func (e *AmbiguousMatchErr) Error() string {
+	return fmt.Sprintf("%s %q is ambiguous, it matches: %s",
+		e.Kind, e.Ref, strings.Join(e.Matches, ", "))
+}
+
+// ExitCode is the exit code for an ambiguous reference.
+func (e *AmbiguousMatchErr) ExitCode() int {
+	return doit.ExitValidation
+}
+
+// NoMatchErr is returned when a reference doesn't match any resource.
+type NoMatchErr struct {
+	Kind string
+	Ref  string
+}
+
+var _ doit.ExitCoder = &NoMatchErr{}
+
+func (e *NoMatchErr) Error() string {
+	return fmt.Sprintf("unable to find %s %q", e.Kind, e.Ref)
+}
+
+// ExitCode is the exit code for a reference which matches nothing.
+func (e *NoMatchErr) ExitCode() int {
+	return doit.ExitNotFound
+}
+
+// resolver turns the names, globs, slugs, fingerprints and addresses given on
+// the command line into the ids the API expects. Resources are listed at most
+// once per resolver, and only when a reference isn't already an id.
+type resolver struct {
+	c *CmdConfig
+
+	droplets    do.Droplets
+	images      do.Images
+	imageRefs   map[string]*do.Image
+	keys        do.SSHKeys
+	floatingIPs do.FloatingIPs
+}
+
+func newResolver(c *CmdConfig) *resolver {
+	return &resolver{c: c}
+}
+
+// matchRef returns the indexes of the candidates matching ref. A candidate
+// matches if any of its names equals ref or, if ref is a glob, if any of its
+// names matches the pattern.
+func matchRef(ref string, candidates [][]string) []int {
+	glob := isGlob(ref)
+
+	matches := []int{}
+	for i, names := range candidates {
+		for _, n := range names {
+			if n == "" {
+				continue
+			}
+
+			ok := n == ref
+			if !ok && glob {
+				ok, _ = path.Match(ref, n)
+			}
+
+			if ok {
+				matches = append(matches, i)
+				break
+			}
+		}
+	}
+
+	return matches
+}
+
+// Droplet resolves a droplet by id, name or name glob.
+func (r *resolver) Droplet(ref string) (*do.Droplet, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return r.c.Droplets().Get(id)
+	}
+
+	if r.droplets == nil {
+		list, err := r.c.Droplets().List()
+		if err != nil {
+			return nil, err
+		}
+		r.droplets = list
+	}
+
+	candidates := make([][]string, len(r.droplets))
+	for i, d := range r.droplets {
+		candidates[i] = []string{d.Name}
+	}
+
+	matches := matchRef(ref, candidates)
+	switch len(matches) {
+	case 0:
+		return nil, &NoMatchErr{Kind: "droplet", Ref: ref}
+	case 1:
+		d := r.droplets[matches[0]]
+		return &d, nil
+	}
+
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		d := r.droplets[m]
+		names[i] = fmt.Sprintf("%s (%d)", d.Name, d.ID)
+	}
+
+	return nil, &AmbiguousMatchErr{Kind: "droplet", Ref: ref, Matches: names}
+}
+
+// DropletID resolves the id of a droplet by id, name or name glob.
+func (r *resolver) DropletID(ref string) (int, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return id, nil
+	}
+
+	d, err := r.Droplet(ref)
+	if err != nil {
+		return 0, err
+	}
+
+	return d.ID, nil
+}
+
+// DropletIDs resolves the ids of the droplets a reference refers to. A glob
+// resolves to every droplet it matches, other references to a single droplet.
+func (r *resolver) DropletIDs(ref string) ([]int, error) {
+	if !isGlob(ref) {
+		id, err := r.DropletID(ref)
+		if err != nil {
+			return nil, err
+		}
+		return []int{id}, nil
+	}
+
+	if r.droplets == nil {
+		list, err := r.c.Droplets().List()
+		if err != nil {
+			return nil, err
+		}
+		r.droplets = list
+	}
+
+	candidates := make([][]string, len(r.droplets))
+	for i, d := range r.droplets {
+		candidates[i] = []string{d.Name}
+	}
+
+	matches := matchRef(ref, candidates)
+	if len(matches) == 0 {
+		return nil, &NoMatchErr{Kind: "droplet", Ref: ref}
+	}
+
+	ids := make([]int, len(matches))
+	for i, m := range matches {
+		ids[i] = r.droplets[m].ID
+	}
+
+	return ids, nil
+}
+
+// isGlob reports whether ref is a name glob.
+func isGlob(ref string) bool {
+	return strings.ContainsAny(ref, "*?[")
+}
+
+// isNotFound reports whether err is an API not found error.
+func isNotFound(err error) bool {
+	_, ok := doit.NewAPIError(err).(*doit.NotFoundErr)
+	return ok
+}
+
+// Image resolves an image by id, slug, name or name glob. Slugs are looked up
+// directly, images are only listed to match a name.
+func (r *resolver) Image(ref string) (*do.Image, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return r.c.Images().GetByID(id)
+	}
+
+	if img, ok := r.imageRefs[ref]; ok {
+		return img, nil
+	}
+
+	img, err := r.image(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.imageRefs == nil {
+		r.imageRefs = map[string]*do.Image{}
+	}
+	r.imageRefs[ref] = img
+
+	return img, nil
+}
+
+func (r *resolver) image(ref string) (*do.Image, error) {
+	if !isGlob(ref) {
+		img, err := r.c.Images().GetBySlug(ref)
+		if err == nil {
+			return img, nil
+		}
+		if !isNotFound(err) {
+			return nil, err
+		}
+	}
+
+	if r.images == nil {
+		list, err := r.c.Images().List(false)
+		if err != nil {
+			return nil, err
+		}
+		r.images = list
+	}
+
+	candidates := make([][]string, len(r.images))
+	for i, img := range r.images {
+		candidates[i] = []string{img.Slug, img.Name}
+	}
+
+	matches := matchRef(ref, candidates)
+	switch len(matches) {
+	case 0:
+		return nil, &NoMatchErr{Kind: "image", Ref: ref}
+	case 1:
+		img := r.images[matches[0]]
+		return &img, nil
+	}
+
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		img := r.images[m]
+		names[i] = fmt.Sprintf("%s (%d)", img.Name, img.ID)
+	}
+
+	return nil, &AmbiguousMatchErr{Kind: "image", Ref: ref, Matches: names}
+}
+
+// ImageID resolves the id of an image by id, slug, name or name glob.
+func (r *resolver) ImageID(ref string) (int, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return id, nil
+	}
+
+	img, err := r.Image(ref)
+	if err != nil {
+		return 0, err
+	}
+
+	return img.ID, nil
+}
+
+// KeyID resolves an SSH key by id, fingerprint, name or name glob. It returns
+// the id or fingerprint, which the API accepts interchangeably.
+func (r *resolver) KeyID(ref string) (string, error) {
+	if _, err := strconv.Atoi(ref); err == nil {
+		return ref, nil
+	}
+
+	if fingerprintRe.MatchString(ref) {
+		return ref, nil
+	}
+
+	if r.keys == nil {
+		list, err := r.c.Keys().List()
+		if err != nil {
+			return "", err
+		}
+		r.keys = list
+	}
+
+	candidates := make([][]string, len(r.keys))
+	for i, k := range r.keys {
+		candidates[i] = []string{k.Name, k.Fingerprint}
+	}
+
+	matches := matchRef(ref, candidates)
+	switch len(matches) {
+	case 0:
+		return "", &NoMatchErr{Kind: "ssh key", Ref: ref}
+	case 1:
+		return strconv.Itoa(r.keys[matches[0]].ID), nil
+	}
+
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		k := r.keys[m]
+		names[i] = fmt.Sprintf("%s (%s)", k.Name, k.Fingerprint)
+	}
+
+	return "", &AmbiguousMatchErr{Kind: "ssh key", Ref: ref, Matches: names}
+}
+
+// FloatingIP resolves a floating IP by address or by the id, name or name
+// glob of the droplet it is assigned to.
+func (r *resolver) FloatingIP(ref string) (string, error) {
+	if net.ParseIP(ref) != nil {
+		return ref, nil
+	}
+
+	d, err := r.Droplet(ref)
+	if err != nil {
+		return "", err
+	}
+
+	if r.floatingIPs == nil {
+		list, err := r.c.FloatingIPs().List()
+		if err != nil {
+			return "", err
+		}
+		r.floatingIPs = list
+	}
+
+	ips := []string{}
+	for _, fip := range r.floatingIPs {
+		if fip.Droplet != nil && fip.Droplet.ID == d.ID {
+			ips = append(ips, fip.IP)
+		}
+	}
+
+	switch len(ips) {
+	case 0:
+		return "", &NoMatchErr{Kind: "floating IP assigned to", Ref: ref}
+	case 1:
+		return ips[0], nil
+	}
+
+	return "", &AmbiguousMatchErr{Kind: "floating IP assigned to", Ref: ref, Matches: ips}
+}
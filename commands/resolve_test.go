@@ -0,0 +1,173 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/doctl/do"
+	"github.com/digitalocean/godo"
+	"github.com/stretchr/testify/assert"
+)
+
+var resolveDropletList = do.Droplets{
+	{Droplet: &godo.Droplet{ID: 1, Name: "web-1"}},
+	{Droplet: &godo.Droplet{ID: 2, Name: "web-2"}},
+	{Droplet: &godo.Droplet{ID: 3, Name: "db-1"}},
+	{Droplet: &godo.Droplet{ID: 4, Name: "db-1"}},
+}
+
+func TestResolverDropletID(t *testing.T) {
+	cases := []struct {
+		ref  string
+		id   int
+		err  string
+		code int
+	}{
+		{ref: "web-1", id: 1},
+		{ref: "web-[2]", id: 2},
+		{ref: "web-*", err: `droplet "web-*" is ambiguous, it matches: web-1 (1), web-2 (2)`, code: doit.ExitValidation},
+		{ref: "db-1", err: `droplet "db-1" is ambiguous, it matches: db-1 (3), db-1 (4)`, code: doit.ExitValidation},
+		{ref: "missing", err: `unable to find droplet "missing"`, code: doit.ExitNotFound},
+	}
+
+	for _, c := range cases {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.droplets.On("List").Return(resolveDropletList, nil)
+
+			id, err := newResolver(config).DropletID(c.ref)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				assert.Equal(t, c.code, doit.ExitCode(err))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.id, id)
+		})
+	}
+}
+
+func TestResolverDropletID_Numeric(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		id, err := newResolver(config).DropletID("42")
+		assert.NoError(t, err)
+		assert.Equal(t, 42, id)
+	})
+}
+
+func TestResolverListsOnce(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.droplets.On("List").Return(resolveDropletList, nil).Once()
+
+		r := newResolver(config)
+		for _, ref := range []string{"web-1", "web-2"} {
+			_, err := r.DropletID(ref)
+			assert.NoError(t, err)
+		}
+	})
+}
+
+func TestResolverImageID(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		images := do.Images{
+			{Image: &godo.Image{ID: 10, Slug: "ubuntu-14-04-x64", Name: "14.04 x64"}},
+			{Image: &godo.Image{ID: 11, Name: "web-snapshot"}},
+		}
+		tm.images.On("GetBySlug", "ubuntu-14-04-x64").Return(&images[0], nil)
+		tm.images.On("GetBySlug", "web-snapshot").Return(nil, testAPIError(404))
+		tm.images.On("List", false).Return(images, nil).Once()
+
+		r := newResolver(config)
+
+		id, err := r.ImageID("ubuntu-14-04-x64")
+		assert.NoError(t, err)
+		assert.Equal(t, 10, id)
+
+		id, err = r.ImageID("web-snapshot")
+		assert.NoError(t, err)
+		assert.Equal(t, 11, id)
+
+		id, err = r.ImageID("web-*")
+		assert.NoError(t, err)
+		assert.Equal(t, 11, id)
+	})
+}
+
+func TestResolverImageAPIError(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		apiErr := testAPIError(500)
+		tm.images.On("GetBySlug", "ubuntu").Return(nil, apiErr)
+
+		_, err := newResolver(config).Image("ubuntu")
+		assert.Equal(t, apiErr, err)
+	})
+}
+
+// testAPIError returns an API error response with status.
+func testAPIError(status int) error {
+	return &godo.ErrorResponse{
+		Response: &http.Response{StatusCode: status, Header: http.Header{}},
+		Message:  http.StatusText(status),
+	}
+}
+
+func TestResolverKeyID(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		keys := do.SSHKeys{
+			{Key: &godo.Key{ID: 5, Name: "laptop", Fingerprint: "SHA256:abc"}},
+		}
+		tm.keys.On("List").Return(keys, nil)
+
+		r := newResolver(config)
+
+		id, err := r.KeyID(testKey.Fingerprint)
+		assert.NoError(t, err)
+		assert.Equal(t, testKey.Fingerprint, id)
+
+		id, err = r.KeyID("laptop")
+		assert.NoError(t, err)
+		assert.Equal(t, "5", id)
+
+		id, err = r.KeyID("SHA256:abc")
+		assert.NoError(t, err)
+		assert.Equal(t, "5", id)
+	})
+}
+
+func TestResolverFloatingIP(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		fips := do.FloatingIPs{
+			{FloatingIP: &godo.FloatingIP{IP: "45.55.96.47"}},
+			{FloatingIP: &godo.FloatingIP{IP: "45.55.96.48", Droplet: &godo.Droplet{ID: 2}}},
+		}
+		tm.droplets.On("List").Return(resolveDropletList, nil)
+		tm.floatingIPs.On("List").Return(fips, nil)
+
+		r := newResolver(config)
+
+		ip, err := r.FloatingIP("45.55.96.47")
+		assert.NoError(t, err)
+		assert.Equal(t, "45.55.96.47", ip)
+
+		ip, err = r.FloatingIP("web-2")
+		assert.NoError(t, err)
+		assert.Equal(t, "45.55.96.48", ip)
+
+		_, err = r.FloatingIP("web-1")
+		assert.EqualError(t, err, `unable to find floating IP assigned to "web-1"`)
+	})
+}
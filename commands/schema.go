@@ -0,0 +1,94 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// CommandSchema is a machine-readable description of a command and its
+// subcommands, for generating tools and editor support.
+type CommandSchema struct {
+	Name          string           `json:"name"`
+	Path          string           `json:"path"`
+	Use           string           `json:"use"`
+	Short         string           `json:"short,omitempty"`
+	Long          string           `json:"long,omitempty"`
+	Aliases       []string         `json:"aliases,omitempty"`
+	DocCategories []string         `json:"doc_categories,omitempty"`
+	Columns       []string         `json:"columns,omitempty"`
+	Flags         []FlagSchema     `json:"flags,omitempty"`
+	Commands      []*CommandSchema `json:"commands,omitempty"`
+}
+
+// FlagSchema describes a flag. Persistent flags also apply to all of a
+// command's subcommands.
+type FlagSchema struct {
+	Name       string `json:"name"`
+	Shorthand  string `json:"shorthand,omitempty"`
+	Type       string `json:"type"`
+	Default    string `json:"default,omitempty"`
+	Usage      string `json:"usage"`
+	Required   bool   `json:"required,omitempty"`
+	Persistent bool   `json:"persistent,omitempty"`
+}
+
+// Schema describes cmd and its available subcommands.
+func Schema(cmd *Command) *CommandSchema {
+	s := &CommandSchema{
+		Name:          cmd.Name(),
+		Path:          cmd.CommandPath(),
+		Use:           cmd.Use,
+		Short:         cmd.Short,
+		Long:          cmd.Long,
+		Aliases:       cmd.Aliases,
+		DocCategories: cmd.DocCategories,
+		Columns:       cmd.Columns(),
+	}
+	if s.Long == s.Short {
+		s.Long = ""
+	}
+
+	persistent := cmd.PersistentFlags()
+	cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		if f.Hidden {
+			return
+		}
+
+		_, required := f.Annotations[cobra.BashCompOneRequiredFlag]
+		required = required || viper.GetBool(requiredKey(flagName(cmd, f.Name)))
+		s.Flags = append(s.Flags, FlagSchema{
+			Name:       f.Name,
+			Shorthand:  f.Shorthand,
+			Type:       f.Value.Type(),
+			Default:    f.DefValue,
+			Usage:      strings.TrimSuffix(f.Usage, " "+requiredColor("(required)")),
+			Required:   required,
+			Persistent: persistent.Lookup(f.Name) != nil,
+		})
+	})
+
+	for _, c := range cmd.ChildCommands() {
+		if !c.IsAvailableCommand() || c.IsHelpCommand() {
+			continue
+		}
+		s.Commands = append(s.Commands, Schema(c))
+	}
+
+	return s
+}
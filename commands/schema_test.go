@@ -0,0 +1,64 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/digitalocean/doctl"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchema(t *testing.T) {
+	root := &Command{Command: &cobra.Command{Use: "doctl", Short: "doctl"}}
+	root.PersistentFlags().String("access-token", "", "token")
+
+	parent := &Command{Command: &cobra.Command{Use: "domain", Short: "domain commands"}, DocCategories: []string{"domain"}}
+	root.AddCommand(parent)
+
+	cmd := CmdBuilder(parent, RunDomainCreate, "create <domain>", "create domain", Writer,
+		aliasOpt("c"), displayerType(&domain{}), docCategories("domain"))
+	AddStringFlag(cmd, doit.ArgIPAddress, "", "IP address", requiredOpt())
+
+	CmdBuilder(parent, RunDomainList, "hidden", "hidden", Writer, hiddenCmd())
+
+	s := Schema(root)
+	assert.Equal(t, "doctl", s.Path)
+	if assert.Len(t, s.Flags, 1) {
+		assert.Equal(t, FlagSchema{Name: "access-token", Type: "string", Usage: "token", Persistent: true}, s.Flags[0])
+	}
+
+	if !assert.Len(t, s.Commands, 1) || !assert.Len(t, s.Commands[0].Commands, 1) {
+		return
+	}
+
+	create := s.Commands[0].Commands[0]
+	assert.Equal(t, "doctl domain create", create.Path)
+	assert.Equal(t, "create <domain>", create.Use)
+	assert.Equal(t, []string{"c"}, create.Aliases)
+	assert.Equal(t, []string{"domain"}, create.DocCategories)
+	assert.Equal(t, []string{"Domain", "TTL"}, create.Columns)
+
+	var ip *FlagSchema
+	for i := range create.Flags {
+		if create.Flags[i].Name == doit.ArgIPAddress {
+			ip = &create.Flags[i]
+		}
+	}
+	if assert.NotNil(t, ip) {
+		assert.True(t, ip.Required)
+		assert.Equal(t, "IP address", ip.Usage)
+	}
+}
@@ -0,0 +1,414 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/digitalocean/doctl/pkg/lineedit"
+	"github.com/digitalocean/doctl/pkg/term"
+	"github.com/digitalocean/doctl/pluginhost"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// shellHistorySize is how many lines of shell history are kept.
+const shellHistorySize = 1000
+
+// Shell creates the shell command.
+func Shell() *Command {
+	cmd := CmdBuilder(nil, RunShell, "shell", "run doctl commands interactively", Writer)
+	cmd.Long = `shell runs doctl commands interactively, reusing one configuration and API client
+
+Commands are entered without the doctl prefix, e.g. "compute droplet list". Tab completes commands, flags and
+resource names. The shell also understands:
+
+  set <flag> <value>   use a flag's value for following commands which accept it, e.g. "set region nyc3"
+  unset <flag>         stop using a flag's value
+  set                  list the values set
+  history              list previous commands
+  exit                 leave the shell`
+
+	return cmd
+}
+
+// RunShell runs the interactive shell.
+func RunShell(c *CmdConfig) error {
+	s := newShell(DoitCmd.Command, c)
+
+	if a, err := c.Account().Get(); err == nil {
+		s.account = a.Email
+	}
+
+	path, err := shellHistoryPath()
+	if err == nil {
+		s.editor.History = readHistory(path)
+	}
+
+	// Errors from commands are reported, but don't end the shell.
+	defer func(a func(int)) { errAction = a }(errAction)
+	errAction = func(int) {}
+
+	fd, isTerminal := term.GetFdInfo(os.Stdin)
+	var lines *bufio.Scanner
+	if !isTerminal {
+		lines = bufio.NewScanner(os.Stdin)
+	}
+
+	for {
+		var line string
+		if isTerminal {
+			state, err := term.MakeRaw(fd)
+			if err != nil {
+				return err
+			}
+			line, err = s.editor.ReadLine(s.prompt())
+			term.RestoreTerminal(fd, state)
+
+			if err == lineedit.ErrInterrupted {
+				continue
+			}
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		} else {
+			if !lines.Scan() {
+				return lines.Err()
+			}
+			line = lines.Text()
+		}
+
+		if strings.TrimSpace(line) != "" {
+			s.editor.AddHistory(line)
+			if path != "" {
+				appendHistory(path, line)
+			}
+		}
+
+		if s.execute(line) {
+			return nil
+		}
+	}
+}
+
+// shell runs commands from a command tree, with session defaults for flags.
+type shell struct {
+	root     *cobra.Command
+	config   *CmdConfig
+	out      io.Writer
+	editor   *lineedit.Editor
+	account  string
+	defaults map[string]string
+}
+
+func newShell(root *cobra.Command, c *CmdConfig) *shell {
+	s := &shell{
+		root:     root,
+		config:   c,
+		out:      c.Out,
+		editor:   lineedit.New(os.Stdin, c.Out),
+		defaults: map[string]string{},
+	}
+	s.editor.Complete = s.complete
+
+	return s
+}
+
+// prompt shows the account and any session defaults.
+func (s *shell) prompt() string {
+	var context []string
+	if s.account != "" {
+		context = append(context, s.account)
+	}
+	for _, name := range s.defaultNames() {
+		context = append(context, name+"="+s.defaults[name])
+	}
+
+	if len(context) == 0 {
+		return "doctl> "
+	}
+
+	return fmt.Sprintf("doctl (%s)> ", strings.Join(context, " "))
+}
+
+func (s *shell) defaultNames() []string {
+	var names []string
+	for name := range s.defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// execute runs a line, returning true if the shell should exit.
+func (s *shell) execute(line string) bool {
+	words, err := splitWords(line)
+	if err != nil {
+		fmt.Fprintf(s.out, "%s: %v\n", colorErr, err)
+		return false
+	}
+
+	if len(words) > 0 && words[0] == s.root.Name() {
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return false
+	}
+
+	switch words[0] {
+	case "exit", "quit":
+		return true
+	case "history":
+		for i, h := range s.editor.History {
+			fmt.Fprintf(s.out, "%5d  %s\n", i+1, h)
+		}
+	case "set":
+		s.set(words[1:])
+	case "unset":
+		for _, name := range words[1:] {
+			delete(s.defaults, strings.TrimLeft(name, "-"))
+		}
+	case "shell":
+		fmt.Fprintf(s.out, "%s: already in a shell\n", colorErr)
+	default:
+		s.run(words)
+	}
+
+	return false
+}
+
+func (s *shell) set(args []string) {
+	switch len(args) {
+	case 0:
+		for _, name := range s.defaultNames() {
+			fmt.Fprintf(s.out, "%s=%s\n", name, s.defaults[name])
+		}
+	case 2:
+		s.defaults[strings.TrimLeft(args[0], "-")] = args[1]
+	default:
+		fmt.Fprintf(s.out, "%s: usage: set <flag> <value>\n", colorErr)
+	}
+}
+
+// run executes a command, adding session defaults for flags it accepts which
+// weren't given.
+func (s *shell) run(words []string) {
+	if cmd, _, err := s.root.Find(words); err == nil {
+		// The token is kept once given, so it can't change for one command.
+		if f := cmd.Flag("access-token"); f != nil && hasFlag(words, f) {
+			fmt.Fprintf(s.out, "%s: --access-token can only be given when starting the shell\n", colorErr)
+			return
+		}
+
+		for _, name := range s.defaultNames() {
+			f := cmd.Flag(name)
+			if f == nil || hasFlag(words, f) {
+				continue
+			}
+			words = append(words, fmt.Sprintf("--%s=%s", name, s.defaults[name]))
+		}
+	}
+
+	resetFlags(s.root)
+	s.root.SetArgs(words)
+	s.root.Execute()
+}
+
+// complete lists candidates for the last word of line.
+func (s *shell) complete(line string) []string {
+	words, err := splitWords(line)
+	if err != nil {
+		return nil
+	}
+
+	cur := ""
+	if len(words) > 0 && !strings.HasSuffix(line, " ") {
+		cur = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+	if len(words) > 0 && words[0] == s.root.Name() {
+		words = words[1:]
+	}
+
+	candidates := complete(s.root, s.config, words, cur)
+	if len(words) == 0 {
+		candidates = append(candidates, matching([]string{"exit", "history", "set", "unset"}, "", cur)...)
+		sort.Strings(candidates)
+	}
+
+	return candidates
+}
+
+// hasFlag reports whether f was given in words.
+func hasFlag(words []string, f *pflag.Flag) bool {
+	for _, w := range words {
+		if w == "--" {
+			return false
+		}
+		if w == "--"+f.Name || strings.HasPrefix(w, "--"+f.Name+"=") {
+			return true
+		}
+		if f.Shorthand != "" && strings.HasPrefix(w, "-"+f.Shorthand) && !strings.HasPrefix(w, "--") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resetFlags returns the flags of cmd and its subcommands to their defaults,
+// so values don't carry over between commands run in the shell.
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if !f.Changed {
+			return
+		}
+		f.Changed = false
+
+		// Slice values append once set, so are replaced.
+		if f.Value.Type() == "stringSlice" {
+			fs := pflag.NewFlagSet(f.Name, pflag.ContinueOnError)
+			fs.StringSlice(f.Name, sliceDefault(f), f.Usage)
+			f.Value = fs.Lookup(f.Name).Value
+			return
+		}
+
+		f.Value.Set(f.DefValue)
+	}
+
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+
+	for _, c := range cmd.Commands() {
+		resetFlags(c)
+	}
+}
+
+// sliceDefault parses the default value of a string slice flag.
+func sliceDefault(f *pflag.Flag) []string {
+	v := strings.Trim(f.DefValue, "[]")
+	if v == "" {
+		return []string{}
+	}
+
+	dflt, _ := csv.NewReader(strings.NewReader(v)).Read()
+	return dflt
+}
+
+// splitWords splits a line into words like a POSIX shell, honouring quotes
+// and backslash escapes.
+func splitWords(line string) ([]string, error) {
+	var words []string
+	var word []rune
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			word = append(word, r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word = append(word, r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, string(word))
+				word = word[:0]
+				inWord = false
+			}
+		default:
+			word = append(word, r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inWord {
+		words = append(words, string(word))
+	}
+
+	return words, nil
+}
+
+// shellHistoryPath is kept alongside the plugin store, in doctl's data
+// directory.
+func shellHistoryPath() (string, error) {
+	dir, err := pluginhost.DefaultStoreDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(dir), "shell_history"), nil
+}
+
+func readHistory(path string) []string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) > shellHistorySize {
+		lines = lines[len(lines)-shellHistorySize:]
+	}
+	if len(lines) == 1 && lines[0] == "" {
+		return nil
+	}
+
+	return lines
+}
+
+func appendHistory(path, line string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(f, line); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
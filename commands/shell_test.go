@@ -0,0 +1,149 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitWords(t *testing.T) {
+	cases := []struct {
+		line  string
+		words []string
+		err   bool
+	}{
+		{line: "compute droplet list", words: []string{"compute", "droplet", "list"}},
+		{line: `  set region  nyc3 `, words: []string{"set", "region", "nyc3"}},
+		{line: `droplet create "my droplet" --user-data 'a "b"'`, words: []string{"droplet", "create", "my droplet", "--user-data", `a "b"`}},
+		{line: `a\ b ""`, words: []string{"a b", ""}},
+		{line: `"unterminated`, err: true},
+	}
+
+	for _, c := range cases {
+		words, err := splitWords(c.line)
+		if c.err {
+			assert.Error(t, err, c.line)
+			continue
+		}
+		assert.NoError(t, err, c.line)
+		assert.Equal(t, c.words, words, c.line)
+	}
+}
+
+type shellRecorder struct {
+	region string
+	tags   []string
+	calls  int
+}
+
+func testShellRoot(r *shellRecorder) *cobra.Command {
+	root := &cobra.Command{Use: "doctl"}
+	root.PersistentFlags().StringP("output", "o", "text", "output")
+	root.PersistentFlags().StringP("access-token", "t", "", "token")
+
+	create := &cobra.Command{
+		Use: "create",
+		Run: func(cmd *cobra.Command, args []string) {
+			r.calls++
+			r.region, _ = cmd.Flags().GetString("region")
+			r.tags, _ = cmd.Flags().GetStringSlice("tag")
+		},
+	}
+	create.Flags().String("region", "", "region")
+	create.Flags().StringSlice("tag", []string{}, "tags")
+
+	droplet := &cobra.Command{Use: "droplet"}
+	droplet.AddCommand(create)
+	root.AddCommand(droplet)
+
+	return root
+}
+
+func TestShellExecute(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		var out bytes.Buffer
+		config.Out = &out
+
+		r := &shellRecorder{}
+		s := newShell(testShellRoot(r), config)
+
+		assert.False(t, s.execute("doctl droplet create --region sfo2 --tag a --tag b"))
+		assert.Equal(t, "sfo2", r.region)
+		assert.Equal(t, []string{"a", "b"}, r.tags)
+
+		// Flags don't carry over between commands.
+		s.execute("droplet create")
+		assert.Equal(t, "", r.region)
+		assert.Equal(t, []string{}, r.tags)
+
+		s.execute("set region nyc3")
+		assert.Equal(t, "doctl (region=nyc3)> ", s.prompt())
+		s.execute("droplet create")
+		assert.Equal(t, "nyc3", r.region)
+
+		// Explicit flags win over session defaults.
+		s.execute("droplet create --region=lon1")
+		assert.Equal(t, "lon1", r.region)
+
+		s.execute("set")
+		assert.Equal(t, "region=nyc3\n", out.String())
+
+		s.execute("unset region")
+		s.execute("droplet create")
+		assert.Equal(t, "", r.region)
+		assert.Equal(t, "doctl> ", s.prompt())
+
+		// The token can't be changed for one command.
+		out.Reset()
+		s.execute("droplet create -t secret")
+		assert.Contains(t, out.String(), "--access-token can only be given when starting the shell")
+
+		s.account = "sammy@example.com"
+		assert.Equal(t, "doctl (sammy@example.com)> ", s.prompt())
+
+		assert.Equal(t, 5, r.calls)
+		assert.True(t, s.execute("exit"))
+	})
+}
+
+func TestShellComplete(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		s := newShell(testShellRoot(&shellRecorder{}), config)
+
+		assert.Equal(t, []string{"droplet"}, s.complete("dr"))
+		assert.Equal(t, []string{"set"}, s.complete("se"))
+		assert.Equal(t, []string{"create"}, s.complete("doctl droplet "))
+		assert.Equal(t, []string{"--region"}, s.complete("droplet create --reg"))
+	})
+}
+
+func TestShellHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shell")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "doctl", "shell_history")
+	assert.Nil(t, readHistory(path))
+
+	assert.NoError(t, appendHistory(path, "account get"))
+	assert.NoError(t, appendHistory(path, "compute droplet list"))
+	assert.Equal(t, []string{"account get", "compute droplet list"}, readHistory(path))
+}
@@ -80,11 +80,11 @@ func RunSSH(c *CmdConfig) error {
 
 	var droplet *do.Droplet
 
-	ds := c.Droplets()
-	if id, err := strconv.Atoi(dropletID); err == nil {
+	r := newResolver(c)
+	if _, err := strconv.Atoi(dropletID); err == nil {
 		// dropletID is an integer
 
-		doDroplet, err := ds.Get(id)
+		doDroplet, err := r.Droplet(dropletID)
 		if err != nil {
 			return err
 		}
@@ -92,11 +92,6 @@ func RunSSH(c *CmdConfig) error {
 		droplet = doDroplet
 	} else {
 		// dropletID is a string
-		droplets, err := ds.List()
-		if err != nil {
-			return err
-		}
-
 		shi := extractHostInfo(dropletID)
 
 		user = shi.user
@@ -104,21 +99,12 @@ func RunSSH(c *CmdConfig) error {
 			port = i
 		}
 
-		for _, d := range droplets {
-			if d.Name == shi.host {
-				droplet = &d
-				break
-			}
-			if strconv.Itoa(d.ID) == shi.host {
-				droplet = &d
-				break
-			}
-		}
-
-		if droplet == nil {
-			return errors.New("could not find droplet")
+		doDroplet, err := r.Droplet(shi.host)
+		if err != nil {
+			return err
 		}
 
+		droplet = doDroplet
 	}
 
 	if user == "" {
@@ -77,7 +77,7 @@ func TestSSH_UnknownDroplet(t *testing.T) {
 		config.Args = append(config.Args, "missing")
 
 		err := RunSSH(config)
-		assert.EqualError(t, err, "could not find droplet")
+		assert.EqualError(t, err, `unable to find droplet "missing"`)
 	})
 }
 
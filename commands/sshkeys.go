@@ -37,10 +37,10 @@ func SSHKeys() *Command {
 	}
 
 	CmdBuilder(cmd, RunKeyList, "list", "list ssh keys", Writer,
-		aliasOpt("ls"), displayerType(&key{}), docCategories("sshkeys"))
+		aliasOpt("ls"), displayerType(&key{}), listOpt(), docCategories("sshkeys"))
 
-	CmdBuilder(cmd, RunKeyGet, "get <key-id|key-fingerprint>", "get ssh key", Writer,
-		aliasOpt("g"), displayerType(&key{}), docCategories("sshkeys"))
+	CmdBuilder(cmd, RunKeyGet, "get <key-id|key-fingerprint|key-name>", "get ssh key", Writer,
+		aliasOpt("g"), displayerType(&key{}), singleOpt(), docCategories("sshkeys"))
 
 	cmdSSHKeysCreate := CmdBuilder(cmd, RunKeyCreate, "create <key-name>", "create ssh key", Writer,
 		aliasOpt("c"), displayerType(&key{}), docCategories("sshkeys"))
@@ -50,10 +50,10 @@ func SSHKeys() *Command {
 		aliasOpt("i"), displayerType(&key{}), docCategories("sshkeys"))
 	AddStringFlag(cmdSSHKeysImport, doit.ArgKeyPublicKeyFile, "", "Public key file", requiredOpt())
 
-	CmdBuilder(cmd, RunKeyDelete, "delete <key-id|key-fingerprint>", "delete ssh key", Writer,
+	CmdBuilder(cmd, RunKeyDelete, "delete <key-id|key-fingerprint|key-name>", "delete ssh key", Writer,
 		aliasOpt("d"), docCategories("sshkeys"))
 
-	cmdSSHKeysUpdate := CmdBuilder(cmd, RunKeyUpdate, "update <key-id|key-fingerprint>", "update ssh key", Writer,
+	cmdSSHKeysUpdate := CmdBuilder(cmd, RunKeyUpdate, "update <key-id|key-fingerprint|key-name>", "update ssh key", Writer,
 		aliasOpt("u"), displayerType(&key{}), docCategories("sshkeys"))
 	AddStringFlag(cmdSSHKeysUpdate, doit.ArgKeyName, "", "Key name", requiredOpt())
 
@@ -81,7 +81,10 @@ func RunKeyGet(c *CmdConfig) error {
 		return doit.NewMissingArgsErr(c.NS)
 	}
 
-	rawKey := c.Args[0]
+	rawKey, err := newResolver(c).KeyID(c.Args[0])
+	if err != nil {
+		return err
+	}
 	k, err := ks.Get(rawKey)
 
 	if err != nil {
@@ -172,7 +175,10 @@ func RunKeyDelete(c *CmdConfig) error {
 		return doit.NewMissingArgsErr(c.NS)
 	}
 
-	rawKey := c.Args[0]
+	rawKey, err := newResolver(c).KeyID(c.Args[0])
+	if err != nil {
+		return err
+	}
 	return ks.Delete(rawKey)
 }
 
@@ -184,7 +190,10 @@ func RunKeyUpdate(c *CmdConfig) error {
 		return doit.NewMissingArgsErr(c.NS)
 	}
 
-	rawKey := c.Args[0]
+	rawKey, err := newResolver(c).KeyID(c.Args[0])
+	if err != nil {
+		return err
+	}
 
 	name, err := c.Doit.GetString(c.NS, doit.ArgKeyName)
 	if err != nil {
@@ -26,7 +26,7 @@ import (
 )
 
 var (
-	testKey     = do.SSHKey{Key: &godo.Key{ID: 1, Fingerprint: "fingerprint"}}
+	testKey     = do.SSHKey{Key: &godo.Key{ID: 1, Fingerprint: "3b:16:bf:e4:8b:00:8b:b8:59:8c:a9:d3:f0:19:45:fa"}}
 	testKeyList = do.SSHKeys{testKey}
 )
 
@@ -94,9 +94,9 @@ func TestKeysDeleteByID(t *testing.T) {
 
 func TestKeysDeleteByFingerprint(t *testing.T) {
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-		tm.keys.On("Delete", "fingerprint").Return(nil)
+		tm.keys.On("Delete", testKey.Fingerprint).Return(nil)
 
-		config.Args = append(config.Args, "fingerprint")
+		config.Args = append(config.Args, testKey.Fingerprint)
 
 		err := RunKeyDelete(config)
 		assert.NoError(t, err)
@@ -122,9 +122,9 @@ func TestKeysUpdateByID(t *testing.T) {
 func TestKeysUpdateByFingerprint(t *testing.T) {
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
 		kur := &godo.KeyUpdateRequest{Name: "the key"}
-		tm.keys.On("Update", "fingerprint", kur).Return(&testKey, nil)
+		tm.keys.On("Update", testKey.Fingerprint, kur).Return(&testKey, nil)
 
-		config.Args = append(config.Args, "fingerprint")
+		config.Args = append(config.Args, testKey.Fingerprint)
 
 		config.Doit.Set(config.NS, doit.ArgKeyName, "the key")
 
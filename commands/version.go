@@ -14,11 +14,18 @@ limitations under the License.
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 
+	"github.com/blang/semver"
 	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/doctl/install"
+	"github.com/digitalocean/doctl/pkg/cache"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 type version struct {
@@ -26,34 +33,132 @@ type version struct {
 	Name, Build, Label  string
 }
 
+// upgrader finds and installs doctl releases.
+type upgrader interface {
+	Latest(channel string) (string, error)
+	Upgrade(version, binPath string) error
+}
+
+var (
+	newUpgrader = func() (upgrader, error) { return install.NewUpgrader(viper.GetString(doit.ArgUpdateURL)) }
+	executable  = os.Executable
+)
+
 // Version creates a version command.
 func Version() *Command {
-	return &Command{
+	cmd := &Command{
 		Command: &cobra.Command{
 			Use:   "version",
 			Short: "show the current version",
 			Run: func(cmd *cobra.Command, args []string) {
-				if doit.Build != "" {
-					doit.DoitVersion.Build = doit.Build
-				}
-				if doit.Major != "" {
-					i, _ := strconv.Atoi(doit.Major)
-					doit.DoitVersion.Major = i
-				}
-				if doit.Minor != "" {
-					i, _ := strconv.Atoi(doit.Minor)
-					doit.DoitVersion.Minor = i
-				}
-				if doit.Patch != "" {
-					i, _ := strconv.Atoi(doit.Patch)
-					doit.DoitVersion.Patch = i
-				}
-				if doit.Label != "" {
-					doit.DoitVersion.Label = doit.Label
-				}
-
-				fmt.Println(doit.DoitVersion.Complete(&doit.GithubLatestVersioner{}))
+				lv := latestVersioner()
+				setVersion()
+				fmt.Println(doit.DoitVersion.Complete(lv))
 			},
 		},
 	}
+
+	cmdVersionUpgrade := CmdBuilder(cmd, RunVersionUpgrade, "upgrade",
+		"upgrade doctl to the latest, or a given, release", Writer)
+	AddStringFlag(cmdVersionUpgrade, doit.ArgChannel, install.ChannelStable,
+		fmt.Sprintf("release channel: %s or %s (pre-releases)", install.ChannelStable, install.ChannelBeta))
+	AddStringFlag(cmdVersionUpgrade, doit.ArgVersion, "", "release version, instead of the latest in the channel")
+
+	return cmd
+}
+
+// latestVersioner starts looking up the latest release, unless update checks
+// are disabled.
+func latestVersioner() doit.LatestVersioner {
+	if !viper.GetBool(doit.ArgUpdateCheck) {
+		return disabledVersioner{}
+	}
+
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		dir = ""
+	}
+
+	lv := doit.NewLatestVersioner(viper.GetString(doit.ArgUpdateURL), dir)
+	return doit.StartLatestVersion(lv, doit.UpdateCheckTimeout)
+}
+
+type disabledVersioner struct{}
+
+func (disabledVersioner) LatestVersion() (string, error) {
+	return "", errors.New("update checks are disabled")
+}
+
+// setVersion fills in doit.DoitVersion from the values set at build time.
+func setVersion() {
+	if doit.Build != "" {
+		doit.DoitVersion.Build = doit.Build
+	}
+	if doit.Major != "" {
+		i, _ := strconv.Atoi(doit.Major)
+		doit.DoitVersion.Major = i
+	}
+	if doit.Minor != "" {
+		i, _ := strconv.Atoi(doit.Minor)
+		doit.DoitVersion.Minor = i
+	}
+	if doit.Patch != "" {
+		i, _ := strconv.Atoi(doit.Patch)
+		doit.DoitVersion.Patch = i
+	}
+	if doit.Label != "" {
+		doit.DoitVersion.Label = doit.Label
+	}
+}
+
+// RunVersionUpgrade replaces the running doctl binary with a release.
+func RunVersionUpgrade(c *CmdConfig) error {
+	channel, err := c.Doit.GetString(c.NS, doit.ArgChannel)
+	if err != nil {
+		return err
+	}
+
+	target, err := c.Doit.GetString(c.NS, doit.ArgVersion)
+	if err != nil {
+		return err
+	}
+
+	u, err := newUpgrader()
+	if err != nil {
+		return err
+	}
+
+	setVersion()
+	current := doit.DoitVersion.String()
+
+	if target == "" {
+		target, err = u.Latest(channel)
+		if err != nil {
+			return err
+		}
+
+		v0, err1 := semver.Make(target)
+		v1, err2 := semver.Make(current)
+		if err1 == nil && err2 == nil && !v0.GT(v1) {
+			fmt.Fprintf(c.Out, "doctl is up to date (%s)\n", current)
+			return nil
+		}
+	}
+
+	binPath, err := executable()
+	if err != nil {
+		return err
+	}
+
+	binPath, err = filepath.EvalSymlinks(binPath)
+	if err != nil {
+		return err
+	}
+
+	if err := u.Upgrade(target, binPath); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Out, "upgraded doctl from %s to %s\n", current, target)
+	return nil
 }
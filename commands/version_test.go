@@ -14,13 +14,126 @@ limitations under the License.
 package commands
 
 import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/digitalocean/doctl"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestVersionCommand(t *testing.T) {
 	cmd := Version()
 	assert.NotNil(t, cmd)
-	assertCommandNames(t, cmd)
+	assertCommandNames(t, cmd, "upgrade")
+}
+
+type fakeUpgrader struct {
+	latest   string
+	channel  string
+	version  string
+	binPath  string
+	upgraded bool
+}
+
+func (u *fakeUpgrader) Latest(channel string) (string, error) {
+	u.channel = channel
+	return u.latest, nil
+}
+
+func (u *fakeUpgrader) Upgrade(version, binPath string) error {
+	u.version, u.binPath, u.upgraded = version, binPath, true
+	return nil
+}
+
+func withFakeUpgrader(t *testing.T, u *fakeUpgrader, fn func()) {
+	origUpgrader, origExecutable, origVersion := newUpgrader, executable, doit.DoitVersion
+	defer func() {
+		newUpgrader, executable, doit.DoitVersion = origUpgrader, origExecutable, origVersion
+	}()
+
+	newUpgrader = func() (upgrader, error) { return u, nil }
+	executable = func() (string, error) { return "/", nil }
+	doit.DoitVersion = doit.Version{Major: 1, Minor: 0, Patch: 0}
+
+	fn()
+}
+
+func TestRunVersionUpgrade(t *testing.T) {
+	u := &fakeUpgrader{latest: "1.1.0-beta"}
+	withFakeUpgrader(t, u, func() {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Doit.Set(config.NS, doit.ArgChannel, "beta")
+
+			err := RunVersionUpgrade(config)
+			assert.NoError(t, err)
+			assert.Equal(t, "beta", u.channel)
+			assert.True(t, u.upgraded)
+			assert.Equal(t, "1.1.0-beta", u.version)
+			assert.Equal(t, "/", u.binPath)
+		})
+	})
+}
+
+func TestRunVersionUpgradeUpToDate(t *testing.T) {
+	u := &fakeUpgrader{latest: "1.0.0"}
+	withFakeUpgrader(t, u, func() {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Doit.Set(config.NS, doit.ArgChannel, "stable")
+
+			err := RunVersionUpgrade(config)
+			assert.NoError(t, err)
+			assert.False(t, u.upgraded)
+		})
+	})
+}
+
+func TestRunVersionUpgradeVersion(t *testing.T) {
+	u := &fakeUpgrader{latest: "1.1.0"}
+	withFakeUpgrader(t, u, func() {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Doit.Set(config.NS, doit.ArgVersion, "0.9.0")
+
+			err := RunVersionUpgrade(config)
+			assert.NoError(t, err)
+			assert.Equal(t, "", u.channel)
+			assert.Equal(t, "0.9.0", u.version)
+		})
+	})
+}
+
+func TestRunVersionUpgradeNoKey(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		orig := newUpgrader
+		defer func() { newUpgrader = orig }()
+		newUpgrader = func() (upgrader, error) { return nil, errors.New("no key") }
+
+		err := RunVersionUpgrade(config)
+		assert.Error(t, err)
+	})
+}
+
+func TestLatestVersioner(t *testing.T) {
+	defer viper.Set(doit.ArgUpdateCheck, true)
+	defer viper.Set(doit.ArgUpdateURL, "")
+
+	dir, err := ioutil.TempDir("", "version")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "latest")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("9.9.9"), 0600))
+
+	viper.Set(doit.ArgUpdateCheck, true)
+	viper.Set(doit.ArgUpdateURL, path)
+	v, err := latestVersioner().LatestVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, "9.9.9", v)
+
+	viper.Set(doit.ArgUpdateCheck, false)
+	_, err = latestVersioner().LatestVersion()
+	assert.Error(t, err)
 }
@@ -0,0 +1,279 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/digitalocean/doctl"
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+// defaultWatchInterval is how often --watch polls when no interval is given.
+const defaultWatchInterval = "5s"
+
+var (
+	addedColor   = color.New(color.FgGreen).SprintFunc()
+	changedColor = color.New(color.Bold, color.FgYellow).SprintFunc()
+)
+
+// watchInterval returns the poll interval given with --watch, or zero if the
+// command isn't being watched. Intervals are durations such as "30s", or a
+// number of seconds.
+func watchInterval(c *CmdConfig) (time.Duration, error) {
+	raw, err := c.Doit.GetString(c.NS, doit.ArgWatch)
+	if err != nil || raw == "" {
+		return 0, err
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		secs, serr := strconv.Atoi(raw)
+		if serr != nil {
+			return 0, fmt.Errorf("invalid watch interval %q", raw)
+		}
+		d = time.Duration(secs) * time.Second
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid watch interval %q", raw)
+	}
+
+	return d, nil
+}
+
+// watch runs a command every interval and redraws its output until it is
+// interrupted.
+func watch(c *CmdConfig, cr CmdRunner, interval time.Duration) error {
+	output, err := c.Doit.GetString(doit.NSRoot, doit.ArgOutput)
+	if err != nil {
+		return err
+	}
+
+	cols, err := handleColumns(c.NS, c.Doit)
+	if err != nil {
+		return err
+	}
+
+	w := &watcher{out: c.Out, json: output == "json", tty: isTerminal(c.Out)}
+	c.displayFn = func(d Displayable) error {
+		return w.render(d, cols)
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	defer signal.Stop(sig)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := cr(c); err != nil {
+			return err
+		}
+
+		select {
+		case <-sig:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && isatty.IsTerminal(f.Fd())
+}
+
+// watcher renders successive polls of a command's output. Text output is
+// redrawn in place on a terminal, with new rows and changed cells
+// highlighted. JSON output is written as one object per line, and only for
+// objects which changed since the last poll.
+type watcher struct {
+	out  io.Writer
+	json bool
+	tty  bool
+
+	polls     int
+	lines     int
+	prevRows  map[string][]string
+	prevItems map[string]string
+}
+
+func (w *watcher) render(item Displayable, cols []string) error {
+	defer func() { w.polls++ }()
+
+	if w.json {
+		return w.renderJSON(item)
+	}
+
+	return w.renderText(item, cols)
+}
+
+// rowKey identifies a row across polls by its ID column, falling back to its
+// position.
+func rowKey(r map[string]interface{}, i int) string {
+	if id, ok := r["ID"]; ok {
+		return fmt.Sprint(id)
+	}
+
+	return strconv.Itoa(i)
+}
+
+func (w *watcher) renderText(item Displayable, includeCols []string) error {
+	cols := item.Cols()
+	if len(includeCols) > 0 && includeCols[0] != "" {
+		cols = includeCols
+	}
+
+	headers := make([]string, len(cols))
+	widths := make([]int, len(cols))
+	for i, k := range cols {
+		h := item.ColMap()[k]
+		if h == "" {
+			return fmt.Errorf("unknown column %q", k)
+		}
+
+		headers[i] = h
+		if !hc.hideHeader {
+			widths[i] = utf8.RuneCountInString(h)
+		}
+	}
+
+	kv := item.KV()
+	keys := make([]string, len(kv))
+	rows := map[string][]string{}
+	for i, r := range kv {
+		cells := make([]string, len(cols))
+		for j, col := range cols {
+			cells[j] = fmt.Sprint(r[col])
+			if n := utf8.RuneCountInString(cells[j]); n > widths[j] {
+				widths[j] = n
+			}
+		}
+
+		keys[i] = rowKey(r, i)
+		rows[keys[i]] = cells
+	}
+
+	var buf bytes.Buffer
+	lines := 0
+
+	writeRow := func(cells []string, prev []string, highlight bool) {
+		for j, cell := range cells {
+			padded := cell + strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell))
+			if j == len(cells)-1 {
+				padded = cell
+			}
+
+			switch {
+			case !highlight:
+			case prev == nil:
+				padded = addedColor(padded)
+			case prev[j] != cell:
+				padded = changedColor(padded)
+			}
+
+			buf.WriteString(padded)
+			if j < len(cells)-1 {
+				buf.WriteString("  ")
+			}
+		}
+		buf.WriteString("\n")
+		lines++
+	}
+
+	if !hc.hideHeader {
+		writeRow(headers, headers, false)
+	}
+
+	highlight := w.tty && w.polls > 0
+	for _, k := range keys {
+		writeRow(rows[k], w.prevRows[k], highlight)
+	}
+
+	switch {
+	case w.tty && w.lines > 0:
+		fmt.Fprintf(w.out, "\033[%dA\033[J", w.lines)
+	case w.polls > 0:
+		fmt.Fprintln(w.out)
+	}
+
+	w.lines = lines
+	w.prevRows = rows
+
+	_, err := buf.WriteTo(w.out)
+	return err
+}
+
+// objectKey identifies a JSON object across polls by its id, falling back
+// to its position.
+func objectKey(o interface{}, i int) string {
+	if m, ok := o.(map[string]interface{}); ok {
+		if id, ok := m["id"]; ok {
+			return fmt.Sprint(id)
+		}
+	}
+
+	return strconv.Itoa(i)
+}
+
+func (w *watcher) renderJSON(item Displayable) error {
+	var buf bytes.Buffer
+	if err := item.JSON(&buf); err != nil {
+		return err
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		return err
+	}
+
+	objs, ok := data.([]interface{})
+	if !ok {
+		objs = []interface{}{data}
+	}
+
+	items := map[string]string{}
+	for i, o := range objs {
+		b, err := json.Marshal(o)
+		if err != nil {
+			return err
+		}
+
+		key := objectKey(o, i)
+		items[key] = string(b)
+
+		if prev, ok := w.prevItems[key]; ok && prev == string(b) {
+			continue
+		}
+
+		if _, err := fmt.Fprintln(w.out, string(b)); err != nil {
+			return err
+		}
+	}
+
+	w.prevItems = items
+	return nil
+}
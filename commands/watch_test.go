@@ -0,0 +1,191 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/doctl/do"
+	"github.com/digitalocean/godo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchInterval(t *testing.T) {
+	cases := []struct {
+		in       string
+		expected time.Duration
+		err      string
+	}{
+		{in: "", expected: 0},
+		{in: "5s", expected: 5 * time.Second},
+		{in: "2m", expected: 2 * time.Minute},
+		{in: "10", expected: 10 * time.Second},
+		{in: "0", err: `invalid watch interval "0"`},
+		{in: "soon", err: `invalid watch interval "soon"`},
+	}
+
+	for _, c := range cases {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Doit.Set(config.NS, doit.ArgWatch, c.in)
+
+			d, err := watchInterval(config)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, d)
+		})
+	}
+}
+
+func watchTestKeys(names ...string) *key {
+	keys := do.SSHKeys{}
+	for i, n := range names {
+		keys = append(keys, do.SSHKey{Key: &godo.Key{ID: i + 1, Name: n, Fingerprint: "fp"}})
+	}
+
+	return &key{keys: keys}
+}
+
+func TestWatcher_Text(t *testing.T) {
+	var buf bytes.Buffer
+	w := &watcher{out: &buf}
+
+	err := w.render(watchTestKeys("laptop"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "ID  Name    FingerPrint\n1   laptop  fp\n", buf.String())
+
+	buf.Reset()
+	err = w.render(watchTestKeys("laptop", "ci"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "\nID  Name    FingerPrint\n1   laptop  fp\n2   ci      fp\n", buf.String())
+}
+
+func TestWatcher_TextTTY(t *testing.T) {
+	var buf bytes.Buffer
+	w := &watcher{out: &buf, tty: true}
+
+	err := w.render(watchTestKeys("laptop"), []string{"ID", "Name"})
+	assert.NoError(t, err)
+
+	buf.Reset()
+	err = w.render(watchTestKeys("desktop", "ci"), []string{"ID", "Name"})
+	assert.NoError(t, err)
+
+	expected := "\033[2A\033[J" +
+		"ID  Name\n" +
+		"1   " + changedColor("desktop") + "\n" +
+		addedColor("2 ") + "  " + addedColor("ci") + "\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestWatcher_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	w := &watcher{out: &buf, json: true}
+
+	err := w.render(watchTestKeys("laptop", "ci"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"{\"fingerprint\":\"fp\",\"id\":1,\"name\":\"laptop\"}\n"+
+			"{\"fingerprint\":\"fp\",\"id\":2,\"name\":\"ci\"}\n",
+		buf.String())
+
+	buf.Reset()
+	err = w.render(watchTestKeys("laptop", "build"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"fingerprint\":\"fp\",\"id\":2,\"name\":\"build\"}\n", buf.String())
+}
+
+func TestWatchOutputFromConfig(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		var buf bytes.Buffer
+		config.Out = &buf
+		config.Doit.Set(doit.NSRoot, doit.ArgOutput, "json")
+
+		errDone := errors.New("done")
+		polls := 0
+		err := watch(config, func(c *CmdConfig) error {
+			polls++
+			if polls > 1 {
+				return errDone
+			}
+			return c.Display(watchTestKeys("laptop"))
+		}, time.Millisecond)
+
+		assert.Equal(t, errDone, err)
+		assert.Equal(t, "{\"fingerprint\":\"fp\",\"id\":1,\"name\":\"laptop\"}\n", buf.String())
+	})
+}
+
+// subCommand returns the child command of parent with the given name.
+func subCommand(t *testing.T, parent *Command, name string) *Command {
+	for _, c := range parent.ChildCommands() {
+		if c.Name() == name {
+			return c
+		}
+	}
+
+	t.Fatalf("command %q has no subcommand %q", parent.Name(), name)
+	return nil
+}
+
+func TestBypassCache(t *testing.T) {
+	cases := []struct {
+		cmd      *Command
+		args     []string
+		expected bool
+	}{
+		{cmd: subCommand(t, Droplet(), "list"), expected: false},
+		{cmd: subCommand(t, Droplet(), "list"), args: []string{"--watch"}, expected: true},
+		{cmd: subCommand(t, Droplet(), "list"), args: []string{"--watch=10s"}, expected: true},
+		{cmd: subCommand(t, Domain(), "audit"), expected: false},
+		{cmd: subCommand(t, Domain(), "audit"), args: []string{"--delete"}, expected: true},
+	}
+
+	for _, c := range cases {
+		assert.NoError(t, c.cmd.ParseFlags(c.args))
+		assert.Equal(t, c.expected, bypassCache(c.cmd.Flags(), c.cmd.uncachedFlags), "%s %v", c.cmd.Name(), c.args)
+	}
+}
+
+func TestWatchFlags(t *testing.T) {
+	cases := []struct {
+		args     []string
+		expected time.Duration
+		rest     []string
+	}{
+		{args: []string{}, expected: 0, rest: []string{}},
+		{args: []string{"--watch"}, expected: 5 * time.Second, rest: []string{}},
+		{args: []string{"--watch=10s"}, expected: 10 * time.Second, rest: []string{}},
+		{args: []string{"--watch=30", "web"}, expected: 30 * time.Second, rest: []string{"web"}},
+		{args: []string{"--watch", "web"}, expected: 5 * time.Second, rest: []string{"web"}},
+	}
+
+	for _, c := range cases {
+		cmd := subCommand(t, Droplet(), "list")
+		assert.NoError(t, cmd.ParseFlags(c.args))
+
+		config := &CmdConfig{NS: cmdNS(cmd.Command), Doit: &doit.LiveConfig{}}
+		d, err := watchInterval(config)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, d, "%v", c.args)
+		assert.Equal(t, c.rest, cmd.Flags().Args(), "%v", c.args)
+	}
+}
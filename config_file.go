@@ -90,6 +90,60 @@ func (cf *ConfigFile) Set(key string, val interface{}) error {
 	return ioutil.WriteFile(cf.location, out, 0600)
 }
 
+// Get returns the value of a ConfigFile key, or nil if it isn't set.
+func (cf *ConfigFile) Get(key string) (interface{}, error) {
+	m, err := cf.read()
+	if err != nil {
+		return nil, err
+	}
+
+	return m[key], nil
+}
+
+// Unset removes a ConfigFile key.
+func (cf *ConfigFile) Unset(key string) error {
+	m, err := cf.read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := m[key]; !ok {
+		return nil
+	}
+
+	delete(m, key)
+
+	out, err := yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(cf.location, out, 0600)
+}
+
+// read returns the keys in a ConfigFile. A missing file has none.
+func (cf *ConfigFile) read() (map[string]interface{}, error) {
+	b, err := ioutil.ReadFile(cf.location)
+	if os.IsNotExist(err) {
+		return map[string]interface{}{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	err = yaml.Unmarshal(b, &m)
+	if err != nil {
+		return nil, err
+	}
+
+	if m == nil {
+		m = map[string]interface{}{}
+	}
+
+	return m, nil
+}
+
 // Open opens a ConfigFile.
 func (cf *ConfigFile) Open() (io.Reader, error) {
 	_, err := os.Stat(cf.location)
@@ -83,3 +83,35 @@ func TestNewConfigFile(t *testing.T) {
 
 	assert.NotEmpty(t, cf.location)
 }
+
+func TestConfigGetAndUnset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cf := &ConfigFile{
+		location: filepath.Join(dir, configFile),
+	}
+
+	v, err := cf.Get("foo")
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+	assert.NoError(t, cf.Unset("foo"))
+
+	assert.NoError(t, cf.Set("foo", "bar"))
+	assert.NoError(t, cf.Set("baz", "qux"))
+
+	v, err = cf.Get("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", v)
+
+	assert.NoError(t, cf.Unset("foo"))
+
+	v, err = cf.Get("foo")
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	v, err = cf.Get("baz")
+	assert.NoError(t, err)
+	assert.Equal(t, "qux", v)
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/digitalocean/doctl/pkg/credentials"
+	"github.com/digitalocean/doctl/pluginhost"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Credential stores.
+const (
+	// CredentialStoreConfig keeps the access token in plain text in the
+	// configuration file. It is the default.
+	CredentialStoreConfig = "config"
+	// CredentialStoreKeyring keeps the access token in the OS keyring.
+	CredentialStoreKeyring = "keyring"
+	// CredentialStoreFile keeps the access token in a file encrypted with a
+	// passphrase.
+	CredentialStoreFile = "file"
+	// CredentialStoreHelper keeps the access token with a credential helper.
+	CredentialStoreHelper = "helper"
+)
+
+const (
+	// EnvCredentialPassphrase is the passphrase of the encrypted credentials
+	// file. It is asked for on the terminal when not set.
+	EnvCredentialPassphrase = "DOCTL_CREDENTIAL_PASSPHRASE"
+
+	// AccessTokenKey is the key access tokens are stored under.
+	AccessTokenKey = "https://api.digitalocean.com"
+)
+
+// NewCredentialStore creates the credential store set with credential-store.
+// Setting only credential-helper selects the helper store.
+func NewCredentialStore() (credentials.Store, error) {
+	helper := viper.GetString(ArgCredentialHelper)
+
+	kind := viper.GetString(ArgCredentialStore)
+	if kind == "" {
+		kind = CredentialStoreConfig
+		if helper != "" {
+			kind = CredentialStoreHelper
+		}
+	}
+
+	switch kind {
+	case CredentialStoreConfig:
+		return NewConfigStore()
+	case CredentialStoreKeyring:
+		return credentials.NewSecretService(), nil
+	case CredentialStoreFile:
+		path := viper.GetString(ArgCredentialFile)
+		if path == "" {
+			dir, err := pluginhost.DefaultStoreDir()
+			if err != nil {
+				return nil, err
+			}
+			path = filepath.Join(filepath.Dir(dir), "credentials.json")
+		}
+		return credentials.NewFileStore(path, readPassphrase), nil
+	case CredentialStoreHelper:
+		if helper == "" {
+			return nil, fmt.Errorf("the %s credential store needs %s to be set", kind, ArgCredentialHelper)
+		}
+		return credentials.NewHelper(helper)
+	default:
+		return nil, fmt.Errorf("unknown credential store %q, use one of %s, %s, %s or %s", kind,
+			CredentialStoreConfig, CredentialStoreKeyring, CredentialStoreFile, CredentialStoreHelper)
+	}
+}
+
+// readPassphrase returns the credentials file passphrase from the
+// environment, or asks for it on the terminal.
+func readPassphrase() ([]byte, error) {
+	if p := os.Getenv(EnvCredentialPassphrase); p != "" {
+		return []byte(p), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return nil, fmt.Errorf("set %s to use the encrypted credentials file", EnvCredentialPassphrase)
+	}
+
+	fmt.Fprint(os.Stderr, "Credentials file passphrase: ")
+	p, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return p, err
+}
+
+// ConfigStore keeps the access token in plain text in the configuration file,
+// as access-token.
+type ConfigStore struct {
+	file *ConfigFile
+}
+
+var _ credentials.Store = &ConfigStore{}
+
+// NewConfigStore creates a ConfigStore for the user's configuration file.
+func NewConfigStore() (*ConfigStore, error) {
+	cf, err := NewConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConfigStore{file: cf}, nil
+}
+
+// Get returns the access token.
+func (cs *ConfigStore) Get(key string) (string, error) {
+	if key != AccessTokenKey {
+		return "", credentials.ErrNotFound
+	}
+
+	v, err := cs.file.Get("access-token")
+	if err != nil {
+		return "", err
+	}
+
+	token, _ := v.(string)
+	if token == "" {
+		return "", credentials.ErrNotFound
+	}
+	return token, nil
+}
+
+// Set saves the access token.
+func (cs *ConfigStore) Set(key, secret string) error {
+	if key != AccessTokenKey {
+		return errors.New("the configuration file can only store the access token")
+	}
+
+	return cs.file.Set("access-token", secret)
+}
+
+// Erase removes the access token.
+func (cs *ConfigStore) Erase(key string) error {
+	if key != AccessTokenKey {
+		return nil
+	}
+
+	return cs.file.Unset("access-token")
+}
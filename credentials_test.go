@@ -0,0 +1,121 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/digitalocean/doctl/pkg/credentials"
+	"github.com/digitalocean/doctl/pkg/credentials/credentialstest"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCredentialStore(t *testing.T) {
+	defer func() {
+		viper.Set(ArgCredentialStore, "")
+		viper.Set(ArgCredentialHelper, "")
+		viper.Set(ArgCredentialFile, "")
+	}()
+
+	cases := []struct {
+		store, helper string
+		expected      credentials.Store
+	}{
+		{store: "", expected: &ConfigStore{}},
+		{store: CredentialStoreConfig, helper: "ignored", expected: &ConfigStore{}},
+		{store: CredentialStoreKeyring, expected: &credentials.SecretService{}},
+		{store: CredentialStoreFile, expected: &credentials.FileStore{}},
+		{store: "", helper: "docker-credential-pass", expected: &credentials.Helper{}},
+		{store: CredentialStoreHelper, helper: "docker-credential-pass", expected: &credentials.Helper{}},
+	}
+
+	for _, c := range cases {
+		viper.Set(ArgCredentialStore, c.store)
+		viper.Set(ArgCredentialHelper, c.helper)
+
+		store, err := NewCredentialStore()
+		assert.NoError(t, err, c.store)
+		assert.IsType(t, c.expected, store, c.store)
+	}
+
+	viper.Set(ArgCredentialStore, CredentialStoreFile)
+	viper.Set(ArgCredentialFile, "/tmp/doctl-credentials.json")
+	store, err := NewCredentialStore()
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/doctl-credentials.json", store.(*credentials.FileStore).Path)
+
+	viper.Set(ArgCredentialStore, CredentialStoreHelper)
+	viper.Set(ArgCredentialHelper, "")
+	_, err = NewCredentialStore()
+	assert.Error(t, err)
+
+	viper.Set(ArgCredentialStore, "vault")
+	_, err = NewCredentialStore()
+	assert.Error(t, err)
+}
+
+func TestLiveConfigAccessToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store := credentialstest.NewStore(filepath.Join(dir, "credentials.json"))
+	c := &LiveConfig{credentialStore: store}
+
+	token, err := c.accessToken()
+	assert.NoError(t, err)
+	assert.Equal(t, "", token)
+
+	assert.NoError(t, store.Set(AccessTokenKey, "stored"))
+	token, err = c.accessToken()
+	assert.NoError(t, err)
+	assert.Equal(t, "stored", token)
+
+	viper.Set("access-token", "given")
+	defer viper.Set("access-token", "")
+
+	token, err = c.accessToken()
+	assert.NoError(t, err)
+	assert.Equal(t, "given", token)
+}
+
+func TestConfigStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cs := &ConfigStore{file: &ConfigFile{location: filepath.Join(dir, configFile)}}
+
+	_, err = cs.Get(AccessTokenKey)
+	assert.Equal(t, credentials.ErrNotFound, err)
+
+	assert.NoError(t, cs.Set(AccessTokenKey, "token"))
+	assert.Error(t, cs.Set("https://example.com", "token"))
+
+	v, err := cs.file.Get("access-token")
+	assert.NoError(t, err)
+	assert.Equal(t, "token", v)
+
+	token, err := cs.Get(AccessTokenKey)
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token)
+
+	assert.NoError(t, cs.Erase(AccessTokenKey))
+	_, err = cs.Get(AccessTokenKey)
+	assert.Equal(t, credentials.ErrNotFound, err)
+}
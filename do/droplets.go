@@ -30,6 +30,8 @@ const (
 	InterfacePublic InterfaceType = "public"
 	// InterfacePrivate is a private interface.
 	InterfacePrivate InterfaceType = "private"
+	// InterfacePublicV6 is a public IPv6 interface.
+	InterfacePublicV6 InterfaceType = "public_v6"
 )
 
 // Droplet is a wrapper for godo.Droplet
@@ -37,6 +39,31 @@ type Droplet struct {
 	*godo.Droplet
 }
 
+// IPs returns a table of the droplet's interface IPs.
+func (d Droplet) IPs() DropletIPTable {
+	t := DropletIPTable{}
+	if d.Droplet == nil || d.Networks == nil {
+		return t
+	}
+
+	for _, in := range d.Networks.V4 {
+		switch in.Type {
+		case "public":
+			t[InterfacePublic] = in.IPAddress
+		case "private":
+			t[InterfacePrivate] = in.IPAddress
+		}
+	}
+
+	for _, in := range d.Networks.V6 {
+		if in.Type == "public" {
+			t[InterfacePublicV6] = in.IPAddress
+		}
+	}
+
+	return t
+}
+
 // Droplets is a slice of Droplet.
 type Droplets []Droplet
 
@@ -51,9 +78,10 @@ type Kernels []Kernel
 // DropletsService is an interface for interacting with DigitalOcean's droplet api.
 type DropletsService interface {
 	List() (Droplets, error)
+	ListByTag(string) (Droplets, error)
 	Get(int) (*Droplet, error)
 	Create(*godo.DropletCreateRequest, bool) (*Droplet, error)
-	CreateMultiple(*godo.DropletMultiCreateRequest) (Droplets, error)
+	CreateMultiple(*godo.DropletMultiCreateRequest) (Droplets, []godo.LinkAction, error)
 	Delete(int) error
 	Kernels(int) (Kernels, error)
 	Snapshots(int) (Images, error)
@@ -104,6 +132,35 @@ func (ds *dropletsService) List() (Droplets, error) {
 	return list, nil
 }
 
+func (ds *dropletsService) ListByTag(tag string) (Droplets, error) {
+	f := func(opt *godo.ListOptions) ([]interface{}, *godo.Response, error) {
+		list, resp, err := ds.client.Droplets.ListByTag(tag, opt)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		si := make([]interface{}, len(list))
+		for i := range list {
+			si[i] = list[i]
+		}
+
+		return si, resp, err
+	}
+
+	si, err := PaginateResp(f)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make(Droplets, len(si))
+	for i := range si {
+		a := si[i].(godo.Droplet)
+		list[i] = Droplet{Droplet: &a}
+	}
+
+	return list, nil
+}
+
 func (ds *dropletsService) Get(id int) (*Droplet, error) {
 	d, _, err := ds.client.Droplets.Get(id)
 	if err != nil {
@@ -141,18 +198,23 @@ func (ds *dropletsService) Create(dcr *godo.DropletCreateRequest, wait bool) (*D
 	return &Droplet{Droplet: d}, nil
 }
 
-func (ds *dropletsService) CreateMultiple(dmcr *godo.DropletMultiCreateRequest) (Droplets, error) {
-	godoDroplets, _, err := ds.client.Droplets.CreateMultiple(dmcr)
+func (ds *dropletsService) CreateMultiple(dmcr *godo.DropletMultiCreateRequest) (Droplets, []godo.LinkAction, error) {
+	godoDroplets, resp, err := ds.client.Droplets.CreateMultiple(dmcr)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var droplets Droplets
-	for _, d := range godoDroplets {
-		droplets = append(droplets, Droplet{Droplet: &d})
+	for i := range godoDroplets {
+		droplets = append(droplets, Droplet{Droplet: &godoDroplets[i]})
 	}
 
-	return droplets, nil
+	var actions []godo.LinkAction
+	if resp.Links != nil {
+		actions = resp.Links.Actions
+	}
+
+	return droplets, actions, nil
 }
 
 func (ds *dropletsService) Delete(id int) error {
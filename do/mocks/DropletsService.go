@@ -44,6 +44,27 @@ func (_m *DropletsService) List() (do.Droplets, error) {
 	return r0, r1
 }
 
+// ListByTag provides a mock function with given fields: _a0
+func (_m *DropletsService) ListByTag(_a0 string) (do.Droplets, error) {
+	ret := _m.Called(_a0)
+
+	var r0 do.Droplets
+	if rf, ok := ret.Get(0).(func(string) do.Droplets); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(do.Droplets)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Get provides a mock function with given fields: _a0
 func (_m *DropletsService) Get(_a0 int) (*do.Droplet, error) {
 	ret := _m.Called(_a0)
@@ -91,24 +112,35 @@ func (_m *DropletsService) Create(_a0 *godo.DropletCreateRequest, _a1 bool) (*do
 }
 
 // CreateMultiple provides a mock function with given fields: _a0
-func (_m *DropletsService) CreateMultiple(_a0 *godo.DropletMultiCreateRequest) (do.Droplets, error) {
+func (_m *DropletsService) CreateMultiple(_a0 *godo.DropletMultiCreateRequest) (do.Droplets, []godo.LinkAction, error) {
 	ret := _m.Called(_a0)
 
 	var r0 do.Droplets
 	if rf, ok := ret.Get(0).(func(*godo.DropletMultiCreateRequest) do.Droplets); ok {
 		r0 = rf(_a0)
 	} else {
-		r0 = ret.Get(0).(do.Droplets)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(do.Droplets)
+		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(*godo.DropletMultiCreateRequest) error); ok {
+	var r1 []godo.LinkAction
+	if rf, ok := ret.Get(1).(func(*godo.DropletMultiCreateRequest) []godo.LinkAction); ok {
 		r1 = rf(_a0)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]godo.LinkAction)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(*godo.DropletMultiCreateRequest) error); ok {
+		r2 = rf(_a0)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // Delete provides a mock function with given fields: _a0
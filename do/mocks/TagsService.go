@@ -0,0 +1,131 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mocks
+
+import "github.com/digitalocean/doctl/do"
+import "github.com/stretchr/testify/mock"
+import "github.com/digitalocean/godo"
+
+type TagsService struct {
+	mock.Mock
+}
+
+// List provides a mock function with given fields:
+func (_m *TagsService) List() (do.Tags, error) {
+	ret := _m.Called()
+
+	var r0 do.Tags
+	if rf, ok := ret.Get(0).(func() do.Tags); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(do.Tags)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Get provides a mock function with given fields: _a0
+func (_m *TagsService) Get(_a0 string) (*do.Tag, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *do.Tag
+	if rf, ok := ret.Get(0).(func(string) *do.Tag); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*do.Tag)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: _a0
+func (_m *TagsService) Create(_a0 *godo.TagCreateRequest) (*do.Tag, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *do.Tag
+	if rf, ok := ret.Get(0).(func(*godo.TagCreateRequest) *do.Tag); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*do.Tag)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*godo.TagCreateRequest) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: _a0
+func (_m *TagsService) Delete(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TagResources provides a mock function with given fields: _a0, _a1
+func (_m *TagsService) TagResources(_a0 string, _a1 *godo.TagResourcesRequest) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, *godo.TagResourcesRequest) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UntagResources provides a mock function with given fields: _a0, _a1
+func (_m *TagsService) UntagResources(_a0 string, _a1 *godo.UntagResourcesRequest) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, *godo.UntagResourcesRequest) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
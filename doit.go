@@ -15,15 +15,17 @@ package doit
 
 import (
 	"bytes"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"strconv"
-	"strings"
+	"os"
+	"path/filepath"
+	"sync"
 
 	"github.com/blang/semver"
+	"github.com/digitalocean/doctl/pkg/cache"
+	"github.com/digitalocean/doctl/pkg/credentials"
+	"github.com/digitalocean/doctl/pkg/har"
 	"github.com/digitalocean/doctl/pkg/runner"
 	"github.com/digitalocean/doctl/pkg/ssh"
 	"github.com/digitalocean/godo"
@@ -36,8 +38,8 @@ const (
 	// NSRoot is a configuration key that signifies this value is at the root.
 	NSRoot = "doit"
 
-	// LatestReleaseURL is the latest release URL endpoint.
-	LatestReleaseURL = "https://api.github.com/repos/bryanl/doit/releases/latest"
+	// LatestReleaseURL is the default latest release URL endpoint.
+	LatestReleaseURL = "https://api.github.com/repos/digitalocean/doctl/releases/latest"
 )
 
 var (
@@ -112,44 +114,10 @@ func (v Version) Complete(lv LatestVersioner) string {
 	return buffer.String()
 }
 
-// LatestVersioner an interface for detecting the latest version.
-type LatestVersioner interface {
-	LatestVersion() (string, error)
-}
-
-// GithubLatestVersioner retrieves the latest version from Github.
-type GithubLatestVersioner struct{}
-
-var _ LatestVersioner = &GithubLatestVersioner{}
-
-// LatestVersion retrieves the latest version from Github or returns
-// an error.
-func (glv *GithubLatestVersioner) LatestVersion() (string, error) {
-	u := LatestReleaseURL
-	res, err := http.Get(u)
-	if err != nil {
-		return "", err
-	}
-
-	defer res.Body.Close()
-
-	var m map[string]interface{}
-	if err = json.NewDecoder(res.Body).Decode(&m); err != nil {
-		return "", err
-	}
-
-	tn, ok := m["tag_name"]
-	if !ok {
-		return "", errors.New("could not find tag name in response")
-	}
-
-	tagName := tn.(string)
-	return strings.TrimPrefix(tagName, "v"), nil
-}
-
 // Config is an interface that represent doit's config.
 type Config interface {
 	GetGodoClient(trace bool) *godo.Client
+	CredentialStore() (credentials.Store, error)
 	SSH(user, host, keyPath string, port int) runner.Runner
 	Set(ns, key string, val interface{})
 	GetString(ns, key string) (string, error)
@@ -160,40 +128,159 @@ type Config interface {
 
 // LiveConfig is an implementation of Config for live values.
 type LiveConfig struct {
-	godoClient *godo.Client
+	mu              sync.Mutex
+	godoClients     map[clientOptions]*godo.Client
+	credentialStore credentials.Store
 }
 
 var _ Config = &LiveConfig{}
 
+// clientOptions are the settings a godo client is built with. Commands run
+// in a shell or batch can change them, so a client is kept for each.
+type clientOptions struct {
+	token           string
+	trace           bool
+	traceFile       string
+	traceUnredacted bool
+	noCache         bool
+}
+
 // GetGodoClient returns a GodoClient.
 func (c *LiveConfig) GetGodoClient(trace bool) *godo.Client {
-	if c.godoClient != nil {
-		return c.godoClient
+	return c.godoClient(trace, viper.GetBool(ArgNoCache))
+}
+
+// GetUncachedGodoClient returns a GodoClient which never uses the response
+// cache.
+func (c *LiveConfig) GetUncachedGodoClient(trace bool) *godo.Client {
+	return c.godoClient(trace, true)
+}
+
+func (c *LiveConfig) godoClient(trace, noCache bool) *godo.Client {
+	opts := clientOptions{
+		token:           viper.GetString("access-token"),
+		trace:           trace,
+		traceFile:       viper.GetString(ArgTraceFile),
+		traceUnredacted: viper.GetBool(ArgTraceUnredacted),
+		noCache:         noCache,
 	}
 
-	token := viper.GetString("access-token")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if client, ok := c.godoClients[opts]; ok {
+		return client
+	}
+
+	token, err := c.accessToken()
+	if err != nil {
+		log.Fatalf("unable to get access token: %v", err)
+	}
 	tokenSource := &TokenSource{AccessToken: token}
 	oauthClient := oauth2.NewClient(oauth2.NoContext, tokenSource)
 
-	if trace {
-		r := newRecorder(oauthClient.Transport)
-
-		go func() {
-			for {
-				select {
-				case msg := <-r.req:
-					log.Println("->", strconv.Quote(msg))
-				case msg := <-r.resp:
-					log.Println("<-", strconv.Quote(msg))
-				}
-			}
-		}()
-
-		oauthClient.Transport = r
+	// Tracing and cassettes wrap the transport below the one adding the
+	// access token, so they see the requests as they are sent.
+	if t, ok := oauthClient.Transport.(*oauth2.Transport); ok {
+		redact := !opts.traceUnredacted
+		base := t.Base
+		if base == nil {
+			base = http.DefaultTransport
+		}
+
+		ct, err := newCassetteTransport(base)
+		if err != nil {
+			log.Fatalf("unable to use cassette: %v", err)
+		}
+		if ct != nil {
+			base = ct
+		}
+
+		if trace {
+			base = newRecorder(base, os.Stderr, redact)
+		}
+
+		if path := opts.traceFile; path != "" {
+			hr := har.NewRecorder(base, path, har.Creator{Name: "doctl", Version: DoitVersion.String()})
+			hr.Redact = redact
+			base = hr
+		}
+
+		t.Base = base
+	}
+
+	// Cached responses would hide requests from cassettes.
+	cassette := os.Getenv(EnvRecord) != "" || os.Getenv(EnvReplay) != ""
+
+	if !noCache && !cassette {
+		if dir, err := cache.DefaultDir(); err == nil {
+			rc := cache.New(filepath.Join(dir, cache.AccountKey(token)))
+			oauthClient.Transport = rc.Transport(oauthClient.Transport)
+		}
+	}
+
+	oauthClient.Transport = &errorBodyTransport{wrap: oauthClient.Transport}
+
+	client := godo.NewClient(oauthClient)
+	if c.godoClients == nil {
+		c.godoClients = map[clientOptions]*godo.Client{}
+	}
+	c.godoClients[opts] = client
+
+	return client
+}
+
+// Uncached wraps config so the API clients it returns never use the response
+// cache.
+func Uncached(config Config) Config {
+	return &uncachedConfig{Config: config}
+}
+
+type uncachedConfig struct {
+	Config
+}
+
+func (c *uncachedConfig) GetGodoClient(trace bool) *godo.Client {
+	if lc, ok := c.Config.(*LiveConfig); ok {
+		return lc.GetUncachedGodoClient(trace)
 	}
 
-	c.godoClient = godo.NewClient(oauthClient)
-	return c.godoClient
+	return c.Config.GetGodoClient(trace)
+}
+
+// CredentialStore returns the configured credential store.
+func (c *LiveConfig) CredentialStore() (credentials.Store, error) {
+	if c.credentialStore != nil {
+		return c.credentialStore, nil
+	}
+
+	store, err := NewCredentialStore()
+	if err != nil {
+		return nil, err
+	}
+
+	c.credentialStore = store
+	return store, nil
+}
+
+// accessToken returns the token API requests are made with. A token given
+// with --access-token, DIGITALOCEAN_ACCESS_TOKEN or in the configuration
+// file is used as is, otherwise it comes from the credential store.
+func (c *LiveConfig) accessToken() (string, error) {
+	if token := viper.GetString("access-token"); token != "" {
+		return token, nil
+	}
+
+	store, err := c.CredentialStore()
+	if err != nil {
+		return "", err
+	}
+
+	token, err := store.Get(AccessTokenKey)
+	if err == credentials.ErrNotFound {
+		return "", nil
+	}
+	return token, err
 }
 
 // SSH creates a ssh connection to a host.
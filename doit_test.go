@@ -16,6 +16,9 @@ package doit
 import (
 	"os"
 	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestMain(m *testing.M) {
@@ -79,3 +82,24 @@ type stubLatestRelease struct {
 func (slr stubLatestRelease) LatestVersion() (string, error) {
 	return slr.version, nil
 }
+
+func TestLiveConfigGodoClients(t *testing.T) {
+	viper.Set("access-token", "token")
+	defer viper.Set("access-token", "")
+
+	c := &LiveConfig{}
+
+	client := c.GetGodoClient(false)
+	assert.True(t, client == c.GetGodoClient(false))
+
+	traced := c.GetGodoClient(true)
+	assert.False(t, traced == client)
+	assert.True(t, traced == c.GetGodoClient(true))
+
+	uncached := Uncached(c).GetGodoClient(false)
+	assert.False(t, uncached == client)
+	assert.True(t, uncached == c.GetUncachedGodoClient(false))
+
+	viper.Set("access-token", "other")
+	assert.False(t, client == c.GetGodoClient(false))
+}
@@ -13,14 +13,61 @@ limitations under the License.
 
 package doit
 
-import "fmt"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/digitalocean/godo"
+)
+
+// Exit codes used by doctl. Scripts can rely on these to tell failures
+// apart.
+const (
+	// ExitError is the exit code for errors without a more specific code.
+	ExitError = 1
+	// ExitMissingArgs is the exit code when a command is missing arguments.
+	ExitMissingArgs = 2
+	// ExitNotFound is the exit code when a resource doesn't exist.
+	ExitNotFound = 3
+	// ExitUnauthorized is the exit code when the access token is invalid or
+	// lacks permission.
+	ExitUnauthorized = 4
+	// ExitRateLimited is the exit code when the API rate limit is exceeded.
+	ExitRateLimited = 5
+	// ExitValidation is the exit code when the API rejects a request as
+	// invalid.
+	ExitValidation = 6
+	// ExitServerError is the exit code when the API fails to handle a
+	// request.
+	ExitServerError = 7
+	// ExitVetoed is the exit code when a pre-command hook vetoes a command.
+	ExitVetoed = 8
+)
+
+// ExitCoder is an error with its own exit code.
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
+// ExitCode returns the exit code for err.
+func ExitCode(err error) int {
+	if ec, ok := err.(ExitCoder); ok {
+		return ec.ExitCode()
+	}
+
+	return ExitError
+}
 
 // MissingArgsErr is an error returned when their are too few arguments for a command.
 type MissingArgsErr struct {
 	Command string
 }
 
-var _ error = &MissingArgsErr{}
+var _ ExitCoder = &MissingArgsErr{}
 
 // NewMissingArgsErr creates a MissingArgsErr instance.
 func NewMissingArgsErr(cmd string) *MissingArgsErr {
@@ -30,3 +77,147 @@ func NewMissingArgsErr(cmd string) *MissingArgsErr {
 func (e *MissingArgsErr) Error() string {
 	return fmt.Sprintf("(%s) command is missing required arguments", e.Command)
 }
+
+// ExitCode returns ExitMissingArgs.
+func (e *MissingArgsErr) ExitCode() int {
+	return ExitMissingArgs
+}
+
+// APIError is an error returned by the DigitalOcean API.
+type APIError struct {
+	Status    int    `json:"status"`
+	ID        string `json:"id,omitempty"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+	Method    string `json:"-"`
+	URL       string `json:"-"`
+}
+
+var _ ExitCoder = &APIError{}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.Status, e.Message)
+	if e.RequestID != "" {
+		msg = fmt.Sprintf("%s (request %s)", msg, e.RequestID)
+	}
+
+	return msg
+}
+
+// ExitCode returns ExitError.
+func (e *APIError) ExitCode() int {
+	return ExitError
+}
+
+// API returns the error itself, so the typed errors embedding an APIError
+// expose it too.
+func (e *APIError) API() *APIError {
+	return e
+}
+
+// NotFoundErr is returned when a resource doesn't exist.
+type NotFoundErr struct{ *APIError }
+
+// ExitCode returns ExitNotFound.
+func (e *NotFoundErr) ExitCode() int { return ExitNotFound }
+
+// UnauthorizedErr is returned when the access token is invalid or lacks
+// permission.
+type UnauthorizedErr struct{ *APIError }
+
+// ExitCode returns ExitUnauthorized.
+func (e *UnauthorizedErr) ExitCode() int { return ExitUnauthorized }
+
+// RateLimitErr is returned when the API rate limit is exceeded.
+type RateLimitErr struct{ *APIError }
+
+// ExitCode returns ExitRateLimited.
+func (e *RateLimitErr) ExitCode() int { return ExitRateLimited }
+
+// ValidationErr is returned when the API rejects a request as invalid.
+type ValidationErr struct{ *APIError }
+
+// ExitCode returns ExitValidation.
+func (e *ValidationErr) ExitCode() int { return ExitValidation }
+
+// ServerErr is returned when the API fails to handle a request.
+type ServerErr struct{ *APIError }
+
+// ExitCode returns ExitServerError.
+func (e *ServerErr) ExitCode() int { return ExitServerError }
+
+// NewAPIError converts a godo.ErrorResponse into a typed doctl error. Other
+// errors are returned as is.
+func NewAPIError(err error) error {
+	er, ok := err.(*godo.ErrorResponse)
+	if !ok || er.Response == nil {
+		return err
+	}
+
+	ae := &APIError{
+		Status:    er.Response.StatusCode,
+		Message:   er.Message,
+		RequestID: er.Response.Header.Get("X-Request-Id"),
+	}
+
+	if req := er.Response.Request; req != nil {
+		ae.Method = req.Method
+		ae.URL = req.URL.String()
+	}
+
+	if body, ok := er.Response.Body.(*errorBody); ok {
+		var details struct {
+			ID        string `json:"id"`
+			RequestID string `json:"request_id"`
+		}
+		if json.Unmarshal(body.buf.Bytes(), &details) == nil {
+			ae.ID = details.ID
+			if details.RequestID != "" {
+				ae.RequestID = details.RequestID
+			}
+		}
+	}
+
+	switch s := ae.Status; {
+	case s == http.StatusNotFound:
+		return &NotFoundErr{ae}
+	case s == http.StatusUnauthorized || s == http.StatusForbidden:
+		return &UnauthorizedErr{ae}
+	case s == 429:
+		return &RateLimitErr{ae}
+	case s == http.StatusBadRequest || s == 422:
+		return &ValidationErr{ae}
+	case s >= 500:
+		return &ServerErr{ae}
+	default:
+		return ae
+	}
+}
+
+// errorBody keeps a copy of an API error response body as godo reads it, so
+// the error id and request id can be recovered afterwards.
+type errorBody struct {
+	io.ReadCloser
+	buf bytes.Buffer
+}
+
+func (b *errorBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	return n, err
+}
+
+// errorBodyTransport wraps the bodies of unsuccessful responses in an
+// errorBody.
+type errorBodyTransport struct {
+	wrap http.RoundTripper
+}
+
+func (t *errorBodyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.wrap.RoundTrip(req)
+	if err == nil && resp.StatusCode >= 300 && resp.Body != nil {
+		resp.Body = &errorBody{ReadCloser: resp.Body}
+	}
+
+	return resp, err
+}
@@ -14,8 +14,14 @@ limitations under the License.
 package doit
 
 import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/digitalocean/godo"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -23,3 +29,67 @@ func TestMissingArgsErr(t *testing.T) {
 	err := NewMissingArgsErr("test-cmd")
 	assert.Equal(t, "(test-cmd) command is missing required arguments", err.Error())
 }
+
+func TestMissingArgsErr_ExitCode(t *testing.T) {
+	assert.Equal(t, ExitMissingArgs, ExitCode(NewMissingArgsErr("test-cmd")))
+	assert.Equal(t, ExitError, ExitCode(errors.New("an error")))
+}
+
+func TestNewAPIError(t *testing.T) {
+	cases := []struct {
+		status int
+		code   int
+	}{
+		{status: 400, code: ExitValidation},
+		{status: 401, code: ExitUnauthorized},
+		{status: 403, code: ExitUnauthorized},
+		{status: 404, code: ExitNotFound},
+		{status: 409, code: ExitError},
+		{status: 422, code: ExitValidation},
+		{status: 429, code: ExitRateLimited},
+		{status: 503, code: ExitServerError},
+	}
+
+	for _, c := range cases {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.status)
+			w.Write([]byte(`{"id":"an_error","message":"it failed","request_id":"abc-123"}`))
+		}))
+
+		client := &http.Client{Transport: &errorBodyTransport{wrap: http.DefaultTransport}}
+		resp, err := client.Get(ts.URL + "/v2/droplets/1")
+		assert.NoError(t, err)
+
+		err = godo.CheckResponse(resp)
+		resp.Body.Close()
+		ts.Close()
+
+		err = NewAPIError(err)
+		assert.Equal(t, c.code, ExitCode(err), "status %d", c.status)
+
+		ae := err.(interface {
+			API() *APIError
+		}).API()
+		assert.Equal(t, c.status, ae.Status)
+		assert.Equal(t, "an_error", ae.ID)
+		assert.Equal(t, "it failed", ae.Message)
+		assert.Equal(t, "abc-123", ae.RequestID)
+		assert.Equal(t, "GET", ae.Method)
+		assert.True(t, strings.HasSuffix(err.Error(), "it failed (request abc-123)"), err.Error())
+	}
+}
+
+func TestNewAPIError_OtherErrors(t *testing.T) {
+	err := errors.New("an error")
+	assert.Equal(t, err, NewAPIError(err))
+
+	resp := &http.Response{
+		StatusCode: 404,
+		Header:     http.Header{"X-Request-Id": []string{"def-456"}},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}
+	err = NewAPIError(&godo.ErrorResponse{Response: resp, Message: "not found"})
+	nf, ok := err.(*NotFoundErr)
+	assert.True(t, ok)
+	assert.Equal(t, "def-456", nf.RequestID)
+}
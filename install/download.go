@@ -75,6 +75,10 @@ func Validate(f, cs io.Reader) error {
 		words = append(words, scanner.Text())
 	}
 
+	if len(words) == 0 {
+		return fmt.Errorf("checksum is empty")
+	}
+
 	if sum, wantedSum := hex.EncodeToString(h.Sum(nil)), words[0]; sum != wantedSum {
 		return fmt.Errorf("invalid checksum: %s != %s", sum, wantedSum)
 	}
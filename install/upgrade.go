@@ -0,0 +1,505 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/digitalocean/doctl/pkg/release"
+	"golang.org/x/crypto/openpgp"
+)
+
+const (
+	// ChannelStable only upgrades to full releases.
+	ChannelStable = "stable"
+
+	// ChannelBeta upgrades to pre-releases as well as full releases.
+	ChannelBeta = "beta"
+)
+
+var (
+	// ReleaseKey is the base64 encoded, armored OpenPGP public key which
+	// release checksums files are signed with. It is set by scripts/stage.sh.
+	ReleaseKey = ""
+
+	releasesURL = "https://api.github.com/repos/digitalocean/doctl/releases"
+	downloadURL = "https://github.com/digitalocean/doctl/releases/download"
+)
+
+// Upgrader replaces a doctl binary with a verified release.
+type Upgrader struct {
+	// ReleasesURL lists releases, in the format of the GitHub releases API.
+	// It may also be a local file, given as a path or file:// URL, holding
+	// such a list, a single release or just a version.
+	ReleasesURL string
+
+	// DownloadURL is the base URL of release files which aren't listed as
+	// assets of their release. They are found at
+	// DownloadURL/v<version>/<file>. If it is empty, release files must be
+	// listed as assets.
+	DownloadURL string
+
+	// Keyring holds the keys release checksums may be signed with.
+	Keyring openpgp.EntityList
+
+	GOOS, GOARCH string
+	Client       *http.Client
+}
+
+// NewUpgrader creates an Upgrader trusting ReleaseKey. Releases are looked up
+// at updateURL, the update-url setting, or in doctl's GitHub releases if it
+// is empty.
+func NewUpgrader(updateURL string) (*Upgrader, error) {
+	if ReleaseKey == "" {
+		return nil, errors.New("this build of doctl has no release signing key, so upgrades can't be verified")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(ReleaseKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid release signing key: %v", err)
+	}
+
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(key))
+	if err != nil {
+		return nil, fmt.Errorf("invalid release signing key: %v", err)
+	}
+
+	u := &Upgrader{
+		ReleasesURL: releasesURL,
+		DownloadURL: downloadURL,
+		Keyring:     keyring,
+		GOOS:        runtime.GOOS,
+		GOARCH:      runtime.GOARCH,
+		Client:      http.DefaultClient,
+	}
+
+	if updateURL != "" {
+		u.ReleasesURL = releasesSource(updateURL)
+		u.DownloadURL = ""
+	}
+
+	return u, nil
+}
+
+// releasesSource returns where releases are listed for an update-url, which
+// names the latest release. The GitHub latest release endpoint is replaced by
+// the list of releases, other sources are used as is.
+func releasesSource(updateURL string) string {
+	if u, err := url.Parse(updateURL); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return strings.TrimSuffix(strings.TrimSuffix(updateURL, "/"), "/latest")
+	}
+
+	return updateURL
+}
+
+// githubRelease is a release in the format of the GitHub releases API.
+type githubRelease struct {
+	TagName    string `json:"tag_name"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+	Assets     []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// releases reads the releases at ReleasesURL, which may be a list of
+// releases, a single release or just a version.
+func (u *Upgrader) releases() ([]githubRelease, error) {
+	b, err := u.read(u.ReleasesURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []githubRelease
+	if err := json.Unmarshal(b, &list); err == nil {
+		return list, nil
+	}
+
+	var r githubRelease
+	if err := json.Unmarshal(b, &r); err == nil && r.TagName != "" {
+		return []githubRelease{r}, nil
+	}
+
+	if v := strings.TrimSpace(string(b)); v != "" && !strings.ContainsAny(v, "{[ \n") {
+		return []githubRelease{{TagName: v}}, nil
+	}
+
+	return nil, fmt.Errorf("unable to read releases from %s", u.ReleasesURL)
+}
+
+// Latest returns the newest release version in a channel.
+func (u *Upgrader) Latest(channel string) (string, error) {
+	if channel != ChannelStable && channel != ChannelBeta {
+		return "", fmt.Errorf("unknown channel %q, expected %s or %s", channel, ChannelStable, ChannelBeta)
+	}
+
+	releases, err := u.releases()
+	if err != nil {
+		return "", err
+	}
+
+	var latest *semver.Version
+	for _, r := range releases {
+		if r.Draft || (r.Prerelease && channel != ChannelBeta) {
+			continue
+		}
+
+		v, err := semver.Make(strings.TrimPrefix(r.TagName, "v"))
+		if err != nil {
+			continue
+		}
+
+		if latest == nil || v.GT(*latest) {
+			latest = &v
+		}
+	}
+
+	if latest == nil {
+		return "", fmt.Errorf("no %s releases found", channel)
+	}
+
+	return latest.String(), nil
+}
+
+// ReleaseName is the name of a release build, as created by scripts/stage.sh,
+// without an extension.
+func ReleaseName(version, goos, goarch string) string {
+	platform := goos
+	switch goos {
+	case "darwin":
+		platform = "darwin-10.6"
+	case "windows":
+		platform = "windows-4.0"
+	}
+
+	return fmt.Sprintf("doctl-%s-%s-%s", version, platform, goarch)
+}
+
+// Upgrade replaces the binary at binPath with a release. The signature of the
+// release's checksums file, written by release.Prepare, is checked, and the
+// archive is checked against its checksum, before the binary is moved into
+// place. If the new binary can't be run, the old one
+// is restored.
+func (u *Upgrader) Upgrade(version, binPath string) error {
+	name := ReleaseName(version, u.GOOS, u.GOARCH)
+	archive := name + ".tar.gz"
+	bin := "doctl"
+	if u.GOOS == "windows" {
+		archive = name + ".zip"
+		bin = "doctl.exe"
+	}
+
+	assets, err := u.assets(version)
+	if err != nil {
+		return err
+	}
+
+	fetch := func(file string) ([]byte, error) {
+		if source, ok := assets[file]; ok {
+			return u.read(source)
+		}
+		if u.DownloadURL == "" {
+			return nil, fmt.Errorf("release %s has no file %s", version, file)
+		}
+		return u.read(strings.TrimSuffix(u.DownloadURL, "/") + "/v" + version + "/" + file)
+	}
+
+	sumsName := release.ChecksumsName(version)
+	sums, err := fetch(sumsName)
+	if err != nil {
+		return err
+	}
+
+	sig, err := fetch(sumsName + ".asc")
+	if err != nil {
+		return err
+	}
+
+	if _, err := openpgp.CheckArmoredDetachedSignature(u.Keyring, bytes.NewReader(sums), bytes.NewReader(sig)); err != nil {
+		return fmt.Errorf("invalid signature for %s: %v", sumsName, err)
+	}
+
+	a, err := fetch(archive)
+	if err != nil {
+		return err
+	}
+
+	if err := checkListedSum(sums, sumsName, archive, a); err != nil {
+		return err
+	}
+
+	b, err := extractBinary(archive, a, bin)
+	if err != nil {
+		return err
+	}
+
+	return replace(binPath, b)
+}
+
+// checkListedSum checks b, the contents of the file name, against its
+// checksum in sums, a checksums file in the format of sha256sum.
+func checkListedSum(sums []byte, sumsName, name string, b []byte) error {
+	for _, line := range strings.Split(string(sums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+
+		sum := sha256.Sum256(b)
+		if got := hex.EncodeToString(sum[:]); got != fields[0] {
+			return fmt.Errorf("invalid checksum for %s: %s != %s", name, got, fields[0])
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("%s isn't listed in %s", name, sumsName)
+}
+
+// assets returns where the files of a release are found, by name. Relative
+// locations are resolved against ReleasesURL. When files can also be found
+// at DownloadURL, releases which can't be listed have no assets.
+func (u *Upgrader) assets(version string) (map[string]string, error) {
+	releases, err := u.releases()
+	if err != nil {
+		if u.DownloadURL != "" {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	assets := map[string]string{}
+	for _, r := range releases {
+		if strings.TrimPrefix(r.TagName, "v") != version {
+			continue
+		}
+
+		for _, a := range r.Assets {
+			if a.Name != "" && a.URL != "" {
+				assets[a.Name] = resolveSource(u.ReleasesURL, a.URL)
+			}
+		}
+	}
+
+	return assets, nil
+}
+
+// resolveSource resolves ref, a URL or path, relative to base.
+func resolveSource(base, ref string) string {
+	if filepath.IsAbs(ref) {
+		return ref
+	}
+
+	r, err := url.Parse(ref)
+	if err != nil || r.IsAbs() {
+		return ref
+	}
+
+	if b, err := url.Parse(base); err == nil && (b.Scheme == "http" || b.Scheme == "https") {
+		return b.ResolveReference(r).String()
+	}
+
+	return filepath.Join(filepath.Dir(localPath(base)), filepath.FromSlash(ref))
+}
+
+// localPath returns the path of a local source, given as a path or file://
+// URL.
+func localPath(source string) string {
+	if u, err := url.Parse(source); err == nil && u.Scheme == "file" {
+		return filepath.FromSlash(u.Path)
+	}
+
+	return source
+}
+
+// read returns the contents of source, an http(s) URL or a local file.
+func (u *Upgrader) read(source string) ([]byte, error) {
+	if s, err := url.Parse(source); err != nil || (s.Scheme != "http" && s.Scheme != "https") {
+		return ioutil.ReadFile(localPath(source))
+	}
+
+	resp, err := u.Client.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to download %s: %s", path.Base(resp.Request.URL.Path), resp.Status)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// extractBinary returns the contents of bin from a .tar.gz or .zip archive.
+func extractBinary(archive string, b []byte, bin string) ([]byte, error) {
+	if strings.HasSuffix(archive, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range zr.File {
+			if path.Base(f.Name) != bin {
+				continue
+			}
+
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+
+			return ioutil.ReadAll(rc)
+		}
+
+		return nil, fmt.Errorf("%s not found in %s", bin, archive)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s not found in %s", bin, archive)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Typeflag == tar.TypeReg && path.Base(hdr.Name) == bin {
+			return ioutil.ReadAll(tr)
+		}
+	}
+}
+
+// replace atomically swaps the binary at binPath for b, keeping the old binary
+// until the new one has been run successfully.
+func replace(binPath string, b []byte) error {
+	dir := filepath.Dir(binPath)
+
+	f, err := ioutil.TempFile(dir, ".doctl-upgrade-")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, 0755); err != nil {
+		return err
+	}
+
+	// The old binary is kept alongside rather than moved away, so there is
+	// always a binary at binPath.
+	old := binPath + ".old"
+	os.Remove(old)
+	if err := backupBinary(binPath, old); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, binPath); err != nil {
+		// A running binary can't be replaced on Windows, but it can be moved.
+		if runtime.GOOS != "windows" {
+			os.Remove(old)
+			return err
+		}
+		if err := os.Rename(binPath, old); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, binPath); err != nil {
+			if rerr := os.Rename(old, binPath); rerr != nil {
+				return fmt.Errorf("unable to install %s: %v, and unable to restore %s: %v", binPath, err, old, rerr)
+			}
+			return err
+		}
+	}
+
+	if out, err := exec.Command(binPath, "version").CombinedOutput(); err != nil {
+		if rerr := os.Rename(old, binPath); rerr != nil {
+			return fmt.Errorf("new binary failed to run: %v, and unable to restore %s: %v", err, old, rerr)
+		}
+		return fmt.Errorf("new binary failed to run, restored previous version: %v: %s", err, bytes.TrimSpace(out))
+	}
+
+	// A running binary can't be removed on Windows, so the old binary may
+	// be left behind.
+	os.Remove(old)
+
+	return nil
+}
+
+// backupBinary links the binary at binPath to old, or copies it where links
+// aren't supported.
+func backupBinary(binPath, old string) error {
+	if err := os.Link(binPath, old); err == nil {
+		return nil
+	}
+
+	fi, err := os.Stat(binPath)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(binPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(old, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fi.Mode())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(old)
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/doctl/pkg/release"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+)
+
+type testRelease struct {
+	files map[string][]byte
+}
+
+func newTestRelease(t *testing.T, entity *openpgp.Entity, version, script string) *testRelease {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "doctl", Mode: 0755, Size: int64(len(script)), Typeflag: tar.TypeReg})
+	tw.Write([]byte(script))
+	tw.Close()
+	gz.Close()
+
+	dir, err := ioutil.TempDir("", "release")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	name := ReleaseName(version, "linux", "amd64")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+".tar.gz"), archive.Bytes(), 0644))
+
+	r, err := release.Prepare(version, dir, entity)
+	assert.NoError(t, err)
+
+	tr := &testRelease{files: map[string][]byte{}}
+	for _, f := range r.Files {
+		b, err := ioutil.ReadFile(f)
+		assert.NoError(t, err)
+		tr.files["/v"+version+"/"+filepath.Base(f)] = b
+	}
+
+	return tr
+}
+
+func (tr *testRelease) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/releases" {
+		fmt.Fprint(w, `[{"tag_name":"v1.1.0-beta","prerelease":true},{"tag_name":"v1.0.0"},{"tag_name":"v0.9.0"}]`)
+		return
+	}
+
+	b, ok := tr.files[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write(b)
+}
+
+func testUpgrader(t *testing.T, tr *testRelease, keyring openpgp.EntityList) (*Upgrader, string, func()) {
+	ts := httptest.NewServer(tr)
+
+	dir, err := ioutil.TempDir("", "upgrade")
+	assert.NoError(t, err)
+
+	bin := filepath.Join(dir, "doctl")
+	assert.NoError(t, ioutil.WriteFile(bin, []byte("#!/bin/sh\necho old\n"), 0755))
+
+	u := &Upgrader{
+		ReleasesURL: ts.URL + "/releases",
+		DownloadURL: ts.URL,
+		Keyring:     keyring,
+		GOOS:        "linux",
+		GOARCH:      "amd64",
+		Client:      http.DefaultClient,
+	}
+
+	return u, bin, func() {
+		ts.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestUpgraderLatest(t *testing.T) {
+	u, _, cleanup := testUpgrader(t, &testRelease{}, nil)
+	defer cleanup()
+
+	v, err := u.Latest(ChannelStable)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0.0", v)
+
+	v, err = u.Latest(ChannelBeta)
+	assert.NoError(t, err)
+	assert.Equal(t, "1.1.0-beta", v)
+
+	_, err = u.Latest("nightly")
+	assert.Error(t, err)
+}
+
+func TestUpgrade(t *testing.T) {
+	if os.PathSeparator != '/' {
+		t.Skip("test binaries are shell scripts")
+	}
+
+	entity, err := openpgp.NewEntity("doctl", "test", "doctl@example.com", nil)
+	assert.NoError(t, err)
+	other, err := openpgp.NewEntity("other", "test", "other@example.com", nil)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		script  string
+		keyring openpgp.EntityList
+		err     bool
+		want    string
+	}{
+		{name: "upgraded", script: "#!/bin/sh\necho new\n", keyring: openpgp.EntityList{entity}, want: "new"},
+		{name: "untrusted signature", script: "#!/bin/sh\necho new\n", keyring: openpgp.EntityList{other}, err: true, want: "old"},
+		{name: "rolled back", script: "#!/bin/sh\nexit 1\n", keyring: openpgp.EntityList{entity}, err: true, want: "old"},
+	}
+
+	for _, c := range cases {
+		tr := newTestRelease(t, entity, "1.0.0", c.script)
+		u, bin, cleanup := testUpgrader(t, tr, c.keyring)
+
+		err := u.Upgrade("1.0.0", bin)
+		if c.err {
+			assert.Error(t, err, c.name)
+		} else {
+			assert.NoError(t, err, c.name)
+		}
+
+		out, err := exec.Command(bin).Output()
+		assert.NoError(t, err, c.name)
+		assert.Equal(t, c.want+"\n", string(out), c.name)
+
+		_, err = os.Stat(bin + ".old")
+		assert.True(t, os.IsNotExist(err), c.name)
+
+		cleanup()
+	}
+}
+
+func TestUpgradeBadChecksum(t *testing.T) {
+	entity, err := openpgp.NewEntity("doctl", "test", "doctl@example.com", nil)
+	assert.NoError(t, err)
+
+	tr := newTestRelease(t, entity, "1.0.0", "#!/bin/sh\necho new\n")
+	other := newTestRelease(t, entity, "1.0.0", "#!/bin/sh\necho tampered\n")
+	name := "/v1.0.0/" + ReleaseName("1.0.0", "linux", "amd64") + ".tar.gz"
+	tr.files[name] = other.files[name]
+
+	u, bin, cleanup := testUpgrader(t, tr, openpgp.EntityList{entity})
+	defer cleanup()
+
+	err = u.Upgrade("1.0.0", bin)
+	assert.Error(t, err)
+
+	b, err := ioutil.ReadFile(bin)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "old")
+}
+
+func TestReleaseName(t *testing.T) {
+	assert.Equal(t, "doctl-1.0.0-linux-amd64", ReleaseName("1.0.0", "linux", "amd64"))
+	assert.Equal(t, "doctl-1.0.0-darwin-10.6-amd64", ReleaseName("1.0.0", "darwin", "amd64"))
+	assert.Equal(t, "doctl-1.0.0-windows-4.0-386", ReleaseName("1.0.0", "windows", "386"))
+}
+
+func TestReleasesSource(t *testing.T) {
+	assert.Equal(t, "https://api.github.com/repos/digitalocean/doctl/releases",
+		releasesSource("https://api.github.com/repos/digitalocean/doctl/releases/latest"))
+	assert.Equal(t, "https://mirror.example.com/doctl/releases.json",
+		releasesSource("https://mirror.example.com/doctl/releases.json"))
+	assert.Equal(t, "/srv/doctl/latest.json", releasesSource("/srv/doctl/latest.json"))
+}
+
+func TestUpgradeFromMirror(t *testing.T) {
+	if os.PathSeparator != '/' {
+		t.Skip("test binaries are shell scripts")
+	}
+
+	entity, err := openpgp.NewEntity("doctl", "test", "doctl@example.com", nil)
+	assert.NoError(t, err)
+
+	tr := newTestRelease(t, entity, "1.0.0", "#!/bin/sh\necho new\n")
+
+	dir, err := ioutil.TempDir("", "upgrade-mirror")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var assets []string
+	for file, b := range tr.files {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, filepath.Base(file)), b, 0644))
+		assets = append(assets, fmt.Sprintf(`{"name":%q,"browser_download_url":%q}`, filepath.Base(file), filepath.Base(file)))
+	}
+	latest := fmt.Sprintf(`{"tag_name":"v1.0.0","assets":[%s]}`, strings.Join(assets, ","))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "latest.json"), []byte(latest), 0644))
+
+	for _, source := range []string{"local", "http"} {
+		u, bin, cleanup := testUpgrader(t, &testRelease{}, openpgp.EntityList{entity})
+		u.DownloadURL = ""
+		u.ReleasesURL = filepath.Join(dir, "latest.json")
+		if source == "http" {
+			ts := httptest.NewServer(http.FileServer(http.Dir(dir)))
+			defer ts.Close()
+			u.ReleasesURL = releasesSource(ts.URL + "/latest.json")
+		}
+
+		v, err := u.Latest(ChannelStable)
+		assert.NoError(t, err, source)
+		assert.Equal(t, "1.0.0", v, source)
+
+		assert.NoError(t, u.Upgrade("1.0.0", bin), source)
+
+		out, err := exec.Command(bin).Output()
+		assert.NoError(t, err, source)
+		assert.Equal(t, "new\n", string(out), source)
+
+		err = u.Upgrade("2.0.0", bin)
+		assert.EqualError(t, err, "release 2.0.0 has no file "+release.ChecksumsName("2.0.0"), source)
+
+		cleanup()
+	}
+}
+
+func TestCheckListedSum(t *testing.T) {
+	sums := []byte("2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824  hello.tar.gz\n")
+
+	assert.NoError(t, checkListedSum(sums, "sums", "hello.tar.gz", []byte("hello")))
+	assert.Error(t, checkListedSum(sums, "sums", "hello.tar.gz", []byte("tampered")))
+	assert.EqualError(t, checkListedSum(sums, "sums", "other.zip", []byte("hello")), "other.zip isn't listed in sums")
+}
+
+func TestBackupBinary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doctl-upgrade")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	bin := filepath.Join(dir, "doctl")
+	assert.NoError(t, ioutil.WriteFile(bin, []byte("old"), 0755))
+
+	assert.NoError(t, backupBinary(bin, bin+".old"))
+
+	for _, path := range []string{bin, bin + ".old"} {
+		b, err := ioutil.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, "old", string(b))
+	}
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cache implements an on-disk cache of API responses for resources
+// which rarely change.
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DefaultTTLs are how long responses are cached, keyed by API path prefix.
+// Paths without a TTL are never cached. Resources which change often are
+// only cached briefly, enough to resolve names and complete arguments
+// without listing them on every command.
+var DefaultTTLs = map[string]time.Duration{
+	"/v2/regions":      24 * time.Hour,
+	"/v2/sizes":        24 * time.Hour,
+	"/v2/images":       time.Hour,
+	"/v2/droplets":     time.Minute,
+	"/v2/account/keys": time.Minute,
+	"/v2/domains":      time.Minute,
+	"/v2/floating_ips": time.Minute,
+}
+
+// DefaultDir returns the directory doctl caches responses in.
+func DefaultDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "doctl"), nil
+	}
+
+	usr, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(usr.HomeDir, "Library", "Caches", "doctl"), nil
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return filepath.Join(dir, "doctl", "cache"), nil
+		}
+	}
+
+	return filepath.Join(usr.HomeDir, ".cache", "doctl"), nil
+}
+
+// AccountKey derives the name of an account's cache directory from its
+// access token, without storing the token itself.
+func AccountKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Cache stores API responses as files in a directory.
+type Cache struct {
+	Dir  string
+	TTLs map[string]time.Duration
+
+	now func() time.Time
+}
+
+// New creates a Cache storing responses in dir with the default TTLs.
+func New(dir string) *Cache {
+	return &Cache{
+		Dir:  dir,
+		TTLs: DefaultTTLs,
+		now:  time.Now,
+	}
+}
+
+// entry is a cached response.
+type entry struct {
+	URL     string      `json:"url"`
+	Expires time.Time   `json:"expires"`
+	Status  int         `json:"status"`
+	Header  http.Header `json:"header"`
+	Body    []byte      `json:"body"`
+}
+
+// ttl returns how long responses for path are cached. Actions are polled
+// until they finish, so they are never cached.
+func (c *Cache) ttl(path string) time.Duration {
+	if strings.Contains(path+"/", "/actions/") {
+		return 0
+	}
+
+	var ttl time.Duration
+	var longest int
+	for prefix, d := range c.TTLs {
+		if (path == prefix || strings.HasPrefix(path, prefix+"/")) && len(prefix) > longest {
+			ttl, longest = d, len(prefix)
+		}
+	}
+
+	return ttl
+}
+
+func (c *Cache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *Cache) get(url string) (*entry, bool) {
+	b, err := ioutil.ReadFile(c.path(url))
+	if err != nil {
+		return nil, false
+	}
+
+	var e entry
+	if err := json.Unmarshal(b, &e); err != nil || e.URL != url || c.now().After(e.Expires) {
+		return nil, false
+	}
+
+	return &e, true
+}
+
+func (c *Cache) put(e *entry) error {
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(c.path(e.URL), b, 0600)
+}
+
+// Clear removes every cached response.
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.Dir)
+}
+
+// Transport returns a RoundTripper which answers GET requests from the cache
+// where possible, and clears the cache on any other request.
+func (c *Cache) Transport(wrap http.RoundTripper) http.RoundTripper {
+	return &transport{cache: c, wrap: wrap}
+}
+
+type transport struct {
+	cache *Cache
+	wrap  http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" {
+		resp, err := t.wrap.RoundTrip(req)
+		t.cache.Clear()
+		return resp, err
+	}
+
+	ttl := t.cache.ttl(req.URL.Path)
+	if ttl <= 0 {
+		return t.wrap.RoundTrip(req)
+	}
+
+	url := req.URL.String()
+	if e, ok := t.cache.get(url); ok {
+		return &http.Response{
+			Status:        http.StatusText(e.Status),
+			StatusCode:    e.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        e.Header,
+			Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+			ContentLength: int64(len(e.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	resp, err := t.wrap.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	t.cache.put(&entry{
+		URL:     url,
+		Expires: t.cache.now().Add(ttl),
+		Status:  resp.StatusCode,
+		Header:  resp.Header,
+		Body:    body,
+	})
+
+	return resp, nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingServer struct {
+	*httptest.Server
+	hits map[string]int
+}
+
+func newCountingServer() *countingServer {
+	cs := &countingServer{hits: map[string]int{}}
+	cs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cs.hits[r.Method+" "+r.URL.Path]++
+		if r.URL.Path == "/v2/images/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"hit":%d}`, cs.hits[r.Method+" "+r.URL.Path])
+	}))
+
+	return cs
+}
+
+func testCache(t *testing.T) (*Cache, *http.Client, func()) {
+	dir, err := ioutil.TempDir("", "doctl-cache")
+	assert.NoError(t, err)
+
+	c := New(dir)
+	client := &http.Client{Transport: c.Transport(http.DefaultTransport)}
+
+	return c, client, func() { os.RemoveAll(dir) }
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	resp, err := client.Get(url)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return string(b)
+}
+
+func TestTransport_CachesGets(t *testing.T) {
+	ts := newCountingServer()
+	defer ts.Close()
+
+	_, client, done := testCache(t)
+	defer done()
+
+	assert.Equal(t, `{"hit":1}`, get(t, client, ts.URL+"/v2/regions"))
+	assert.Equal(t, `{"hit":1}`, get(t, client, ts.URL+"/v2/regions"))
+	assert.Equal(t, 1, ts.hits["GET /v2/regions"])
+
+	// paths without a TTL aren't cached
+	get(t, client, ts.URL+"/v2/account")
+	get(t, client, ts.URL+"/v2/account")
+	assert.Equal(t, 2, ts.hits["GET /v2/account"])
+
+	// errors aren't cached
+	get(t, client, ts.URL+"/v2/images/missing")
+	get(t, client, ts.URL+"/v2/images/missing")
+	assert.Equal(t, 2, ts.hits["GET /v2/images/missing"])
+}
+
+func TestTransport_Expires(t *testing.T) {
+	ts := newCountingServer()
+	defer ts.Close()
+
+	c, client, done := testCache(t)
+	defer done()
+
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	get(t, client, ts.URL+"/v2/images")
+	now = now.Add(30 * time.Minute)
+	get(t, client, ts.URL+"/v2/images")
+	assert.Equal(t, 1, ts.hits["GET /v2/images"])
+
+	now = now.Add(time.Hour)
+	assert.Equal(t, `{"hit":2}`, get(t, client, ts.URL+"/v2/images"))
+}
+
+func TestTransport_InvalidatesOnMutation(t *testing.T) {
+	ts := newCountingServer()
+	defer ts.Close()
+
+	_, client, done := testCache(t)
+	defer done()
+
+	get(t, client, ts.URL+"/v2/images")
+
+	req, err := http.NewRequest("DELETE", ts.URL+"/v2/images/1", nil)
+	assert.NoError(t, err)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, `{"hit":2}`, get(t, client, ts.URL+"/v2/images"))
+}
+
+func TestTTL(t *testing.T) {
+	c := New("")
+
+	assert.Equal(t, 24*time.Hour, c.ttl("/v2/regions"))
+	assert.Equal(t, time.Hour, c.ttl("/v2/images/123"))
+	assert.Equal(t, time.Minute, c.ttl("/v2/account/keys"))
+	assert.Equal(t, time.Minute, c.ttl("/v2/droplets"))
+	assert.Equal(t, time.Minute, c.ttl("/v2/droplets/123/neighbors"))
+	assert.Equal(t, time.Minute, c.ttl("/v2/domains"))
+	assert.Equal(t, time.Minute, c.ttl("/v2/floating_ips"))
+	assert.Equal(t, time.Duration(0), c.ttl("/v2/account"))
+	assert.Equal(t, time.Duration(0), c.ttl("/v2/images-other"))
+	assert.Equal(t, time.Duration(0), c.ttl("/v2/droplets/123/actions"))
+	assert.Equal(t, time.Duration(0), c.ttl("/v2/droplets/123/actions/456"))
+	assert.Equal(t, time.Duration(0), c.ttl("/v2/images/123/actions/456"))
+	assert.Equal(t, time.Duration(0), c.ttl("/v2/floating_ips/1.2.3.4/actions/456"))
+}
+
+func TestAccountKey(t *testing.T) {
+	assert.Equal(t, AccountKey("token"), AccountKey("token"))
+	assert.NotEqual(t, AccountKey("token"), AccountKey("other"))
+	assert.Len(t, AccountKey("token"), 16)
+}
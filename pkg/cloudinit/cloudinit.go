@@ -0,0 +1,463 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cloudinit checks droplet user data before it is sent to the API.
+package cloudinit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// MaxUserDataSize is the largest amount of user data a droplet accepts.
+const MaxUserDataSize = 64 * 1024
+
+// Severity is how serious a problem is.
+type Severity string
+
+const (
+	// SeverityError is a problem that will stop user data from working.
+	SeverityError Severity = "error"
+	// SeverityWarning is a problem that might stop user data from working.
+	SeverityWarning Severity = "warning"
+)
+
+// Problem is an issue found in user data.
+type Problem struct {
+	Line     int      `json:"line,omitempty"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", p.Line, p.Severity, p.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", p.Severity, p.Message)
+}
+
+// ValidationError is returned when user data contains errors.
+type ValidationError struct {
+	Problems []Problem
+}
+
+var _ error = &ValidationError{}
+
+func (e *ValidationError) Error() string {
+	var msgs []string
+	for _, p := range e.Problems {
+		msgs = append(msgs, p.String())
+	}
+
+	return "invalid user data: " + strings.Join(msgs, "; ")
+}
+
+var (
+	yamlLineRE = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+	topKeyRE   = regexp.MustCompile(`^([^\s#:][^:#]*?)\s*:`)
+
+	// knownKeys are the top level keys understood by cloud-init modules and
+	// CoreOS cloud config.
+	knownKeys = map[string]bool{
+		"apt": true, "apt_get_command": true, "apt_mirror": true, "apt_mirror_search": true,
+		"apt_mirror_search_dns": true, "apt_pipelining": true, "apt_preserve_sources_list": true,
+		"apt_proxy": true, "apt_reboot_if_required": true, "apt_sources": true,
+		"apt_update": true, "apt_upgrade": true, "bootcmd": true, "byobu_by_default": true,
+		"ca-certs": true, "ca_certs": true, "chef": true, "chpasswd": true,
+		"cloud_config_modules": true, "cloud_final_modules": true, "cloud_init_modules": true,
+		"coreos": true, "datasource": true, "datasource_list": true, "debconf_selections": true,
+		"disable_ec2_metadata": true, "disable_root": true, "disable_root_opts": true,
+		"disk_setup": true, "final_message": true, "fqdn": true, "fs_setup": true,
+		"groups": true, "growpart": true, "hostname": true, "keyboard": true,
+		"landscape": true, "locale": true, "locale_configfile": true, "lxd": true,
+		"manage_etc_hosts": true, "manage_resolv_conf": true, "mcollective": true,
+		"merge_how": true, "merge_type": true, "mount_default_fields": true, "mounts": true,
+		"no_ssh_fingerprints": true, "ntp": true, "output": true, "package_reboot_if_required": true,
+		"package_update": true, "package_upgrade": true, "packages": true, "password": true,
+		"phone_home": true, "power_state": true, "preserve_hostname": true, "puppet": true,
+		"random_seed": true, "resize_rootfs": true, "resolv_conf": true, "rh_subscription": true,
+		"rsyslog": true, "runcmd": true, "salt_minion": true, "seed_random": true,
+		"snap": true, "snappy": true, "spacewalk": true, "ssh": true,
+		"ssh_authorized_keys": true, "ssh_deletekeys": true, "ssh_fp_console_blacklist": true,
+		"ssh_genkeytypes": true, "ssh_import_id": true, "ssh_key_console_blacklist": true,
+		"ssh_keys": true, "ssh_pwauth": true, "swap": true, "syslog_fix_perms": true,
+		"system_info": true, "timezone": true, "ubuntu_advantage": true, "user": true,
+		"users": true, "write_files": true, "yum_repos": true,
+	}
+
+	// listKeys are top level keys which must contain a list.
+	listKeys = map[string]bool{
+		"bootcmd": true, "packages": true, "runcmd": true, "ssh_authorized_keys": true,
+		"write_files": true,
+	}
+
+	// partTypes are the MIME types cloud-init handles in multipart user data.
+	partTypes = map[string]bool{
+		"text/cloud-boothook": true, "text/cloud-config": true, "text/cloud-config-archive": true,
+		"text/part-handler": true, "text/upstart-job": true, "text/x-include-once-url": true,
+		"text/x-include-url": true, "text/x-shellscript": true,
+	}
+
+	// headerTypes are the first line markers cloud-init accepts without
+	// further checking.
+	headerTypes = []string{
+		"#cloud-boothook", "#cloud-config-archive", "#include", "#include-once",
+		"#part-handler", "#upstart-job",
+	}
+)
+
+// Lint checks user data and returns the problems found in it.
+func Lint(data []byte) []Problem {
+	problems := []Problem{}
+
+	if len(data) > MaxUserDataSize {
+		problems = append(problems, Problem{
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("user data is %d bytes, the limit is %d bytes", len(data), MaxUserDataSize),
+		})
+	}
+
+	return append(problems, lintPart(data)...)
+}
+
+// Validate returns a ValidationError if user data contains errors. Warnings
+// are ignored.
+func Validate(data []byte) error {
+	var errs []Problem
+	for _, p := range Lint(data) {
+		if p.Severity == SeverityError {
+			errs = append(errs, p)
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Problems: errs}
+	}
+
+	return nil
+}
+
+func lintPart(data []byte) []Problem {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil
+	}
+
+	// gzip compressed user data is passed straight through.
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		return nil
+	}
+
+	firstLine := string(trimmed)
+	if i := strings.IndexAny(firstLine, "\r\n"); i >= 0 {
+		firstLine = firstLine[:i]
+	}
+	firstLine = strings.TrimSpace(firstLine)
+
+	switch {
+	case strings.HasPrefix(firstLine, "#cloud-config") && !strings.HasPrefix(firstLine, "#cloud-config-archive"):
+		return lintCloudConfig(data)
+	case strings.HasPrefix(firstLine, "#!"):
+		return lintScript(data)
+	case isMIME(firstLine):
+		return lintMultipart(data)
+	}
+
+	for _, h := range headerTypes {
+		if strings.HasPrefix(firstLine, h) {
+			return nil
+		}
+	}
+
+	// Other formats, such as Ignition JSON, are understood by some images, so
+	// they are passed through with a warning.
+	return []Problem{{
+		Line:     1,
+		Severity: SeverityWarning,
+		Message:  "unrecognized user data format, expected #cloud-config, a #! script or MIME multipart",
+	}}
+}
+
+func isMIME(line string) bool {
+	l := strings.ToLower(line)
+	return strings.HasPrefix(l, "content-type:") || strings.HasPrefix(l, "mime-version:")
+}
+
+func lintCloudConfig(data []byte) []Problem {
+	var problems []Problem
+
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		p := Problem{Severity: SeverityError, Message: err.Error()}
+		if m := yamlLineRE.FindStringSubmatch(err.Error()); m != nil {
+			// yaml reports zero based line numbers.
+			n, _ := strconv.Atoi(m[1])
+			p.Line = n + 1
+			p.Message = m[2]
+		}
+
+		return []Problem{p}
+	}
+
+	if doc == nil {
+		return nil
+	}
+
+	m, ok := doc.(map[interface{}]interface{})
+	if !ok {
+		return []Problem{{
+			Line:     firstContentLine(data),
+			Severity: SeverityError,
+			Message:  "cloud-config must be a mapping of module keys",
+		}}
+	}
+
+	lines := topLevelKeyLines(data)
+
+	for k, v := range m {
+		key := fmt.Sprintf("%v", k)
+		line := lines[key]
+
+		if !knownKeys[key] {
+			problems = append(problems, Problem{
+				Line:     line,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("unknown top level key %q", key),
+			})
+			continue
+		}
+
+		if listKeys[key] {
+			if _, ok := v.([]interface{}); !ok && v != nil {
+				problems = append(problems, Problem{
+					Line:     line,
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("%q must be a list", key),
+				})
+				continue
+			}
+		}
+
+		if key == "write_files" {
+			files, _ := v.([]interface{})
+			for i, f := range files {
+				fm, ok := f.(map[interface{}]interface{})
+				if !ok {
+					problems = append(problems, Problem{
+						Line:     line,
+						Severity: SeverityError,
+						Message:  fmt.Sprintf("write_files entry %d must be a mapping", i),
+					})
+					continue
+				}
+
+				if p, _ := fm["path"].(string); p == "" {
+					problems = append(problems, Problem{
+						Line:     line,
+						Severity: SeverityError,
+						Message:  fmt.Sprintf("write_files entry %d is missing a path", i),
+					})
+				}
+			}
+		}
+	}
+
+	sort.Stable(byLine(problems))
+	return problems
+}
+
+func lintScript(data []byte) []Problem {
+	var problems []Problem
+
+	line := string(data)
+	if i := strings.Index(line, "\n"); i >= 0 {
+		line = line[:i]
+	}
+
+	if strings.HasSuffix(line, "\r") {
+		problems = append(problems, Problem{
+			Line:     1,
+			Severity: SeverityError,
+			Message:  "script uses CRLF line endings, the interpreter will not be found",
+		})
+		line = strings.TrimSuffix(line, "\r")
+	}
+
+	interpreter := strings.TrimSpace(strings.TrimPrefix(line, "#!"))
+	if interpreter == "" {
+		problems = append(problems, Problem{
+			Line:     1,
+			Severity: SeverityError,
+			Message:  "script is missing an interpreter after #!",
+		})
+	} else if !strings.HasPrefix(interpreter, "/") {
+		problems = append(problems, Problem{
+			Line:     1,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("interpreter %q must be an absolute path", interpreter),
+		})
+	}
+
+	return problems
+}
+
+func lintMultipart(data []byte) []Problem {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return []Problem{{Severity: SeverityError, Message: fmt.Sprintf("invalid MIME headers: %v", err)}}
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		return []Problem{{Severity: SeverityError, Message: fmt.Sprintf("invalid Content-Type: %v", err)}}
+	}
+
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		body, _ := ioutil.ReadAll(msg.Body)
+		return lintMIMEPart(0, mediaType, msg.Header.Get("Content-Transfer-Encoding"), body)
+	}
+
+	boundary := params["boundary"]
+	if boundary == "" {
+		return []Problem{{Severity: SeverityError, Message: "multipart user data is missing a boundary"}}
+	}
+
+	var problems []Problem
+	mr := multipart.NewReader(msg.Body, boundary)
+	for i := 1; ; i++ {
+		part, err := mr.NextPart()
+		if err != nil {
+			if err != io.EOF {
+				problems = append(problems, Problem{
+					Severity: SeverityError,
+					Message:  fmt.Sprintf("part %d: %v", i, err),
+				})
+			}
+			break
+		}
+
+		body, err := ioutil.ReadAll(part)
+		if err != nil {
+			problems = append(problems, Problem{
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("part %d: %v", i, err),
+			})
+			break
+		}
+
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		problems = append(problems, lintMIMEPart(i, partType, part.Header.Get("Content-Transfer-Encoding"), body)...)
+	}
+
+	return problems
+}
+
+func lintMIMEPart(n int, mediaType, encoding string, body []byte) []Problem {
+	prefix := func(p Problem) Problem {
+		if n > 0 {
+			p.Message = fmt.Sprintf("part %d: %s", n, p.Message)
+		}
+		p.Line = 0
+		return p
+	}
+
+	if !partTypes[mediaType] {
+		return []Problem{prefix(Problem{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("unknown content type %q", mediaType),
+		})}
+	}
+
+	if strings.EqualFold(encoding, "base64") {
+		decoded, err := base64.StdEncoding.DecodeString(string(bytes.Join(bytes.Fields(body), nil)))
+		if err != nil {
+			return []Problem{prefix(Problem{
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("invalid base64 content: %v", err),
+			})}
+		}
+		body = decoded
+	}
+
+	var problems []Problem
+	switch mediaType {
+	case "text/cloud-config":
+		problems = lintCloudConfig(body)
+	case "text/x-shellscript":
+		problems = lintScript(body)
+	}
+
+	for i := range problems {
+		if problems[i].Line > 0 {
+			problems[i].Message = fmt.Sprintf("line %d: %s", problems[i].Line, problems[i].Message)
+		}
+		problems[i] = prefix(problems[i])
+	}
+
+	return problems
+}
+
+// topLevelKeyLines maps each top level key in a YAML document to the line it
+// is defined on.
+func topLevelKeyLines(data []byte) map[string]int {
+	lines := map[string]int{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxUserDataSize*2)
+	for n := 1; scanner.Scan(); n++ {
+		m := topKeyRE.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+
+		key := strings.Trim(m[1], `"'`)
+		if _, ok := lines[key]; !ok {
+			lines[key] = n
+		}
+	}
+
+	return lines
+}
+
+func firstContentLine(data []byte) int {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxUserDataSize*2)
+	for n := 1; scanner.Scan(); n++ {
+		l := strings.TrimSpace(scanner.Text())
+		if l != "" && !strings.HasPrefix(l, "#") {
+			return n
+		}
+	}
+
+	return 0
+}
+
+type byLine []Problem
+
+func (p byLine) Len() int           { return len(p) }
+func (p byLine) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p byLine) Less(i, j int) bool { return p[i].Line < p[j].Line }
@@ -0,0 +1,150 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudinit
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	cases := []struct {
+		name     string
+		in       string
+		expected []Problem
+	}{
+		{
+			name: "empty",
+			in:   "",
+		},
+		{
+			name: "cloud-config",
+			in:   "#cloud-config\npackages:\n  - nginx\nruncmd:\n  - [service, nginx, start]\n",
+		},
+		{
+			name: "yaml syntax error",
+			in:   "#cloud-config\npackages:\n  - nginx\n runcmd: [\n",
+			expected: []Problem{
+				{Line: 4, Severity: SeverityError, Message: "did not find expected key"},
+			},
+		},
+		{
+			name: "unknown key",
+			in:   "#cloud-config\nhostname: web\npackage:\n  - nginx\n",
+			expected: []Problem{
+				{Line: 3, Severity: SeverityWarning, Message: `unknown top level key "package"`},
+			},
+		},
+		{
+			name: "list key",
+			in:   "#cloud-config\nruncmd: reboot\n",
+			expected: []Problem{
+				{Line: 2, Severity: SeverityError, Message: `"runcmd" must be a list`},
+			},
+		},
+		{
+			name: "write_files without path",
+			in:   "#cloud-config\nwrite_files:\n  - content: hello\n",
+			expected: []Problem{
+				{Line: 2, Severity: SeverityError, Message: "write_files entry 0 is missing a path"},
+			},
+		},
+		{
+			name: "not a mapping",
+			in:   "#cloud-config\n- one\n- two\n",
+			expected: []Problem{
+				{Line: 2, Severity: SeverityError, Message: "cloud-config must be a mapping of module keys"},
+			},
+		},
+		{
+			name: "script",
+			in:   "#!/bin/bash\necho hello\n",
+		},
+		{
+			name: "script with crlf",
+			in:   "#!/bin/bash\r\necho hello\r\n",
+			expected: []Problem{
+				{Line: 1, Severity: SeverityError, Message: "script uses CRLF line endings, the interpreter will not be found"},
+			},
+		},
+		{
+			name: "script without interpreter",
+			in:   "#!\necho hello\n",
+			expected: []Problem{
+				{Line: 1, Severity: SeverityError, Message: "script is missing an interpreter after #!"},
+			},
+		},
+		{
+			name: "unknown format",
+			in:   "packages:\n  - nginx\n",
+			expected: []Problem{
+				{Line: 1, Severity: SeverityWarning, Message: "unrecognized user data format, expected #cloud-config, a #! script or MIME multipart"},
+			},
+		},
+		{
+			name: "ignition",
+			in:   "{\"ignition\": {\"version\": \"2.0.0\"}}\n",
+			expected: []Problem{
+				{Line: 1, Severity: SeverityWarning, Message: "unrecognized user data format, expected #cloud-config, a #! script or MIME multipart"},
+			},
+		},
+		{
+			name: "multipart",
+			in: "Content-Type: multipart/mixed; boundary=\"XXX\"\nMIME-Version: 1.0\n\n" +
+				"--XXX\nContent-Type: text/cloud-config\n\n#cloud-config\nruncmd: reboot\n" +
+				"--XXX\nContent-Type: text/x-shellscript\n\n#!/bin/sh\necho hi\n" +
+				"--XXX\nContent-Type: text/plain\n\nhello\n" +
+				"--XXX--\n",
+			expected: []Problem{
+				{Severity: SeverityError, Message: `part 1: line 2: "runcmd" must be a list`},
+				{Severity: SeverityWarning, Message: `part 3: unknown content type "text/plain"`},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		got := Lint([]byte(c.in))
+		if c.expected == nil {
+			c.expected = []Problem{}
+		}
+		assert.Equal(t, c.expected, got, c.name)
+	}
+}
+
+func TestValidate_Ignition(t *testing.T) {
+	assert.NoError(t, Validate([]byte(`{"ignition": {"version": "2.0.0"}}`)))
+}
+
+func TestLint_TestData(t *testing.T) {
+	b, err := ioutil.ReadFile("../../testdata/cloud-config.yml")
+	assert.NoError(t, err)
+	assert.Empty(t, Lint(b))
+}
+
+func TestLint_Size(t *testing.T) {
+	in := "#!/bin/sh\n" + strings.Repeat("#", MaxUserDataSize)
+	got := Lint([]byte(in))
+	assert.Len(t, got, 1)
+	assert.Equal(t, SeverityError, got[0].Severity)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate([]byte("#cloud-config\nunknown: true\n")))
+
+	err := Validate([]byte("#cloud-config\nruncmd: reboot\n"))
+	assert.EqualError(t, err, `invalid user data: line 2: error: "runcmd" must be a list`)
+}
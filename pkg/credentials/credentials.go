@@ -0,0 +1,32 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package credentials stores secrets, such as API access tokens, outside of
+// doctl's plain text configuration file.
+package credentials
+
+import "errors"
+
+// ErrNotFound is returned when a store has no secret for a key.
+var ErrNotFound = errors.New("credentials not found")
+
+// Store keeps secrets by key. Keys are opaque to stores, doctl uses the API
+// endpoint a token is for.
+type Store interface {
+	// Get returns the secret for key, or ErrNotFound.
+	Get(key string) (string, error)
+	// Set saves the secret for key, replacing any existing one.
+	Set(key, secret string) error
+	// Erase removes the secret for key. Erasing a missing key is not an error.
+	Erase(key string) error
+}
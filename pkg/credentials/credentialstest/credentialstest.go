@@ -0,0 +1,120 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package credentialstest provides a fake credential store, keeping secrets
+// in a plain JSON file, for tests.
+package credentialstest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/digitalocean/doctl/pkg/credentials"
+)
+
+// Store keeps secrets unencrypted in a JSON file. Being a file, it can be
+// shared with helper processes started by a test.
+type Store struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+var _ credentials.Store = &Store{}
+
+// NewStore creates a Store for the file at path. The file is created when a
+// secret is first set.
+func NewStore(path string) *Store {
+	return &Store{Path: path}
+}
+
+// Get returns the secret for key.
+func (s *Store) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.read()
+	if err != nil {
+		return "", err
+	}
+
+	secret, ok := secrets[key]
+	if !ok {
+		return "", credentials.ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set saves the secret for key.
+func (s *Store) Set(key, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	secrets[key] = secret
+	return s.write(secrets)
+}
+
+// Erase removes the secret for key.
+func (s *Store) Erase(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	delete(secrets, key)
+	return s.write(secrets)
+}
+
+// Secrets returns all the secrets in the file.
+func (s *Store) Secrets() (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read()
+}
+
+func (s *Store) read() (map[string]string, error) {
+	secrets := map[string]string{}
+
+	b, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+func (s *Store) write(secrets map[string]string) error {
+	b, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.Path, b, 0600)
+}
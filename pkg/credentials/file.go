@@ -0,0 +1,257 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrBadPassphrase is returned when a credentials file can't be decrypted
+// with the passphrase given.
+var ErrBadPassphrase = errors.New("incorrect passphrase for credentials file")
+
+// scrypt cost parameters for new files. Files record the parameters they were
+// written with, and files asking for a higher cost are rejected, so a
+// corrupted file can't make key derivation hang or exhaust memory.
+var (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// encryptedFile is the on disk format of a FileStore. Data is a JSON object
+// of keys to secrets, sealed with a key derived from the passphrase.
+type encryptedFile struct {
+	Version int    `json:"version"`
+	N       int    `json:"n"`
+	R       int    `json:"r"`
+	P       int    `json:"p"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// FileStore keeps secrets in a file encrypted with a passphrase.
+type FileStore struct {
+	Path string
+	// Passphrase is asked for the passphrase the first time the file is
+	// read or written.
+	Passphrase func() ([]byte, error)
+
+	mu         sync.Mutex
+	passphrase []byte
+}
+
+var _ Store = &FileStore{}
+
+// NewFileStore creates a FileStore for the file at path.
+func NewFileStore(path string, passphrase func() ([]byte, error)) *FileStore {
+	return &FileStore{Path: path, Passphrase: passphrase}
+}
+
+// Get returns the secret for key.
+func (fs *FileStore) Get(key string) (string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	secrets, err := fs.read()
+	if err != nil {
+		return "", err
+	}
+
+	secret, ok := secrets[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set saves the secret for key.
+func (fs *FileStore) Set(key, secret string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	secrets, err := fs.read()
+	if err != nil {
+		return err
+	}
+
+	secrets[key] = secret
+	return fs.write(secrets)
+}
+
+// Erase removes the secret for key.
+func (fs *FileStore) Erase(key string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	secrets, err := fs.read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := secrets[key]; !ok {
+		return nil
+	}
+
+	delete(secrets, key)
+	return fs.write(secrets)
+}
+
+func (fs *FileStore) getPassphrase() ([]byte, error) {
+	if fs.passphrase != nil {
+		return fs.passphrase, nil
+	}
+
+	if fs.Passphrase == nil {
+		return nil, errors.New("no passphrase for credentials file")
+	}
+
+	p, err := fs.Passphrase()
+	if err != nil {
+		return nil, err
+	}
+	if len(p) == 0 {
+		return nil, errors.New("passphrase for credentials file is empty")
+	}
+
+	fs.passphrase = p
+	return p, nil
+}
+
+// read decrypts the file. A missing file has no secrets.
+func (fs *FileStore) read() (map[string]string, error) {
+	b, err := ioutil.ReadFile(fs.Path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ef encryptedFile
+	if err := json.Unmarshal(b, &ef); err != nil {
+		return nil, fmt.Errorf("invalid credentials file %s: %v", fs.Path, err)
+	}
+	if ef.Version != 1 || len(ef.Nonce) != 24 {
+		return nil, fmt.Errorf("unsupported credentials file %s", fs.Path)
+	}
+	if ef.N < 2 || ef.N > scryptN || ef.R < 1 || ef.R > scryptR || ef.P < 1 || ef.P > scryptP {
+		return nil, fmt.Errorf("invalid key derivation parameters in credentials file %s", fs.Path)
+	}
+
+	passphrase, err := fs.getPassphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := deriveKey(passphrase, ef.Salt, ef.N, ef.R, ef.P)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [24]byte
+	copy(nonce[:], ef.Nonce)
+
+	data, ok := secretbox.Open(nil, ef.Data, &nonce, key)
+	if !ok {
+		return nil, ErrBadPassphrase
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return nil, fmt.Errorf("invalid credentials file %s: %v", fs.Path, err)
+	}
+
+	return secrets, nil
+}
+
+// write encrypts secrets with a new salt and nonce, and replaces the file.
+func (fs *FileStore) write(secrets map[string]string) error {
+	passphrase, err := fs.getPassphrase()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	ef := encryptedFile{
+		Version: 1,
+		N:       scryptN,
+		R:       scryptR,
+		P:       scryptP,
+		Salt:    make([]byte, 32),
+		Nonce:   make([]byte, 24),
+	}
+	if _, err := io.ReadFull(rand.Reader, ef.Salt); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(rand.Reader, ef.Nonce); err != nil {
+		return err
+	}
+
+	key, err := deriveKey(passphrase, ef.Salt, ef.N, ef.R, ef.P)
+	if err != nil {
+		return err
+	}
+
+	var nonce [24]byte
+	copy(nonce[:], ef.Nonce)
+	ef.Data = secretbox.Seal(nil, data, &nonce, key)
+
+	b, err := json.Marshal(&ef)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fs.Path), 0700); err != nil {
+		return err
+	}
+
+	tmp := fs.Path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, fs.Path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+func deriveKey(passphrase, salt []byte, n, r, p int) (*[32]byte, error) {
+	k, err := scrypt.Key(passphrase, salt, n, r, p, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	var key [32]byte
+	copy(key[:], k)
+	return &key, nil
+}
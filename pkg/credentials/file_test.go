@@ -0,0 +1,149 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testFileStore(t *testing.T, passphrase string) (*FileStore, func()) {
+	dir, err := ioutil.TempDir("", "credentials")
+	assert.NoError(t, err)
+
+	// Keep key derivation cheap in tests.
+	n := scryptN
+	scryptN = 1 << 10
+
+	fs := NewFileStore(filepath.Join(dir, "credentials.json"), func() ([]byte, error) {
+		return []byte(passphrase), nil
+	})
+
+	return fs, func() {
+		scryptN = n
+		os.RemoveAll(dir)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	fs, cleanup := testFileStore(t, "hunter2")
+	defer cleanup()
+
+	_, err := fs.Get("https://api.digitalocean.com")
+	assert.Equal(t, ErrNotFound, err)
+
+	assert.NoError(t, fs.Set("https://api.digitalocean.com", "token"))
+	assert.NoError(t, fs.Set("https://example.com", "other"))
+
+	b, err := ioutil.ReadFile(fs.Path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "token")
+	assert.NotContains(t, string(b), "api.digitalocean.com")
+
+	fi, err := os.Stat(fs.Path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	reopened := NewFileStore(fs.Path, func() ([]byte, error) { return []byte("hunter2"), nil })
+	secret, err := reopened.Get("https://api.digitalocean.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "token", secret)
+
+	assert.NoError(t, reopened.Erase("https://api.digitalocean.com"))
+	assert.NoError(t, reopened.Erase("https://api.digitalocean.com"))
+
+	_, err = fs.Get("https://api.digitalocean.com")
+	assert.Equal(t, ErrNotFound, err)
+
+	secret, err = fs.Get("https://example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "other", secret)
+}
+
+func TestFileStoreBadPassphrase(t *testing.T) {
+	fs, cleanup := testFileStore(t, "hunter2")
+	defer cleanup()
+
+	assert.NoError(t, fs.Set("key", "token"))
+
+	wrong := NewFileStore(fs.Path, func() ([]byte, error) { return []byte("hunter3"), nil })
+	_, err := wrong.Get("key")
+	assert.Equal(t, ErrBadPassphrase, err)
+
+	err = wrong.Set("key", "replaced")
+	assert.Equal(t, ErrBadPassphrase, err)
+
+	secret, err := fs.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "token", secret)
+}
+
+func TestFileStoreAsksForPassphraseOnce(t *testing.T) {
+	fs, cleanup := testFileStore(t, "")
+	defer cleanup()
+
+	asked := 0
+	fs.Passphrase = func() ([]byte, error) {
+		asked++
+		return []byte("hunter2"), nil
+	}
+
+	assert.NoError(t, fs.Set("key", "token"))
+	_, err := fs.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, asked)
+
+	empty := NewFileStore(fs.Path, func() ([]byte, error) { return nil, nil })
+	_, err = empty.Get("key")
+	assert.Error(t, err)
+}
+
+func TestFileStoreRejectsCostlyParameters(t *testing.T) {
+	fs, cleanup := testFileStore(t, "")
+	defer cleanup()
+
+	asked := 0
+	fs.Passphrase = func() ([]byte, error) {
+		asked++
+		return []byte("hunter2"), nil
+	}
+
+	assert.NoError(t, fs.Set("key", "token"))
+
+	b, err := ioutil.ReadFile(fs.Path)
+	assert.NoError(t, err)
+
+	var ef encryptedFile
+	assert.NoError(t, json.Unmarshal(b, &ef))
+
+	for _, params := range [][3]int{{1 << 30, 8, 1}, {scryptN, 1 << 20, 1}, {scryptN, 8, 1 << 20}, {0, 8, 1}} {
+		ef.N, ef.R, ef.P = params[0], params[1], params[2]
+		b, err := json.Marshal(&ef)
+		assert.NoError(t, err)
+		assert.NoError(t, ioutil.WriteFile(fs.Path, b, 0600))
+
+		reopened := NewFileStore(fs.Path, fs.Passphrase)
+		_, err = reopened.Get("key")
+		assert.Error(t, err, "%v", params)
+		assert.Contains(t, err.Error(), "invalid key derivation parameters", "%v", params)
+	}
+
+	// The file is rejected before the passphrase is asked for.
+	assert.Equal(t, 1, asked)
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// helperNotFound is what credential helpers print when they have no secret
+// for a key.
+const helperNotFound = "credentials not found"
+
+// helperCredentials is the message credential helpers exchange on stdin and
+// stdout.
+type helperCredentials struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+// Helper stores secrets with an external credential helper program, using
+// the protocol of docker credential helpers, so those (for example
+// docker-credential-pass or docker-credential-osxkeychain) can be used as is.
+//
+// The helper is run with get, store or erase as its last argument. get and
+// erase are given the key on stdin. store is given, and get prints, a JSON
+// object with ServerURL (the key), Username and Secret fields.
+type Helper struct {
+	Program string
+	Args    []string
+	// Username is recorded alongside secrets.
+	Username string
+}
+
+var _ Store = &Helper{}
+
+// NewHelper creates a Helper from a command line, such as
+// "docker-credential-pass" or "/path/to/helper --some-flag".
+func NewHelper(command string) (*Helper, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, errors.New("credential helper is empty")
+	}
+
+	return &Helper{Program: fields[0], Args: fields[1:], Username: "doctl"}, nil
+}
+
+// Get returns the secret for key.
+func (h *Helper) Get(key string) (string, error) {
+	out, err := h.run("get", []byte(key))
+	if err != nil {
+		return "", err
+	}
+
+	var hc helperCredentials
+	if err := json.Unmarshal(out, &hc); err != nil {
+		return "", fmt.Errorf("invalid response from credential helper %s: %v", h.Program, err)
+	}
+	if hc.Secret == "" {
+		return "", ErrNotFound
+	}
+
+	return hc.Secret, nil
+}
+
+// Set saves the secret for key.
+func (h *Helper) Set(key, secret string) error {
+	in, err := json.Marshal(&helperCredentials{ServerURL: key, Username: h.Username, Secret: secret})
+	if err != nil {
+		return err
+	}
+
+	_, err = h.run("store", in)
+	return err
+}
+
+// Erase removes the secret for key.
+func (h *Helper) Erase(key string) error {
+	_, err := h.run("erase", []byte(key))
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func (h *Helper) run(action string, in []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	args := append(append([]string{}, h.Args...), action)
+	cmd := exec.Command(h.Program, args...)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return nil, fmt.Errorf("unable to run credential helper %s: %v", h.Program, err)
+		}
+
+		msg := strings.TrimSpace(stdout.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stderr.String())
+		}
+		if strings.Contains(msg, helperNotFound) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("credential helper %s %s failed: %s", h.Program, action, msg)
+	}
+
+	return stdout.Bytes(), nil
+}
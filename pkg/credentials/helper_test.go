@@ -0,0 +1,146 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/digitalocean/doctl/pkg/credentials"
+	"github.com/digitalocean/doctl/pkg/credentials/credentialstest"
+	"github.com/stretchr/testify/assert"
+)
+
+// envHelperStore makes the test binary act as a credential helper, keeping
+// secrets in the named file.
+const envHelperStore = "DOCTL_TEST_HELPER_STORE"
+
+func TestMain(m *testing.M) {
+	if path := os.Getenv(envHelperStore); path != "" {
+		os.Exit(fakeHelper(credentialstest.NewStore(path), os.Args[1:]))
+	}
+
+	os.Exit(m.Run())
+}
+
+func fakeHelper(store credentials.Store, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "unexpected arguments:", args)
+		return 2
+	}
+
+	in, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	switch args[0] {
+	case "get":
+		secret, err := store.Get(string(in))
+		if err == credentials.ErrNotFound {
+			fmt.Println("credentials not found in native keychain")
+			return 1
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		json.NewEncoder(os.Stdout).Encode(map[string]string{
+			"ServerURL": string(in),
+			"Username":  "doctl",
+			"Secret":    secret,
+		})
+	case "store":
+		var c struct{ ServerURL, Username, Secret string }
+		if err := json.Unmarshal(in, &c); err != nil || c.Username != "doctl" {
+			fmt.Fprintln(os.Stderr, "invalid credentials:", string(in))
+			return 1
+		}
+		if err := store.Set(c.ServerURL, c.Secret); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	case "erase":
+		if err := store.Erase(string(in)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "unknown action", args[0])
+		return 2
+	}
+
+	return 0
+}
+
+func TestHelper(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "helper.json")
+	os.Setenv(envHelperStore, path)
+	defer os.Unsetenv(envHelperStore)
+
+	h := &credentials.Helper{Program: os.Args[0], Username: "doctl"}
+
+	_, err = h.Get("https://api.digitalocean.com")
+	assert.Equal(t, credentials.ErrNotFound, err)
+
+	assert.NoError(t, h.Set("https://api.digitalocean.com", "token"))
+
+	secrets, err := credentialstest.NewStore(path).Secrets()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"https://api.digitalocean.com": "token"}, secrets)
+
+	secret, err := h.Get("https://api.digitalocean.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "token", secret)
+
+	assert.NoError(t, h.Erase("https://api.digitalocean.com"))
+	_, err = h.Get("https://api.digitalocean.com")
+	assert.Equal(t, credentials.ErrNotFound, err)
+}
+
+func TestHelperErrors(t *testing.T) {
+	os.Setenv(envHelperStore, "unused")
+	defer os.Unsetenv(envHelperStore)
+
+	h := &credentials.Helper{Program: os.Args[0], Args: []string{"extra"}}
+	_, err := h.Get("key")
+	if assert.Error(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "unexpected arguments"), err.Error())
+	}
+
+	h = &credentials.Helper{Program: filepath.Join(os.TempDir(), "doctl-no-such-helper")}
+	_, err = h.Get("key")
+	assert.Error(t, err)
+	assert.NotEqual(t, credentials.ErrNotFound, err)
+}
+
+func TestNewHelper(t *testing.T) {
+	h, err := credentials.NewHelper("docker-credential-pass  --flag ")
+	assert.NoError(t, err)
+	assert.Equal(t, "docker-credential-pass", h.Program)
+	assert.Equal(t, []string{"--flag"}, h.Args)
+
+	_, err = credentials.NewHelper(" ")
+	assert.Error(t, err)
+}
@@ -0,0 +1,311 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus"
+)
+
+const (
+	ssName                = "org.freedesktop.secrets"
+	ssPath                = dbus.ObjectPath("/org/freedesktop/secrets")
+	ssServiceInterface    = "org.freedesktop.Secret.Service"
+	ssSessionInterface    = "org.freedesktop.Secret.Session"
+	ssItemInterface       = "org.freedesktop.Secret.Item"
+	ssPromptInterface     = "org.freedesktop.Secret.Prompt"
+	ssCollectionInterface = "org.freedesktop.Secret.Collection"
+	ssCollectionLabel     = "org.freedesktop.Secret.Collection.Label"
+	ssItemLabel           = "org.freedesktop.Secret.Item.Label"
+	ssItemAttributes      = "org.freedesktop.Secret.Item.Attributes"
+	ssNoPrompt            = dbus.ObjectPath("/")
+	ssDefaultCollection   = "default"
+)
+
+// SecretService keeps secrets in the OS keyring through the freedesktop.org
+// Secret Service D-Bus API, as provided by GNOME Keyring and KWallet. Items
+// are stored in the default collection, which may ask the user to unlock it.
+type SecretService struct {
+	// Application is an attribute of items, telling doctl's secrets apart
+	// from others.
+	Application string
+}
+
+var _ Store = &SecretService{}
+
+// NewSecretService creates a SecretService.
+func NewSecretService() *SecretService {
+	return &SecretService{Application: "doctl"}
+}
+
+// ssSecret is the Secret Service's secret struct.
+type ssSecret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// ssSession is a connection to the session bus with an open Secret Service
+// session. Secrets are transferred unencrypted ("plain"), as the bus
+// connection is local to the user.
+type ssSession struct {
+	conn *dbus.Conn
+	path dbus.ObjectPath
+}
+
+// Get returns the secret for key.
+func (s *SecretService) Get(key string) (string, error) {
+	ss, err := openSecretService()
+	if err != nil {
+		return "", err
+	}
+	defer ss.close()
+
+	items, err := ss.search(s.attributes(key))
+	if err != nil {
+		return "", err
+	}
+	if len(items) == 0 {
+		return "", ErrNotFound
+	}
+
+	var secret ssSecret
+	err = ss.conn.Object(ssName, items[0]).Call(ssItemInterface+".GetSecret", 0, ss.path).Store(&secret)
+	if err != nil {
+		return "", secretServiceError(err)
+	}
+
+	return string(secret.Value), nil
+}
+
+// Set saves the secret for key.
+func (s *SecretService) Set(key, secret string) error {
+	ss, err := openSecretService()
+	if err != nil {
+		return err
+	}
+	defer ss.close()
+
+	collection, err := ss.defaultCollection()
+	if err != nil {
+		return err
+	}
+	if err := ss.unlock([]dbus.ObjectPath{collection}); err != nil {
+		return err
+	}
+
+	props := map[string]dbus.Variant{
+		ssItemLabel:      dbus.MakeVariant("doctl: " + key),
+		ssItemAttributes: dbus.MakeVariant(s.attributes(key)),
+	}
+	value := ssSecret{
+		Session:     ss.path,
+		Parameters:  []byte{},
+		Value:       []byte(secret),
+		ContentType: "text/plain",
+	}
+
+	var item, prompt dbus.ObjectPath
+	err = ss.conn.Object(ssName, collection).Call(ssCollectionInterface+".CreateItem", 0, props, value, true).Store(&item, &prompt)
+	if err != nil {
+		return secretServiceError(err)
+	}
+
+	_, err = ss.prompt(prompt)
+	return err
+}
+
+// Erase removes the secret for key.
+func (s *SecretService) Erase(key string) error {
+	ss, err := openSecretService()
+	if err != nil {
+		return err
+	}
+	defer ss.close()
+
+	items, err := ss.search(s.attributes(key))
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		var prompt dbus.ObjectPath
+		err := ss.conn.Object(ssName, item).Call(ssItemInterface+".Delete", 0).Store(&prompt)
+		if err != nil {
+			return secretServiceError(err)
+		}
+		if _, err := ss.prompt(prompt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *SecretService) attributes(key string) map[string]string {
+	return map[string]string{
+		"application": s.Application,
+		"key":         key,
+	}
+}
+
+func openSecretService() (*ssSession, error) {
+	conn, err := dbus.SessionBusPrivate()
+	if err != nil {
+		return nil, secretServiceError(err)
+	}
+
+	if err := conn.Auth(nil); err != nil {
+		conn.Close()
+		return nil, secretServiceError(err)
+	}
+	if err := conn.Hello(); err != nil {
+		conn.Close()
+		return nil, secretServiceError(err)
+	}
+
+	var output dbus.Variant
+	var path dbus.ObjectPath
+	err = conn.Object(ssName, ssPath).Call(ssServiceInterface+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&output, &path)
+	if err != nil {
+		conn.Close()
+		return nil, secretServiceError(err)
+	}
+
+	return &ssSession{conn: conn, path: path}, nil
+}
+
+func (ss *ssSession) close() {
+	ss.conn.Object(ssName, ss.path).Call(ssSessionInterface+".Close", 0)
+	ss.conn.Close()
+}
+
+func (ss *ssSession) service() dbus.BusObject {
+	return ss.conn.Object(ssName, ssPath)
+}
+
+// search returns the items matching attrs, unlocking them if needed.
+func (ss *ssSession) search(attrs map[string]string) ([]dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	err := ss.service().Call(ssServiceInterface+".SearchItems", 0, attrs).Store(&unlocked, &locked)
+	if err != nil {
+		return nil, secretServiceError(err)
+	}
+
+	if len(locked) > 0 {
+		if err := ss.unlock(locked); err != nil {
+			return nil, err
+		}
+		unlocked = append(unlocked, locked...)
+	}
+
+	return unlocked, nil
+}
+
+func (ss *ssSession) unlock(objects []dbus.ObjectPath) error {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	err := ss.service().Call(ssServiceInterface+".Unlock", 0, objects).Store(&unlocked, &prompt)
+	if err != nil {
+		return secretServiceError(err)
+	}
+
+	_, err = ss.prompt(prompt)
+	return err
+}
+
+// defaultCollection returns the default collection, creating it if there
+// is none.
+func (ss *ssSession) defaultCollection() (dbus.ObjectPath, error) {
+	var collection dbus.ObjectPath
+	err := ss.service().Call(ssServiceInterface+".ReadAlias", 0, ssDefaultCollection).Store(&collection)
+	if err != nil {
+		return "", secretServiceError(err)
+	}
+	if collection != ssNoPrompt {
+		return collection, nil
+	}
+
+	props := map[string]dbus.Variant{
+		ssCollectionLabel: dbus.MakeVariant("Login"),
+	}
+
+	var prompt dbus.ObjectPath
+	err = ss.service().Call(ssServiceInterface+".CreateCollection", 0, props, ssDefaultCollection).Store(&collection, &prompt)
+	if err != nil {
+		return "", secretServiceError(err)
+	}
+	if prompt == ssNoPrompt {
+		return collection, nil
+	}
+
+	result, err := ss.prompt(prompt)
+	if err != nil {
+		return "", err
+	}
+
+	collection, ok := result.Value().(dbus.ObjectPath)
+	if !ok {
+		return "", errors.New("keyring did not create a collection")
+	}
+	return collection, nil
+}
+
+// prompt shows a prompt, such as a dialog to unlock the keyring, and waits
+// for the user to complete it. There is nothing to do for the "/" prompt.
+func (ss *ssSession) prompt(path dbus.ObjectPath) (dbus.Variant, error) {
+	if path == ssNoPrompt || path == "" {
+		return dbus.Variant{}, nil
+	}
+
+	// Subscribe before prompting, so Completed can't be missed.
+	err := ss.conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(path),
+		dbus.WithMatchInterface(ssPromptInterface),
+		dbus.WithMatchMember("Completed"),
+	)
+	if err != nil {
+		return dbus.Variant{}, secretServiceError(err)
+	}
+
+	ch := make(chan *dbus.Signal, 1)
+	ss.conn.Signal(ch)
+	defer ss.conn.RemoveSignal(ch)
+
+	if err := ss.conn.Object(ssName, path).Call(ssPromptInterface+".Prompt", 0, "").Err; err != nil {
+		return dbus.Variant{}, secretServiceError(err)
+	}
+
+	for sig := range ch {
+		if sig.Path != path || sig.Name != ssPromptInterface+".Completed" || len(sig.Body) != 2 {
+			continue
+		}
+
+		if dismissed, _ := sig.Body[0].(bool); dismissed {
+			return dbus.Variant{}, errors.New("keyring prompt was dismissed")
+		}
+
+		result, _ := sig.Body[1].(dbus.Variant)
+		return result, nil
+	}
+
+	return dbus.Variant{}, errors.New("keyring connection closed while prompting")
+}
+
+func secretServiceError(err error) error {
+	return fmt.Errorf("unable to use the Secret Service keyring: %v", err)
+}
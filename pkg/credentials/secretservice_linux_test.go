@@ -0,0 +1,346 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/godbus/dbus"
+	"github.com/stretchr/testify/assert"
+)
+
+const testBusConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:dir=%s</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// testSessionBus starts a private dbus-daemon, and points the session bus
+// address at it.
+func testSessionBus(t *testing.T) func() {
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "dbus")
+	assert.NoError(t, err)
+
+	conf := filepath.Join(dir, "session.conf")
+	assert.NoError(t, ioutil.WriteFile(conf, []byte(fmt.Sprintf(testBusConfig, dir)), 0600))
+
+	cmd := exec.Command(daemon, "--config-file="+conf, "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	assert.NoError(t, err)
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		t.Skipf("unable to start dbus-daemon: %v", err)
+	}
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		os.RemoveAll(dir)
+		t.Fatalf("dbus-daemon didn't print its address: %v", err)
+	}
+
+	og, ok := os.LookupEnv("DBUS_SESSION_BUS_ADDRESS")
+	os.Setenv("DBUS_SESSION_BUS_ADDRESS", strings.TrimSpace(address))
+
+	return func() {
+		if ok {
+			os.Setenv("DBUS_SESSION_BUS_ADDRESS", og)
+		} else {
+			os.Unsetenv("DBUS_SESSION_BUS_ADDRESS")
+		}
+		cmd.Process.Kill()
+		cmd.Wait()
+		os.RemoveAll(dir)
+	}
+}
+
+// fakeSecretService implements the parts of the Secret Service API doctl
+// uses. The default collection doesn't exist until created, and creating it
+// and unlocking items go through a prompt.
+type fakeSecretService struct {
+	conn *dbus.Conn
+
+	mu         sync.Mutex
+	collection dbus.ObjectPath
+	items      map[dbus.ObjectPath]*fakeItem
+	nextID     int
+	prompts    int
+}
+
+type fakeItem struct {
+	s      *fakeSecretService
+	path   dbus.ObjectPath
+	attrs  map[string]string
+	secret []byte
+	locked bool
+}
+
+type fakeCollection struct{ s *fakeSecretService }
+
+type fakePrompt struct {
+	s      *fakeSecretService
+	path   dbus.ObjectPath
+	result dbus.Variant
+	done   func()
+}
+
+type fakeSession struct{}
+
+func newFakeSecretService(t *testing.T) *fakeSecretService {
+	conn, err := dbus.SessionBusPrivate()
+	assert.NoError(t, err)
+	assert.NoError(t, conn.Auth(nil))
+	assert.NoError(t, conn.Hello())
+
+	s := &fakeSecretService{conn: conn, items: map[dbus.ObjectPath]*fakeItem{}}
+	assert.NoError(t, conn.Export(s, ssPath, ssServiceInterface))
+
+	reply, err := conn.RequestName(ssName, dbus.NameFlagDoNotQueue)
+	assert.NoError(t, err)
+	assert.Equal(t, dbus.RequestNameReplyPrimaryOwner, reply)
+
+	return s
+}
+
+// counts returns the number of items and of prompts shown.
+func (s *fakeSecretService) counts() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.items), s.prompts
+}
+
+func (s *fakeSecretService) path(kind string) dbus.ObjectPath {
+	s.nextID++
+	return dbus.ObjectPath(fmt.Sprintf("/org/freedesktop/secrets/%s/%d", kind, s.nextID))
+}
+
+// prompt exports a prompt completing with result.
+func (s *fakeSecretService) prompt(result dbus.Variant, done func()) dbus.ObjectPath {
+	p := &fakePrompt{s: s, path: s.path("prompt"), result: result, done: done}
+	s.conn.Export(p, p.path, ssPromptInterface)
+	return p.path
+}
+
+func (s *fakeSecretService) OpenSession(algorithm string, input dbus.Variant) (dbus.Variant, dbus.ObjectPath, *dbus.Error) {
+	if algorithm != "plain" {
+		return dbus.Variant{}, "", dbus.NewError("org.freedesktop.DBus.Error.NotSupported", nil)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.path("session")
+	s.conn.Export(fakeSession{}, path, ssSessionInterface)
+	return dbus.MakeVariant(""), path, nil
+}
+
+func (s *fakeSecretService) ReadAlias(name string) (dbus.ObjectPath, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if name != ssDefaultCollection || s.collection == "" {
+		return ssNoPrompt, nil
+	}
+	return s.collection, nil
+}
+
+func (s *fakeSecretService) CreateCollection(props map[string]dbus.Variant, alias string) (dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.path("collection")
+	return ssNoPrompt, s.prompt(dbus.MakeVariant(path), func() {
+		s.collection = path
+		s.conn.Export(&fakeCollection{s: s}, path, ssCollectionInterface)
+	}), nil
+}
+
+func (s *fakeSecretService) SearchItems(attrs map[string]string) ([]dbus.ObjectPath, []dbus.ObjectPath, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlocked, locked := []dbus.ObjectPath{}, []dbus.ObjectPath{}
+	for path, item := range s.items {
+		match := true
+		for k, v := range attrs {
+			if item.attrs[k] != v {
+				match = false
+			}
+		}
+		if !match {
+			continue
+		}
+
+		if item.locked {
+			locked = append(locked, path)
+		} else {
+			unlocked = append(unlocked, path)
+		}
+	}
+
+	return unlocked, locked, nil
+}
+
+func (s *fakeSecretService) Unlock(objects []dbus.ObjectPath) ([]dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var locked []*fakeItem
+	for _, path := range objects {
+		if item, ok := s.items[path]; ok && item.locked {
+			locked = append(locked, item)
+		}
+	}
+	if len(locked) == 0 {
+		return objects, ssNoPrompt, nil
+	}
+
+	return []dbus.ObjectPath{}, s.prompt(dbus.MakeVariant(objects), func() {
+		for _, item := range locked {
+			item.locked = false
+		}
+	}), nil
+}
+
+func (c *fakeCollection) CreateItem(props map[string]dbus.Variant, secret ssSecret, replace bool) (dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	s := c.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attrs, _ := props[ssItemAttributes].Value().(map[string]string)
+
+	if replace {
+		for _, item := range s.items {
+			if fmt.Sprint(item.attrs) == fmt.Sprint(attrs) {
+				item.secret = secret.Value
+				return item.path, ssNoPrompt, nil
+			}
+		}
+	}
+
+	item := &fakeItem{s: s, path: s.path("item"), attrs: attrs, secret: secret.Value}
+	s.items[item.path] = item
+	s.conn.Export(item, item.path, ssItemInterface)
+	return item.path, ssNoPrompt, nil
+}
+
+func (i *fakeItem) GetSecret(session dbus.ObjectPath) (ssSecret, *dbus.Error) {
+	i.s.mu.Lock()
+	defer i.s.mu.Unlock()
+
+	if i.locked {
+		return ssSecret{}, dbus.NewError("org.freedesktop.Secret.Error.IsLocked", nil)
+	}
+	return ssSecret{Session: session, Parameters: []byte{}, Value: i.secret, ContentType: "text/plain"}, nil
+}
+
+func (i *fakeItem) Delete() (dbus.ObjectPath, *dbus.Error) {
+	i.s.mu.Lock()
+	defer i.s.mu.Unlock()
+
+	delete(i.s.items, i.path)
+	return ssNoPrompt, nil
+}
+
+func (p *fakePrompt) Prompt(windowID string) *dbus.Error {
+	p.s.mu.Lock()
+	p.s.prompts++
+	p.done()
+	p.s.mu.Unlock()
+
+	p.s.conn.Emit(p.path, ssPromptInterface+".Completed", false, p.result)
+	return nil
+}
+
+func (fakeSession) Close() *dbus.Error {
+	return nil
+}
+
+func TestSecretService(t *testing.T) {
+	cleanup := testSessionBus(t)
+	defer cleanup()
+
+	fake := newFakeSecretService(t)
+	defer fake.conn.Close()
+	s := NewSecretService()
+
+	_, err := s.Get("https://api.digitalocean.com")
+	assert.Equal(t, ErrNotFound, err)
+
+	// The default collection is created through a prompt.
+	assert.NoError(t, s.Set("https://api.digitalocean.com", "token"))
+	assert.NoError(t, s.Set("https://api.digitalocean.com", "replaced"))
+	assert.NoError(t, s.Set("https://example.com", "other"))
+	items, prompts := fake.counts()
+	assert.Equal(t, 2, items)
+	assert.Equal(t, 1, prompts)
+
+	secret, err := s.Get("https://api.digitalocean.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "replaced", secret)
+
+	// Locked items are unlocked through a prompt.
+	fake.mu.Lock()
+	for _, item := range fake.items {
+		item.locked = true
+		assert.Equal(t, "doctl", item.attrs["application"])
+	}
+	fake.mu.Unlock()
+
+	secret, err = s.Get("https://example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "other", secret)
+	_, prompts = fake.counts()
+	assert.Equal(t, 2, prompts)
+
+	assert.NoError(t, s.Erase("https://api.digitalocean.com"))
+	assert.NoError(t, s.Erase("https://api.digitalocean.com"))
+	_, err = s.Get("https://api.digitalocean.com")
+	assert.Equal(t, ErrNotFound, err)
+	items, _ = fake.counts()
+	assert.Equal(t, 1, items)
+}
+
+func TestSecretServiceUnavailable(t *testing.T) {
+	cleanup := testSessionBus(t)
+	defer cleanup()
+
+	_, err := NewSecretService().Get("https://api.digitalocean.com")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Secret Service")
+	}
+}
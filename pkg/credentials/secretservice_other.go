@@ -0,0 +1,49 @@
+//go:build !linux
+// +build !linux
+
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import "errors"
+
+var errSecretServiceUnsupported = errors.New("the keyring credential store is only supported on Linux")
+
+// SecretService keeps secrets in the OS keyring through the freedesktop.org
+// Secret Service D-Bus API. It is only supported on Linux.
+type SecretService struct {
+	Application string
+}
+
+var _ Store = &SecretService{}
+
+// NewSecretService creates a SecretService.
+func NewSecretService() *SecretService {
+	return &SecretService{Application: "doctl"}
+}
+
+// Get is not supported on this platform.
+func (s *SecretService) Get(key string) (string, error) {
+	return "", errSecretServiceUnsupported
+}
+
+// Set is not supported on this platform.
+func (s *SecretService) Set(key, secret string) error {
+	return errSecretServiceUnsupported
+}
+
+// Erase is not supported on this platform.
+func (s *SecretService) Erase(key string) error {
+	return errSecretServiceUnsupported
+}
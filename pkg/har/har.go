@@ -0,0 +1,372 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package har records HTTP traffic as HAR 1.2 archives, redacting secrets.
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Log is the root of a HAR archive.
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+// Creator is the application which created an archive.
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry is a single request and response.
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Time            float64   `json:"time"`
+	Request         Request   `json:"request"`
+	Response        Response  `json:"response"`
+	Cache           struct{}  `json:"cache"`
+	Timings         Timings   `json:"timings"`
+	Comment         string    `json:"comment,omitempty"`
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []NameValue `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// NameValue is a header, cookie or query parameter.
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PostData is a request body.
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// Content is a response body.
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// Timings are the durations, in milliseconds, of the phases of a request.
+// Phases which didn't happen, such as DNS lookups on reused connections,
+// are -1.
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// Recorder is a http.RoundTripper which records each request and response
+// it makes. After every request the archive is rewritten to Path, so it is
+// complete even if doctl exits abruptly.
+type Recorder struct {
+	Wrap   http.RoundTripper
+	Path   string
+	Redact bool
+
+	mu  sync.Mutex
+	log Log
+	now func() time.Time
+}
+
+// NewRecorder creates a Recorder which writes an archive to path, redacting
+// secrets.
+func NewRecorder(wrap http.RoundTripper, path string, creator Creator) *Recorder {
+	return &Recorder{
+		Wrap:   wrap,
+		Path:   path,
+		Redact: true,
+		log: Log{
+			Version: "1.2",
+			Creator: creator,
+			Entries: []Entry{},
+		},
+		now: time.Now,
+	}
+}
+
+// Log returns the recorded archive.
+func (r *Recorder) Log() Log {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.log
+}
+
+// RoundTrip makes and records a request.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = b
+		req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	}
+
+	var t phaseTimes
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), t.trace(r.now)))
+
+	t.start = r.now()
+	resp, rerr := r.wrap().RoundTrip(req)
+	t.headers = r.now()
+
+	var respBody []byte
+	if resp != nil && resp.Body != nil {
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		respBody = b
+		resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+	}
+	t.end = r.now()
+
+	entry := r.entry(req, reqBody, resp, respBody, &t)
+	if rerr != nil {
+		entry.Comment = rerr.Error()
+	}
+
+	r.mu.Lock()
+	r.log.Entries = append(r.log.Entries, entry)
+	err := r.save()
+	r.mu.Unlock()
+
+	if err != nil {
+		log.Printf("unable to write trace file %s: %v", r.Path, err)
+	}
+
+	return resp, rerr
+}
+
+func (r *Recorder) wrap() http.RoundTripper {
+	if r.Wrap == nil {
+		return http.DefaultTransport
+	}
+	return r.Wrap
+}
+
+func (r *Recorder) save() error {
+	b, err := json.MarshalIndent(struct {
+		Log Log `json:"log"`
+	}{r.log}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(r.Path, b, 0600)
+}
+
+func (r *Recorder) entry(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, t *phaseTimes) Entry {
+	headers := req.Header
+	u := *req.URL
+	if r.Redact {
+		headers = RedactHeaders(headers)
+		reqBody = RedactBody(reqBody)
+		u.RawQuery = RedactQuery(u.Query()).Encode()
+	}
+
+	e := Entry{
+		StartedDateTime: t.start,
+		Time:            ms(t.end.Sub(t.start)),
+		Request: Request{
+			Method:      req.Method,
+			URL:         u.String(),
+			HTTPVersion: httpVersion(req.ProtoMajor, req.ProtoMinor),
+			Cookies:     []NameValue{},
+			Headers:     nameValues(headers),
+			QueryString: nameValues(u.Query()),
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Timings: t.timings(),
+	}
+
+	if len(reqBody) > 0 {
+		e.Request.PostData = &PostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     string(reqBody),
+		}
+	}
+
+	if resp == nil {
+		e.Response = Response{Cookies: []NameValue{}, Headers: []NameValue{}, HeadersSize: -1, BodySize: -1}
+		return e
+	}
+
+	respHeaders := resp.Header
+	if r.Redact {
+		respHeaders = RedactHeaders(respHeaders)
+		respBody = RedactBody(respBody)
+	}
+
+	e.Response = Response{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: httpVersion(resp.ProtoMajor, resp.ProtoMinor),
+		Cookies:     []NameValue{},
+		Headers:     nameValues(respHeaders),
+		Content: Content{
+			Size:     len(respBody),
+			MimeType: resp.Header.Get("Content-Type"),
+			Text:     string(respBody),
+		},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(respBody),
+	}
+
+	return e
+}
+
+// phaseTimes are the times at which the phases of a request happened.
+type phaseTimes struct {
+	mu sync.Mutex
+
+	start, end, headers              time.Time
+	dnsStart, dnsDone                time.Time
+	connectStart, connectDone        time.Time
+	tlsStart, tlsDone                time.Time
+	gotConn, wroteRequest, firstByte time.Time
+}
+
+func (t *phaseTimes) trace(now func() time.Time) *httptrace.ClientTrace {
+	set := func(p *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if p.IsZero() {
+			*p = now()
+		}
+	}
+
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { set(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { set(&t.dnsDone) },
+		ConnectStart:         func(string, string) { set(&t.connectStart) },
+		ConnectDone:          func(string, string, error) { set(&t.connectDone) },
+		TLSHandshakeStart:    func() { set(&t.tlsStart) },
+		GotConn:              func(httptrace.GotConnInfo) { set(&t.gotConn) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { set(&t.wroteRequest) },
+		GotFirstResponseByte: func() { set(&t.firstByte) },
+	}
+}
+
+func (t *phaseTimes) timings() Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	span := func(from, to time.Time) float64 {
+		if from.IsZero() || to.IsZero() {
+			return -1
+		}
+		return ms(to.Sub(from))
+	}
+
+	// The TLS handshake is done once the connection is ready.
+	if !t.tlsStart.IsZero() {
+		t.tlsDone = t.gotConn
+	}
+
+	timings := Timings{
+		DNS:     span(t.dnsStart, t.dnsDone),
+		Connect: span(t.connectStart, t.gotConn),
+		SSL:     span(t.tlsStart, t.tlsDone),
+		Send:    span(t.gotConn, t.wroteRequest),
+		Wait:    span(t.wroteRequest, t.firstByte),
+		Receive: span(t.firstByte, t.end),
+		Blocked: -1,
+	}
+
+	// Without tracing, e.g. when the wrapped transport doesn't make real
+	// connections, the whole request is spent waiting.
+	if timings.Wait < 0 {
+		timings.Send, timings.Receive = 0, ms(t.end.Sub(t.headers))
+		timings.Wait = ms(t.headers.Sub(t.start))
+	}
+	if timings.Send < 0 {
+		timings.Send = 0
+	}
+	if timings.Receive < 0 {
+		timings.Receive = 0
+	}
+
+	if first := firstOf(t.dnsStart, t.connectStart, t.gotConn); !first.IsZero() {
+		timings.Blocked = ms(first.Sub(t.start))
+	}
+
+	return timings
+}
+
+func firstOf(times ...time.Time) time.Time {
+	for _, t := range times {
+		if !t.IsZero() {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func httpVersion(major, minor int) string {
+	if major == 0 {
+		major, minor = 1, 1
+	}
+	return fmt.Sprintf("HTTP/%d.%d", major, minor)
+}
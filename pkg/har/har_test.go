@@ -0,0 +1,145 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package har
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"droplet":{"id":1,"name":"web"}}`))
+	}))
+}
+
+func recordRequest(t *testing.T, redact bool) (Log, string) {
+	ts := testServer()
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "doctl-har")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "out.har")
+	rec := NewRecorder(nil, path, Creator{Name: "doctl", Version: "1.0.0"})
+	rec.Redact = redact
+
+	body := `{"name":"web","user_data":"#!/bin/sh\nexport SECRET=1"}`
+	req, err := http.NewRequest("POST", ts.URL+"/v2/droplets?page=1", strings.NewReader(body))
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer abc123")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := (&http.Client{Transport: rec}).Do(req)
+	assert.NoError(t, err)
+
+	b, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"droplet":{"id":1,"name":"web"}}`, string(b))
+
+	file, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	return rec.Log(), string(file)
+}
+
+func TestRecorder(t *testing.T) {
+	l, file := recordRequest(t, true)
+
+	assert.Equal(t, "1.2", l.Version)
+	assert.Equal(t, "doctl", l.Creator.Name)
+	assert.Len(t, l.Entries, 1)
+
+	e := l.Entries[0]
+	assert.Equal(t, "POST", e.Request.Method)
+	assert.Contains(t, e.Request.URL, "/v2/droplets?page=1")
+	assert.Equal(t, []NameValue{{Name: "page", Value: "1"}}, e.Request.QueryString)
+	assert.Contains(t, e.Request.Headers, NameValue{Name: "Authorization", Value: "Bearer [REDACTED]"})
+	assert.Equal(t, `{"name":"web","user_data":"[REDACTED]"}`, e.Request.PostData.Text)
+	assert.Equal(t, "application/json", e.Request.PostData.MimeType)
+
+	assert.Equal(t, 201, e.Response.Status)
+	assert.Equal(t, "Created", e.Response.StatusText)
+	assert.Equal(t, `{"droplet":{"id":1,"name":"web"}}`, e.Response.Content.Text)
+
+	assert.True(t, e.Time >= 0)
+	assert.True(t, e.Timings.Wait >= 0)
+	assert.True(t, e.Timings.Send >= 0)
+	assert.True(t, e.Timings.Receive >= 0)
+
+	var archive struct {
+		Log Log `json:"log"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(file), &archive))
+	assert.Len(t, archive.Log.Entries, 1)
+	assert.NotContains(t, file, "abc123")
+	assert.NotContains(t, file, "SECRET")
+}
+
+func TestRecorderUnredacted(t *testing.T) {
+	l, file := recordRequest(t, false)
+
+	e := l.Entries[0]
+	assert.Contains(t, e.Request.Headers, NameValue{Name: "Authorization", Value: "Bearer abc123"})
+	assert.Contains(t, e.Request.PostData.Text, "SECRET")
+	assert.Contains(t, file, "abc123")
+}
+
+func TestRedactHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer abc123")
+	h.Set("Cookie", "session=abc")
+	h.Set("Accept", "application/json")
+
+	got := RedactHeaders(h)
+	assert.Equal(t, "Bearer [REDACTED]", got.Get("Authorization"))
+	assert.Equal(t, "[REDACTED]", got.Get("Cookie"))
+	assert.Equal(t, "application/json", got.Get("Accept"))
+	assert.Equal(t, "Bearer abc123", h.Get("Authorization"))
+}
+
+func TestRedactBody(t *testing.T) {
+	cases := []struct {
+		in, out string
+	}{
+		{`{"user_data":"secret"}`, `{"user_data":"[REDACTED]"}`},
+		{`{"droplets":[{"user_data":"secret","name":"a"}]}`, `{"droplets":[{"name":"a","user_data":"[REDACTED]"}]}`},
+		{`{"user_data":null, "name": "a"}`, `{"user_data":null, "name": "a"}`},
+		{`not json`, `not json`},
+		{``, ``},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.out, string(RedactBody([]byte(c.in))))
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	q := url.Values{"access_token": {"abc"}, "page": {"2"}}
+	got := RedactQuery(q)
+	assert.Equal(t, "[REDACTED]", got.Get("access_token"))
+	assert.Equal(t, "2", got.Get("page"))
+}
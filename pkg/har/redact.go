@@ -0,0 +1,141 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package har
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Redacted replaces secret values.
+const Redacted = "[REDACTED]"
+
+// secretHeaders are headers whose values are always secret.
+var secretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// secretFields are JSON fields and query parameters whose values are
+// secret. User data often holds credentials for provisioning.
+var secretFields = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"password":      true,
+	"user_data":     true,
+}
+
+// RedactHeaders returns a copy of h with secret values replaced. The scheme
+// of authorization headers, e.g. "Bearer", is kept.
+func RedactHeaders(h http.Header) http.Header {
+	out := http.Header{}
+	for k, v := range h {
+		out[k] = append([]string(nil), v...)
+	}
+
+	for _, name := range secretHeaders {
+		values := out[http.CanonicalHeaderKey(name)]
+		for i, v := range values {
+			if scheme := strings.SplitN(v, " ", 2); len(scheme) == 2 && strings.HasSuffix(name, "Authorization") {
+				values[i] = scheme[0] + " " + Redacted
+			} else {
+				values[i] = Redacted
+			}
+		}
+	}
+
+	return out
+}
+
+// RedactQuery returns a copy of q with secret values replaced.
+func RedactQuery(q url.Values) url.Values {
+	out := url.Values{}
+	for k, v := range q {
+		if secretFields[k] {
+			out[k] = []string{Redacted}
+			continue
+		}
+		out[k] = v
+	}
+
+	return out
+}
+
+// RedactBody replaces the values of secret fields in a JSON body. Other
+// bodies are returned as they are.
+func RedactBody(b []byte) []byte {
+	var v interface{}
+	if len(b) == 0 || json.Unmarshal(b, &v) != nil {
+		return b
+	}
+
+	if !redactValue(v) {
+		return b
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		return b
+	}
+
+	return out
+}
+
+// redactValue redacts secret fields in v in place, reporting whether any
+// were found.
+func redactValue(v interface{}) bool {
+	found := false
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, fv := range t {
+			if secretFields[k] {
+				if fv != nil && fv != "" {
+					t[k] = Redacted
+					found = true
+				}
+				continue
+			}
+			if redactValue(fv) {
+				found = true
+			}
+		}
+	case []interface{}:
+		for _, ev := range t {
+			if redactValue(ev) {
+				found = true
+			}
+		}
+	}
+
+	return found
+}
+
+// nameValues flattens headers or query parameters, sorted by name.
+func nameValues(m map[string][]string) []NameValue {
+	var names []string
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	out := []NameValue{}
+	for _, k := range names {
+		for _, v := range m[k] {
+			out = append(out, NameValue{Name: k, Value: v})
+		}
+	}
+
+	return out
+}
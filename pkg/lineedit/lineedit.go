@@ -0,0 +1,282 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lineedit reads lines from a terminal in raw mode, with history and
+// tab completion.
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// ErrInterrupted is returned by ReadLine when the user presses Ctrl-C.
+var ErrInterrupted = errors.New("interrupted")
+
+// CompleteFunc returns the candidates for the word being typed at the end of
+// line.
+type CompleteFunc func(line string) []string
+
+// Editor edits lines read from a terminal in raw mode.
+type Editor struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// History is the previously entered lines, oldest first.
+	History []string
+
+	// Complete is called to complete the word before the cursor on Tab.
+	Complete CompleteFunc
+}
+
+// New creates an Editor. in should be a terminal in raw mode.
+func New(in io.Reader, out io.Writer) *Editor {
+	return &Editor{in: bufio.NewReader(in), out: out}
+}
+
+type state struct {
+	e       *Editor
+	prompt  string
+	line    []rune
+	pos     int
+	history int
+	saved   []rune
+}
+
+// ReadLine prints prompt and reads a line. It returns io.EOF if the user
+// presses Ctrl-D on an empty line, and ErrInterrupted on Ctrl-C.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	s := &state{e: e, prompt: prompt, history: len(e.History)}
+	s.refresh()
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(s.line), nil
+		case 3: // Ctrl-C
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", ErrInterrupted
+		case 4: // Ctrl-D
+			if len(s.line) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			s.delete()
+		case 1: // Ctrl-A
+			s.pos = 0
+		case 5: // Ctrl-E
+			s.pos = len(s.line)
+		case 2: // Ctrl-B
+			s.left()
+		case 6: // Ctrl-F
+			s.right()
+		case 16: // Ctrl-P
+			s.previous()
+		case 14: // Ctrl-N
+			s.next()
+		case 11: // Ctrl-K
+			s.line = s.line[:s.pos]
+		case 21: // Ctrl-U
+			s.line = s.line[s.pos:]
+			s.pos = 0
+		case 23: // Ctrl-W
+			s.deleteWord()
+		case 8, 127: // Backspace
+			if s.pos > 0 {
+				s.pos--
+				s.delete()
+			}
+		case '\t':
+			s.complete()
+		case 27: // escape sequences
+			s.escape()
+		default:
+			if unicode.IsPrint(r) {
+				s.insert(r)
+			}
+		}
+
+		s.refresh()
+	}
+}
+
+// AddHistory appends line to the history, unless it's empty or repeats the
+// last line.
+func (e *Editor) AddHistory(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if n := len(e.History); n > 0 && e.History[n-1] == line {
+		return
+	}
+
+	e.History = append(e.History, line)
+}
+
+func (s *state) escape() {
+	r, _, err := s.e.in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return
+	}
+
+	r, _, err = s.e.in.ReadRune()
+	if err != nil {
+		return
+	}
+
+	switch r {
+	case 'A':
+		s.previous()
+	case 'B':
+		s.next()
+	case 'C':
+		s.right()
+	case 'D':
+		s.left()
+	case 'H':
+		s.pos = 0
+	case 'F':
+		s.pos = len(s.line)
+	case '3':
+		if r, _, err := s.e.in.ReadRune(); err == nil && r == '~' {
+			s.delete()
+		}
+	}
+}
+
+func (s *state) insert(r rune) {
+	s.line = append(s.line, 0)
+	copy(s.line[s.pos+1:], s.line[s.pos:])
+	s.line[s.pos] = r
+	s.pos++
+}
+
+func (s *state) delete() {
+	if s.pos < len(s.line) {
+		s.line = append(s.line[:s.pos], s.line[s.pos+1:]...)
+	}
+}
+
+func (s *state) deleteWord() {
+	i := s.pos
+	for i > 0 && s.line[i-1] == ' ' {
+		i--
+	}
+	for i > 0 && s.line[i-1] != ' ' {
+		i--
+	}
+
+	s.line = append(s.line[:i], s.line[s.pos:]...)
+	s.pos = i
+}
+
+func (s *state) left() {
+	if s.pos > 0 {
+		s.pos--
+	}
+}
+
+func (s *state) right() {
+	if s.pos < len(s.line) {
+		s.pos++
+	}
+}
+
+func (s *state) previous() {
+	if s.history == 0 {
+		return
+	}
+	if s.history == len(s.e.History) {
+		s.saved = s.line
+	}
+
+	s.history--
+	s.line = []rune(s.e.History[s.history])
+	s.pos = len(s.line)
+}
+
+func (s *state) next() {
+	if s.history >= len(s.e.History) {
+		return
+	}
+
+	s.history++
+	if s.history == len(s.e.History) {
+		s.line = s.saved
+	} else {
+		s.line = []rune(s.e.History[s.history])
+	}
+	s.pos = len(s.line)
+}
+
+// complete replaces the word before the cursor with the only candidate, or
+// with the candidates' common prefix. If that doesn't add anything, the
+// candidates are listed.
+func (s *state) complete() {
+	if s.e.Complete == nil {
+		return
+	}
+
+	before := string(s.line[:s.pos])
+	candidates := s.e.Complete(before)
+	if len(candidates) == 0 {
+		return
+	}
+
+	start := strings.LastIndex(before, " ") + 1
+	word := before[start:]
+
+	replacement := candidates[0]
+	if len(candidates) == 1 {
+		replacement += " "
+	} else {
+		for _, c := range candidates[1:] {
+			replacement = commonPrefix(replacement, c)
+		}
+	}
+
+	if len(candidates) > 1 && len(replacement) <= len(word) {
+		fmt.Fprint(s.e.out, "\r\n"+strings.Join(candidates, "  ")+"\r\n")
+		return
+	}
+
+	after := s.line[s.pos:]
+	s.line = append([]rune(before[:start]+replacement), after...)
+	s.pos = len([]rune(before[:start] + replacement))
+}
+
+func (s *state) refresh() {
+	fmt.Fprintf(s.e.out, "\r%s%s\x1b[K", s.prompt, string(s.line))
+	if n := len(s.line) - s.pos; n > 0 {
+		fmt.Fprintf(s.e.out, "\x1b[%dD", n)
+	}
+}
+
+func commonPrefix(a, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+
+	return a[:i]
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lineedit
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadLine(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		line  string
+		err   error
+	}{
+		{name: "plain", input: "doctl\r", line: "doctl"},
+		{name: "backspace", input: "doctx\x7fl\r", line: "doctl"},
+		{name: "cursor", input: "dctl\x1b[D\x1b[D\x1b[Do\r", line: "doctl"},
+		{name: "home and end", input: "octl\x01d\x05!\r", line: "doctl!"},
+		{name: "kill word", input: "doctl compute\x17account\r", line: "doctl account"},
+		{name: "history", input: "\x1b[A\x1b[A\r", line: "first"},
+		{name: "history down", input: "new\x1b[A\x1b[B\r", line: "new"},
+		{name: "interrupt", input: "abc\x03", err: ErrInterrupted},
+		{name: "eof", input: "\x04", err: io.EOF},
+		{name: "complete", input: "compute dr\t\r", line: "compute droplet "},
+		{name: "common prefix", input: "compute d\t\r", line: "compute d"},
+		{name: "utf-8", input: "sammy 🦈\r", line: "sammy 🦈"},
+	}
+
+	for _, c := range cases {
+		var out bytes.Buffer
+		e := New(strings.NewReader(c.input), &out)
+		e.History = []string{"first", "second"}
+		e.Complete = func(line string) []string {
+			var matches []string
+			word := line[strings.LastIndex(line, " ")+1:]
+			for _, w := range []string{"droplet", "droplet-action", "domain"} {
+				if strings.HasPrefix(w, word) {
+					matches = append(matches, w)
+				}
+			}
+			if word == "dr" {
+				return matches[:1]
+			}
+			return matches
+		}
+
+		line, err := e.ReadLine("> ")
+		assert.Equal(t, c.err, err, c.name)
+		assert.Equal(t, c.line, line, c.name)
+	}
+}
+
+func TestCompleteLists(t *testing.T) {
+	var out bytes.Buffer
+	e := New(strings.NewReader("d\t\r"), &out)
+	e.Complete = func(string) []string { return []string{"droplet", "domain"} }
+
+	_, err := e.ReadLine("> ")
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "droplet  domain")
+}
+
+func TestAddHistory(t *testing.T) {
+	e := New(strings.NewReader(""), &bytes.Buffer{})
+	e.AddHistory("a")
+	e.AddHistory("a")
+	e.AddHistory(" ")
+	e.AddHistory("b")
+	assert.Equal(t, []string{"a", "b"}, e.History)
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package release
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// GitHubAPIURL is the GitHub API endpoint.
+const GitHubAPIURL = "https://api.github.com"
+
+// GitHubPublisher creates a GitHub release tagged v<version>, or updates an
+// existing one, and uploads the release's files as assets.
+type GitHubPublisher struct {
+	// APIURL defaults to GitHubAPIURL.
+	APIURL      string
+	Owner, Repo string
+	Token       string
+	Prerelease  bool
+	Client      *http.Client
+}
+
+var _ Publisher = &GitHubPublisher{}
+
+type githubRelease struct {
+	ID        int    `json:"id"`
+	UploadURL string `json:"upload_url"`
+	Assets    []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"assets"`
+}
+
+// Publish creates the release and uploads its files, replacing assets with
+// the same names.
+func (p *GitHubPublisher) Publish(r *Release) error {
+	tag := "v" + r.Version
+
+	var gr githubRelease
+	err := p.do("GET", p.repoURL("/releases/tags/"+url.PathEscape(tag)), nil, "", &gr)
+	if herr, ok := err.(*githubError); ok && herr.status == http.StatusNotFound {
+		body, _ := json.Marshal(map[string]interface{}{
+			"tag_name":   tag,
+			"name":       tag,
+			"prerelease": p.Prerelease,
+		})
+		err = p.do("POST", p.repoURL("/releases"), body, "application/json", &gr)
+	}
+	if err != nil {
+		return err
+	}
+
+	uploadURL := gr.UploadURL
+	if i := strings.Index(uploadURL, "{"); i >= 0 {
+		uploadURL = uploadURL[:i]
+	}
+
+	for _, f := range r.Files {
+		name := filepath.Base(f)
+
+		for _, a := range gr.Assets {
+			if a.Name == name {
+				if err := p.do("DELETE", p.repoURL(fmt.Sprintf("/releases/assets/%d", a.ID)), nil, "", nil); err != nil {
+					return err
+				}
+			}
+		}
+
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return err
+		}
+
+		u := uploadURL + "?" + url.Values{"name": {name}}.Encode()
+		if err := p.do("POST", u, b, "application/octet-stream", nil); err != nil {
+			return fmt.Errorf("unable to upload %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *GitHubPublisher) repoURL(path string) string {
+	api := p.APIURL
+	if api == "" {
+		api = GitHubAPIURL
+	}
+
+	return fmt.Sprintf("%s/repos/%s/%s%s", strings.TrimSuffix(api, "/"), p.Owner, p.Repo, path)
+}
+
+type githubError struct {
+	status  int
+	message string
+}
+
+func (e *githubError) Error() string {
+	return fmt.Sprintf("github: %d %s", e.status, e.message)
+}
+
+func (p *GitHubPublisher) do(method, u string, body []byte, contentType string, out interface{}) error {
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Authorization", "token "+p.Token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var m struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&m)
+		return &githubError{status: resp.StatusCode, message: m.Message}
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package release prepares doctl release builds and publishes them.
+package release
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// Artifact is a file in a release.
+type Artifact struct {
+	Name   string `json:"name"`
+	OS     string `json:"os,omitempty"`
+	Arch   string `json:"arch,omitempty"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// Manifest describes a release's artifacts.
+type Manifest struct {
+	Version   string     `json:"version"`
+	Artifacts []Artifact `json:"artifacts"`
+}
+
+// Release is a prepared release: its manifest, and the paths of the files to
+// publish.
+type Release struct {
+	Version  string
+	Manifest *Manifest
+	Files    []string
+}
+
+// Publisher publishes a release.
+type Publisher interface {
+	Publish(r *Release) error
+}
+
+// ChecksumsName is the name of a release's checksums file. Its signature has
+// a .asc suffix.
+func ChecksumsName(version string) string {
+	return fmt.Sprintf("doctl-%s-checksums.sha256", version)
+}
+
+// ManifestName is the name of a release's manifest file.
+func ManifestName(version string) string {
+	return fmt.Sprintf("doctl-%s-manifest.json", version)
+}
+
+// NewManifest builds a manifest for the files in dir. The platform of files
+// named like doctl-<version>-<os>[-<os version>]-<arch>.<ext> is recorded.
+func NewManifest(version, dir string) (*Manifest, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{Version: version}
+	for _, fi := range fis {
+		if fi.IsDir() || isGenerated(version, fi.Name()) {
+			continue
+		}
+
+		sum, err := fileSHA256(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		a := Artifact{Name: fi.Name(), SHA256: sum, Size: fi.Size()}
+		a.OS, a.Arch = platform(version, fi.Name())
+		m.Artifacts = append(m.Artifacts, a)
+	}
+
+	if len(m.Artifacts) == 0 {
+		return nil, fmt.Errorf("no release files found in %s", dir)
+	}
+
+	sort.Sort(byName(m.Artifacts))
+	return m, nil
+}
+
+// Checksums returns the artifacts' checksums, in the format of sha256sum.
+func (m *Manifest) Checksums() []byte {
+	var buf bytes.Buffer
+	for _, a := range m.Artifacts {
+		fmt.Fprintf(&buf, "%s  %s\n", a.SHA256, a.Name)
+	}
+
+	return buf.Bytes()
+}
+
+// Prepare writes a manifest, a checksums file and, if signer isn't nil, an
+// armored signature of the checksums file to dir, and returns the release
+// of every file in dir.
+func Prepare(version, dir string, signer *openpgp.Entity) (*Release, error) {
+	m, err := NewManifest(version, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{
+		ManifestName(version):  b,
+		ChecksumsName(version): m.Checksums(),
+	}
+
+	if signer != nil {
+		var sig bytes.Buffer
+		if err := openpgp.ArmoredDetachSign(&sig, signer, bytes.NewReader(m.Checksums()), nil); err != nil {
+			return nil, fmt.Errorf("unable to sign checksums: %v", err)
+		}
+		files[ChecksumsName(version)+".asc"] = sig.Bytes()
+	}
+
+	for name, b := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), b, 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	r := &Release{Version: version, Manifest: m}
+	for _, a := range m.Artifacts {
+		r.Files = append(r.Files, filepath.Join(dir, a.Name))
+	}
+
+	var generated []string
+	for name := range files {
+		generated = append(generated, name)
+	}
+	sort.Strings(generated)
+	for _, name := range generated {
+		r.Files = append(r.Files, filepath.Join(dir, name))
+	}
+
+	return r, nil
+}
+
+// ReadSigningKey reads an armored private key, decrypting it with passphrase
+// if it's encrypted.
+func ReadSigningKey(r io.Reader, passphrase string) (*openpgp.Entity, error) {
+	keyring, err := openpgp.ReadArmoredKeyRing(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range keyring {
+		if e.PrivateKey == nil {
+			continue
+		}
+
+		if e.PrivateKey.Encrypted {
+			if err := e.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+				return nil, fmt.Errorf("unable to decrypt signing key: %v", err)
+			}
+		}
+
+		return e, nil
+	}
+
+	return nil, errors.New("no private key found")
+}
+
+func isGenerated(version, name string) bool {
+	return name == ManifestName(version) || name == ChecksumsName(version) ||
+		name == ChecksumsName(version)+".asc"
+}
+
+func platform(version, name string) (string, string) {
+	prefix := "doctl-" + version + "-"
+	if !strings.HasPrefix(name, prefix) {
+		return "", ""
+	}
+
+	name = strings.TrimPrefix(name, prefix)
+	for _, ext := range []string{".asc", ".sha256", ".tar.gz", ".zip", ".exe"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	parts := strings.Split(name, "-")
+	if len(parts) < 2 {
+		return "", ""
+	}
+
+	return parts[0], parts[len(parts)-1]
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+type byName []Artifact
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byName) Less(i, j int) bool { return b[i].Name < b[j].Name }
@@ -0,0 +1,216 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package releasetest provides local fakes of the services releases are
+// published to, so publishing can be tested offline.
+package releasetest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// GitHub is a fake of the GitHub releases API.
+type GitHub struct {
+	*httptest.Server
+
+	// Token is the access token requests must use.
+	Token string
+
+	mu       sync.Mutex
+	nextID   int
+	releases map[string]*Release
+}
+
+// Release is a release created in the fake GitHub.
+type Release struct {
+	ID         int
+	Tag        string
+	Prerelease bool
+	Assets     map[string][]byte
+	assetIDs   map[int]string
+}
+
+// NewGitHub starts a fake GitHub. Close it when done.
+func NewGitHub(token string) *GitHub {
+	g := &GitHub{Token: token, releases: map[string]*Release{}}
+	g.Server = httptest.NewServer(http.HandlerFunc(g.serve))
+	return g
+}
+
+// Release returns the release tagged tag, or nil.
+func (g *GitHub) Release(tag string) *Release {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.releases[tag]
+}
+
+func (g *GitHub) serve(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "token "+g.Token {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Bad credentials"})
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 4 || parts[0] != "repos" || parts[3] != "releases" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+		return
+	}
+	rest := parts[4:]
+
+	switch {
+	case r.Method == "GET" && len(rest) == 2 && rest[0] == "tags":
+		rel, ok := g.releases[rest[1]]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, g.release(r, rel))
+
+	case r.Method == "POST" && len(rest) == 0:
+		var body struct {
+			TagName    string `json:"tag_name"`
+			Prerelease bool   `json:"prerelease"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		g.nextID++
+		rel := &Release{ID: g.nextID, Tag: body.TagName, Prerelease: body.Prerelease,
+			Assets: map[string][]byte{}, assetIDs: map[int]string{}}
+		g.releases[body.TagName] = rel
+		writeJSON(w, http.StatusCreated, g.release(r, rel))
+
+	case r.Method == "POST" && len(rest) == 2 && rest[1] == "assets":
+		id, _ := strconv.Atoi(rest[0])
+		for _, rel := range g.releases {
+			if rel.ID != id {
+				continue
+			}
+
+			name := r.URL.Query().Get("name")
+			if _, ok := rel.Assets[name]; ok {
+				writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"message": "already_exists"})
+				return
+			}
+
+			b, _ := ioutil.ReadAll(r.Body)
+			g.nextID++
+			rel.Assets[name] = b
+			rel.assetIDs[g.nextID] = name
+			writeJSON(w, http.StatusCreated, map[string]interface{}{"id": g.nextID, "name": name})
+			return
+		}
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+
+	case r.Method == "DELETE" && len(rest) == 2 && rest[0] == "assets":
+		id, _ := strconv.Atoi(rest[1])
+		for _, rel := range g.releases {
+			if name, ok := rel.assetIDs[id]; ok {
+				delete(rel.Assets, name)
+				delete(rel.assetIDs, id)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Not Found"})
+	}
+}
+
+func (g *GitHub) release(r *http.Request, rel *Release) map[string]interface{} {
+	var assets []map[string]interface{}
+	for id, name := range rel.assetIDs {
+		assets = append(assets, map[string]interface{}{"id": id, "name": name})
+	}
+
+	prefix := strings.Join(strings.Split(strings.Trim(r.URL.Path, "/"), "/")[:4], "/")
+	return map[string]interface{}{
+		"id":         rel.ID,
+		"tag_name":   rel.Tag,
+		"prerelease": rel.Prerelease,
+		"upload_url": fmt.Sprintf("%s/%s/%d/assets{?name,label}", g.URL, prefix, rel.ID),
+		"assets":     assets,
+	}
+}
+
+// S3 is a fake of S3-compatible object storage, which accepts signed PUT
+// requests.
+type S3 struct {
+	*httptest.Server
+
+	// AccessKey is the access key requests must be signed with.
+	AccessKey string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+// NewS3 starts a fake S3. Close it when done.
+func NewS3(accessKey string) *S3 {
+	s := &S3{AccessKey: accessKey, objects: map[string][]byte{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Object returns the object stored at path, which includes the bucket, or
+// nil.
+func (s *S3) Object(path string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.objects[strings.TrimPrefix(path, "/")]
+}
+
+func (s *S3) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential="+s.AccessKey+"/") ||
+		r.Header.Get("X-Amz-Date") == "" || r.Header.Get("X-Amz-Content-Sha256") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, "<Error><Code>AccessDenied</Code></Error>")
+		return
+	}
+
+	if r.Method != "PUT" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	b, _ := ioutil.ReadAll(r.Body)
+	if sum := sha256.Sum256(b); hex.EncodeToString(sum[:]) != r.Header.Get("X-Amz-Content-Sha256") {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, "<Error><Code>XAmzContentSHA256Mismatch</Code></Error>")
+		return
+	}
+	s.objects[strings.TrimPrefix(r.URL.Path, "/")] = b
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
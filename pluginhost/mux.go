@@ -0,0 +1,110 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pluginhost
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// mux splits a single plugin connection into a stream of calls made by the
+// other side and a stream of responses to calls made by this side, so doctl
+// and a plugin can both make JSON-RPC calls over the same pie connection.
+type mux struct {
+	conn io.ReadWriteCloser
+	wmu  sync.Mutex
+
+	calls      *io.PipeReader
+	callsW     *io.PipeWriter
+	responses  *io.PipeReader
+	responsesW *io.PipeWriter
+}
+
+func newMux(conn io.ReadWriteCloser) *mux {
+	m := &mux{conn: conn}
+	m.calls, m.callsW = io.Pipe()
+	m.responses, m.responsesW = io.Pipe()
+
+	go m.demux()
+
+	return m
+}
+
+// demux routes each message read from the connection. JSON-RPC requests
+// have a method; responses don't.
+func (m *mux) demux() {
+	dec := json.NewDecoder(m.conn)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			m.callsW.CloseWithError(err)
+			m.responsesW.CloseWithError(err)
+			return
+		}
+
+		var msg struct {
+			Method *string `json:"method"`
+		}
+		json.Unmarshal(raw, &msg)
+
+		w := m.responsesW
+		if msg.Method != nil {
+			w = m.callsW
+		}
+
+		if _, err := w.Write(raw); err != nil {
+			return
+		}
+	}
+}
+
+func (m *mux) write(b []byte) (int, error) {
+	m.wmu.Lock()
+	defer m.wmu.Unlock()
+	return m.conn.Write(b)
+}
+
+// client is the connection for a JSON-RPC client codec. Closing it closes
+// the underlying connection.
+func (m *mux) client() io.ReadWriteCloser {
+	return &muxConn{m: m, r: m.responses, closeConn: true}
+}
+
+// server is the connection for a JSON-RPC server codec.
+func (m *mux) server() io.ReadWriteCloser {
+	return &muxConn{m: m, r: m.calls}
+}
+
+type muxConn struct {
+	m         *mux
+	r         *io.PipeReader
+	closeConn bool
+}
+
+func (c *muxConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+func (c *muxConn) Write(b []byte) (int, error) {
+	return c.m.write(b)
+}
+
+func (c *muxConn) Close() error {
+	if c.closeConn {
+		return c.m.conn.Close()
+	}
+
+	return c.r.Close()
+}
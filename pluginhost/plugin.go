@@ -14,14 +14,16 @@ limitations under the License.
 package pluginhost
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/rpc"
 	"net/rpc/jsonrpc"
 	"os"
-
-	"github.com/natefinch/pie"
-	"github.com/spf13/viper"
+	"os/exec"
+	"strings"
+	"time"
 )
 
 // Host is an object consumers can retrieve doit information from.
@@ -29,23 +31,117 @@ type Host struct {
 	client *rpc.Client
 }
 
-// NewHost builds an instance of Host.
-func NewHost(pluginPath string) (*Host, error) {
-	client, err := pie.StartProviderCodec(jsonrpc.NewClientCodec, os.Stderr, pluginPath)
+// NewHost builds an instance of Host. The plugin may call back into services
+// over the same connection; services may be nil if it shouldn't.
+func NewHost(pluginPath string, services *Services) (*Host, error) {
+	conn, err := startPlugin(pluginPath, pluginEnv(os.Environ()))
 	if err != nil {
 		return nil, err
 	}
 
 	return &Host{
-		client: client,
+		client: rpc.NewClientWithCodec(hostCodec(services)(conn)),
 	}, nil
 }
 
+// pluginEnv removes the access token and credential store settings from
+// environ, so plugins can only reach the API through the services they were
+// granted.
+func pluginEnv(environ []string) []string {
+	env := []string{}
+	for _, kv := range environ {
+		name := strings.SplitN(kv, "=", 2)[0]
+		if name == "DIGITALOCEAN_ACCESS_TOKEN" || strings.HasPrefix(name, "DOCTL_CREDENTIAL_") {
+			continue
+		}
+		env = append(env, kv)
+	}
+
+	return env
+}
+
+// pluginStopTimeout is how long a plugin has to exit after being interrupted
+// before it is killed.
+var pluginStopTimeout = time.Second
+
+// pluginConn is a connection to a plugin over its stdin and stdout.
+type pluginConn struct {
+	io.ReadCloser
+	io.WriteCloser
+	cmd *exec.Cmd
+}
+
+// startPlugin runs the plugin at path with the environment env, writing its
+// stderr to doctl's.
+func startPlugin(path string, env []string) (*pluginConn, error) {
+	cmd := exec.Command(path)
+	cmd.Env = env
+	cmd.Stderr = os.Stderr
+
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return &pluginConn{ReadCloser: out, WriteCloser: in, cmd: cmd}, nil
+}
+
+// Close closes the pipes and stops the plugin, killing it if it doesn't exit
+// in time.
+func (pc *pluginConn) Close() error {
+	err := pc.ReadCloser.Close()
+	if werr := pc.WriteCloser.Close(); werr != nil {
+		err = werr
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- pc.cmd.Wait() }()
+
+	if serr := pc.cmd.Process.Signal(os.Interrupt); serr != nil {
+		return serr
+	}
+
+	select {
+	case werr := <-done:
+		if werr != nil {
+			return werr
+		}
+		return err
+	case <-time.After(pluginStopTimeout):
+		if kerr := pc.cmd.Process.Kill(); kerr != nil {
+			return fmt.Errorf("unable to kill plugin after timeout: %v", kerr)
+		}
+		return fmt.Errorf("plugin didn't stop within %s", pluginStopTimeout)
+	}
+}
+
+// hostCodec returns a function which builds the codec for calling a plugin
+// over conn, while serving services to it over the same connection.
+func hostCodec(services *Services) func(io.ReadWriteCloser) rpc.ClientCodec {
+	return func(conn io.ReadWriteCloser) rpc.ClientCodec {
+		m := newMux(conn)
+
+		server := rpc.NewServer()
+		server.RegisterName("Host", &hostService{services: services})
+		go server.ServeCodec(jsonrpc.NewServerCodec(m.server()))
+
+		return jsonrpc.NewClientCodec(m.client())
+	}
+}
+
 // Call a method on the plugin.
 func (h *Host) Call(method string, args ...string) (string, error) {
 	opts := &CallOptions{
-		AccessToken: viper.GetString("access-token"),
-		Args:        args,
+		Args: args,
 	}
 
 	var result string
@@ -58,6 +154,49 @@ func (h *Host) Call(method string, args ...string) (string, error) {
 	return result, nil
 }
 
+// Invoke calls a method on the plugin and returns its result undecoded, so
+// plugins may return structured results as well as strings.
+func (h *Host) Invoke(method string, opts *CallOptions) (json.RawMessage, error) {
+	var result json.RawMessage
+	err := h.client.Call(method, opts, &result)
+	if err != nil {
+		if se, ok := err.(rpc.ServerError); ok {
+			return nil, fmt.Errorf("plugin action %s failed: %s", method, se)
+		}
+		debug(err.Error())
+		return nil, fmt.Errorf("unable to run plugin action %s", method)
+	}
+
+	return result, nil
+}
+
+// Describe asks the plugin named name for the commands it provides.
+func (h *Host) Describe(name string) (*Description, error) {
+	var d Description
+	err := h.client.Call(name+".Describe", &CallOptions{}, &d)
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe plugin %s: %v", name, err)
+	}
+
+	return &d, nil
+}
+
+// Hook sends event to the plugin's Hook method.
+func (h *Host) Hook(name string, event *HookEvent) (*HookResponse, error) {
+	var resp HookResponse
+	err := h.client.Call(name+".Hook", event, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("unable to run hook of plugin %s: %v", name, err)
+	}
+
+	return &resp, nil
+}
+
+// Close stops the plugin.
+func (h *Host) Close() error {
+	return h.client.Close()
+}
+
 func debug(msg string) {
 	//if viper.GetBool("verbose") {
 	log.Println(msg)
@@ -65,8 +204,79 @@ func debug(msg string) {
 }
 
 // CallOptions are options to a plugin call. This is exported so go based plugins
-// can use the type.
+// can use the type. Plugins are never given the access token; they call
+// doctl's services through Provider.Call instead.
 type CallOptions struct {
-	AccessToken string
-	Args        []string
+	Args  []string
+	Flags map[string]interface{}
+}
+
+// Description is returned by a plugin's Describe method and lists the
+// commands the plugin adds to doctl.
+type Description struct {
+	Short    string
+	Long     string
+	Commands []CommandDescription
+
+	// Permissions are the doctl service operations the plugin needs, e.g.
+	// "droplets.read" or "domains.Create". See NewServices. They must be
+	// approved by the user before the plugin can call them.
+	Permissions []string
+}
+
+// CommandDescription describes a single plugin command.
+type CommandDescription struct {
+	// Name is the command name, e.g. "deploy". Its usage line, e.g.
+	// "deploy <app>", may be given as Use instead.
+	Name string
+	Use  string
+
+	// Method is the plugin method the command calls. It defaults to the
+	// title cased name.
+	Method string
+
+	Short   string
+	Long    string
+	Aliases []string
+	Flags   []FlagDescription
+
+	// Columns are the fields of structured results shown in text output.
+	// All fields are shown if it is empty.
+	Columns []string
+}
+
+// FlagDescription describes a flag of a plugin command.
+type FlagDescription struct {
+	Name      string
+	Shorthand string
+
+	// Type is one of "string", "bool", "int" or "stringSlice". It defaults
+	// to "string".
+	Type     string
+	Default  string
+	Usage    string
+	Required bool
+}
+
+// HookEvent is sent to hooks before and after a doctl command runs.
+type HookEvent struct {
+	// Phase is "pre" or "post".
+	Phase string `json:"phase"`
+
+	// Command is the command's namespace, e.g. "droplet.create".
+	Command string                 `json:"command"`
+	Args    []string               `json:"args"`
+	Flags   map[string]interface{} `json:"flags"`
+
+	// Result is the JSON output of the command, and Error the error it
+	// failed with, if any. They are only set after the command runs.
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// HookResponse is a plugin's response to a HookEvent. A pre hook may veto
+// the command, explaining why in Message.
+type HookResponse struct {
+	Veto    bool   `json:"veto"`
+	Message string `json:"message"`
 }
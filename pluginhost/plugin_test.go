@@ -0,0 +1,50 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pluginhost
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluginEnv(t *testing.T) {
+	env := pluginEnv([]string{
+		"HOME=/home/sammy",
+		"DIGITALOCEAN_ACCESS_TOKEN=secret",
+		"DOCTL_CREDENTIAL_PASSPHRASE=secret",
+		"DOCTL_CREDENTIAL_HELPER=pass",
+		"DOCTL_UPDATE_CHECK=false",
+	})
+
+	assert.Equal(t, []string{"HOME=/home/sammy", "DOCTL_UPDATE_CHECK=false"}, env)
+}
+
+func TestStartPluginEnv(t *testing.T) {
+	path, err := exec.LookPath("env")
+	if err != nil {
+		t.Skip("env isn't available")
+	}
+
+	conn, err := startPlugin(path, pluginEnv([]string{"A=1", "DIGITALOCEAN_ACCESS_TOKEN=secret"}))
+	assert.NoError(t, err)
+
+	out, err := ioutil.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, "A=1\n", string(out))
+
+	conn.Close()
+}
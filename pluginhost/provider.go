@@ -0,0 +1,109 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pluginhost
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+)
+
+// Provider is used by go based plugins to serve their methods to doctl and
+// to call doctl's services.
+type Provider struct {
+	server *rpc.Server
+	host   *rpc.Client
+}
+
+// NewProvider builds an instance of Provider.
+func NewProvider() *Provider {
+	return &Provider{
+		server: rpc.NewServer(),
+	}
+}
+
+// RegisterName registers the methods of rcvr under name, which should be
+// the plugin's name.
+func (p *Provider) RegisterName(name string, rcvr interface{}) error {
+	return p.server.RegisterName(name, rcvr)
+}
+
+// Serve serves the plugin to doctl over stdin and stdout. It blocks until
+// doctl closes the connection.
+func (p *Provider) Serve() {
+	p.ServeConn(stdio{})
+}
+
+// ServeConn serves the plugin to doctl over conn. It blocks until conn is
+// closed.
+func (p *Provider) ServeConn(conn io.ReadWriteCloser) {
+	m := newMux(conn)
+	p.host = rpc.NewClientWithCodec(jsonrpc.NewClientCodec(m.client()))
+	p.server.ServeCodec(jsonrpc.NewServerCodec(m.server()))
+}
+
+// Call calls method on one of doctl's services, e.g. Call("droplets", "Get",
+// []interface{}{&droplet}, 1). results receive the method's return values,
+// excluding its error. The plugin must have permission to make the call.
+func (p *Provider) Call(service, method string, results []interface{}, args ...interface{}) error {
+	if p.host == nil {
+		return fmt.Errorf("plugin is not connected to doctl")
+	}
+
+	req := &Request{Service: service, Method: method}
+	for _, a := range args {
+		b, err := json.Marshal(a)
+		if err != nil {
+			return err
+		}
+		req.Args = append(req.Args, b)
+	}
+
+	var resp Response
+	if err := p.host.Call("Host.Call", req, &resp); err != nil {
+		return err
+	}
+
+	for i, r := range results {
+		if i >= len(resp.Results) {
+			break
+		}
+		if err := json.Unmarshal(resp.Results[i], r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type stdio struct{}
+
+func (stdio) Read(b []byte) (int, error) {
+	return os.Stdin.Read(b)
+}
+
+func (stdio) Write(b []byte) (int, error) {
+	return os.Stdout.Write(b)
+}
+
+func (stdio) Close() error {
+	err := os.Stdin.Close()
+	if werr := os.Stdout.Close(); err == nil {
+		err = werr
+	}
+	return err
+}
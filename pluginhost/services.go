@@ -0,0 +1,148 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pluginhost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Request is a call from a plugin to one of doctl's services, e.g. the
+// "droplets" service's "List" method.
+type Request struct {
+	Service string
+	Method  string
+	Args    []json.RawMessage
+}
+
+// Response holds the results of a Request, excluding its error.
+type Response struct {
+	Results []json.RawMessage
+}
+
+// Services proxies calls from a plugin to doctl's services. Only the
+// operations allowed by the user's approved permissions may be called.
+type Services struct {
+	services    map[string]interface{}
+	permissions []string
+}
+
+// NewServices builds an instance of Services. Permissions take the form
+// "<service>.<method>", where the service may be "*" and the method may be
+// "*" for every method or "read" for methods whose names start with Get or
+// List.
+func NewServices(services map[string]interface{}, permissions []string) *Services {
+	return &Services{
+		services:    services,
+		permissions: permissions,
+	}
+}
+
+// Allowed reports whether permissions allow calling method on service.
+func Allowed(permissions []string, service, method string) bool {
+	for _, p := range permissions {
+		i := strings.LastIndex(p, ".")
+		if i < 0 {
+			continue
+		}
+
+		ps, pm := p[:i], p[i+1:]
+		if ps != service && ps != "*" {
+			continue
+		}
+
+		switch pm {
+		case "*", method:
+			return true
+		case "read":
+			if strings.HasPrefix(method, "Get") || strings.HasPrefix(method, "List") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func (s *Services) call(req *Request, resp *Response) error {
+	if s == nil {
+		return errors.New("plugin host services are not available")
+	}
+
+	if !Allowed(s.permissions, req.Service, req.Method) {
+		return fmt.Errorf("plugin is not allowed to call %s.%s", req.Service, req.Method)
+	}
+
+	svc, ok := s.services[req.Service]
+	if !ok {
+		var names []string
+		for name := range s.services {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown service %q, possible values: %s", req.Service, strings.Join(names, ", "))
+	}
+
+	m := reflect.ValueOf(svc).MethodByName(req.Method)
+	if !m.IsValid() {
+		return fmt.Errorf("unknown method %s.%s", req.Service, req.Method)
+	}
+
+	mt := m.Type()
+	if mt.IsVariadic() || mt.NumIn() != len(req.Args) {
+		return fmt.Errorf("%s.%s takes %d arguments, got %d", req.Service, req.Method, mt.NumIn(), len(req.Args))
+	}
+
+	in := make([]reflect.Value, mt.NumIn())
+	for i := range in {
+		v := reflect.New(mt.In(i))
+		if err := json.Unmarshal(req.Args[i], v.Interface()); err != nil {
+			return fmt.Errorf("invalid argument %d to %s.%s: %v", i, req.Service, req.Method, err)
+		}
+		in[i] = v.Elem()
+	}
+
+	for _, out := range m.Call(in) {
+		if out.Type() == errorType {
+			if !out.IsNil() {
+				return out.Interface().(error)
+			}
+			continue
+		}
+
+		b, err := json.Marshal(out.Interface())
+		if err != nil {
+			return err
+		}
+		resp.Results = append(resp.Results, b)
+	}
+
+	return nil
+}
+
+// hostService is registered with the plugin connection as "Host", so
+// plugins call "Host.Call".
+type hostService struct {
+	services *Services
+}
+
+func (h *hostService) Call(req *Request, resp *Response) error {
+	return h.services.call(req, resp)
+}
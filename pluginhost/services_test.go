@@ -0,0 +1,145 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pluginhost
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testDroplet struct {
+	ID   int
+	Name string
+}
+
+type testDropletsService struct {
+	deleted []int
+}
+
+func (s *testDropletsService) Get(id int) (*testDroplet, error) {
+	if id != 1 {
+		return nil, errors.New("not found")
+	}
+	return &testDroplet{ID: 1, Name: "web"}, nil
+}
+
+func (s *testDropletsService) Delete(id int) error {
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+// testPlugin calls back into doctl from its methods.
+type testPlugin struct {
+	provider *Provider
+}
+
+func (p *testPlugin) Name(opts *CallOptions, out *string) error {
+	var d testDroplet
+	if err := p.provider.Call("droplets", "Get", []interface{}{&d}, 1); err != nil {
+		return err
+	}
+
+	*out = d.Name
+	return nil
+}
+
+func (p *testPlugin) Delete(opts *CallOptions, out *string) error {
+	return p.provider.Call("droplets", "Delete", nil, 1)
+}
+
+func startTestPlugin(t *testing.T, services *Services) *rpc.Client {
+	hostConn, pluginConn := net.Pipe()
+
+	provider := NewProvider()
+	assert.NoError(t, provider.RegisterName("test", &testPlugin{provider: provider}))
+	go provider.ServeConn(pluginConn)
+
+	return rpc.NewClientWithCodec(hostCodec(services)(hostConn))
+}
+
+func TestAllowed(t *testing.T) {
+	cases := []struct {
+		perms   []string
+		service string
+		method  string
+		allowed bool
+	}{
+		{[]string{"droplets.Get"}, "droplets", "Get", true},
+		{[]string{"droplets.Get"}, "droplets", "Delete", false},
+		{[]string{"droplets.read"}, "droplets", "List", true},
+		{[]string{"droplets.read"}, "droplets", "Delete", false},
+		{[]string{"droplets.*"}, "droplets", "Delete", true},
+		{[]string{"droplets.*"}, "domains", "List", false},
+		{[]string{"*.read"}, "domains", "Get", true},
+		{[]string{"invalid"}, "droplets", "Get", false},
+		{nil, "droplets", "Get", false},
+	}
+
+	for _, c := range cases {
+		got := Allowed(c.perms, c.service, c.method)
+		assert.Equal(t, c.allowed, got, "%v %s.%s", c.perms, c.service, c.method)
+	}
+}
+
+func TestProviderCallsHost(t *testing.T) {
+	ds := &testDropletsService{}
+	services := NewServices(map[string]interface{}{"droplets": ds}, []string{"droplets.read"})
+
+	client := startTestPlugin(t, services)
+	defer client.Close()
+
+	var name string
+	err := client.Call("test.Name", &CallOptions{}, &name)
+	assert.NoError(t, err)
+	assert.Equal(t, "web", name)
+
+	err = client.Call("test.Delete", &CallOptions{}, &name)
+	assert.EqualError(t, err, "plugin is not allowed to call droplets.Delete")
+	assert.Empty(t, ds.deleted)
+}
+
+func TestProviderCallsHostAllowedWrite(t *testing.T) {
+	ds := &testDropletsService{}
+	services := NewServices(map[string]interface{}{"droplets": ds}, []string{"droplets.*"})
+
+	client := startTestPlugin(t, services)
+	defer client.Close()
+
+	var out string
+	err := client.Call("test.Delete", &CallOptions{}, &out)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, ds.deleted)
+}
+
+func TestServicesErrors(t *testing.T) {
+	services := NewServices(map[string]interface{}{"droplets": &testDropletsService{}}, []string{"*.*"})
+
+	var resp Response
+	err := services.call(&Request{Service: "domains", Method: "List"}, &resp)
+	assert.EqualError(t, err, `unknown service "domains", possible values: droplets`)
+
+	err = services.call(&Request{Service: "droplets", Method: "Create"}, &resp)
+	assert.EqualError(t, err, "unknown method droplets.Create")
+
+	err = services.call(&Request{Service: "droplets", Method: "Get"}, &resp)
+	assert.EqualError(t, err, "droplets.Get takes 1 arguments, got 0")
+
+	var none *Services
+	err = none.call(&Request{Service: "droplets", Method: "Get"}, &resp)
+	assert.EqualError(t, err, "plugin host services are not available")
+}
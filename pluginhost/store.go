@@ -0,0 +1,494 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pluginhost
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/doctl/install"
+)
+
+// PluginPrefix is the file name prefix of plugin executables.
+const PluginPrefix = "doit-provider-"
+
+// manifestName is the name of the optional manifest in a plugin archive.
+const manifestName = "plugin.json"
+
+// DefaultStoreDir returns the directory doctl installs plugins in.
+func DefaultStoreDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "doctl", "plugins"), nil
+	}
+
+	usr, err := user.Current()
+	if err != nil {
+		return "", err
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(usr.HomeDir, "Library", "Application Support", "doctl", "plugins"), nil
+	case "windows":
+		if dir := os.Getenv("AppData"); dir != "" {
+			return filepath.Join(dir, "doctl", "plugins"), nil
+		}
+	}
+
+	return filepath.Join(usr.HomeDir, ".local", "share", "doctl", "plugins"), nil
+}
+
+// Manifest may be included in a plugin archive as plugin.json to describe
+// the plugin.
+type Manifest struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// IndexEntry records an installed plugin.
+type IndexEntry struct {
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	Source    string    `json:"source"`
+	Checksum  string    `json:"checksum"`
+	Path      string    `json:"path"`
+	Installed time.Time `json:"installed"`
+}
+
+// Store manages the plugins installed by doctl. Executables are kept in
+// its bin directory and recorded in index.json.
+type Store struct {
+	Dir string
+}
+
+// NewStore creates a Store in dir.
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+// BinDir is the directory holding installed plugin executables.
+func (s *Store) BinDir() string {
+	return filepath.Join(s.Dir, "bin")
+}
+
+func (s *Store) indexPath() string {
+	return filepath.Join(s.Dir, "index.json")
+}
+
+// List returns the installed plugins, sorted by name.
+func (s *Store) List() ([]IndexEntry, error) {
+	index, err := s.readIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []IndexEntry
+	for _, e := range index {
+		entries = append(entries, e)
+	}
+	sort.Sort(byName(entries))
+
+	return entries, nil
+}
+
+// Get returns the installed plugin named name.
+func (s *Store) Get(name string) (*IndexEntry, error) {
+	index, err := s.readIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	e, ok := index[name]
+	if !ok {
+		return nil, fmt.Errorf("plugin %q is not installed", name)
+	}
+
+	return &e, nil
+}
+
+// validName matches the names plugins may be installed under. Names become
+// file names and index keys, so they can't hold path separators or dots.
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Package is a fetched and verified plugin archive, ready to be installed.
+type Package struct {
+	Name     string
+	Version  string
+	Source   string
+	Checksum string
+
+	data []byte
+}
+
+// OpenPackage fetches the plugin archive at source, a path or an http(s) URL,
+// verifies it against checksum, which holds a SHA-256 sum as written by
+// sha256sum, and extracts the plugin it contains. Nothing is installed.
+func OpenPackage(source string, checksum io.Reader) (*Package, error) {
+	tmp, err := ioutil.TempDir("", "doctl-plugin-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	archive := filepath.Join(tmp, path.Base(source))
+	if err := Fetch(source, archive); err != nil {
+		return nil, err
+	}
+
+	sum, err := verify(archive, checksum)
+	if err != nil {
+		return nil, err
+	}
+
+	bin, manifest, err := extract(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	name := manifest.Name
+	if name == "" {
+		name = strings.TrimSuffix(strings.TrimPrefix(bin.name, PluginPrefix), ".exe")
+	}
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid plugin name %q, names may only contain lowercase letters, digits, - and _", name)
+	}
+
+	version := manifest.Version
+	if version == "" {
+		version = archiveVersion(path.Base(source))
+	}
+
+	return &Package{
+		Name:     name,
+		Version:  version,
+		Source:   source,
+		Checksum: sum,
+		data:     bin.data,
+	}, nil
+}
+
+// Install fetches and verifies the plugin archive at source, as OpenPackage
+// does, and installs the plugin it contains.
+func (s *Store) Install(source string, checksum io.Reader) (*IndexEntry, error) {
+	pkg, err := OpenPackage(source, checksum)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.InstallPackage(pkg)
+}
+
+// InstallPackage installs pkg. An installed plugin of the same name is
+// replaced.
+func (s *Store) InstallPackage(pkg *Package) (*IndexEntry, error) {
+	if !validName.MatchString(pkg.Name) {
+		return nil, fmt.Errorf("invalid plugin name %q", pkg.Name)
+	}
+
+	dest := filepath.Join(s.BinDir(), PluginPrefix+pkg.Name)
+	if runtime.GOOS == "windows" {
+		dest += ".exe"
+	}
+	if filepath.Dir(dest) != filepath.Clean(s.BinDir()) {
+		return nil, fmt.Errorf("plugin %q would be installed outside of %s", pkg.Name, s.BinDir())
+	}
+
+	if err := os.MkdirAll(s.BinDir(), 0755); err != nil {
+		return nil, err
+	}
+
+	// Write next to the destination first so a failed install never leaves
+	// a partial executable behind.
+	part := dest + ".part"
+	if err := ioutil.WriteFile(part, pkg.data, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(part, dest); err != nil {
+		os.Remove(part)
+		return nil, err
+	}
+
+	entry := IndexEntry{
+		Name:      pkg.Name,
+		Version:   pkg.Version,
+		Source:    pkg.Source,
+		Checksum:  pkg.Checksum,
+		Path:      dest,
+		Installed: time.Now().UTC(),
+	}
+
+	index, err := s.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	index[pkg.Name] = entry
+
+	if err := s.writeIndex(index); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// Remove uninstalls the plugin named name.
+func (s *Store) Remove(name string) error {
+	index, err := s.readIndex()
+	if err != nil {
+		return err
+	}
+
+	e, ok := index[name]
+	if !ok {
+		return fmt.Errorf("plugin %q is not installed", name)
+	}
+
+	if err := os.Remove(e.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	delete(index, name)
+	return s.writeIndex(index)
+}
+
+func (s *Store) readIndex() (map[string]IndexEntry, error) {
+	index := map[string]IndexEntry{}
+
+	b, err := ioutil.ReadFile(s.indexPath())
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &index); err != nil {
+		return nil, fmt.Errorf("invalid plugin index %s: %v", s.indexPath(), err)
+	}
+
+	return index, nil
+}
+
+func (s *Store) writeIndex(index map[string]IndexEntry) error {
+	b, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.indexPath(), b, 0644)
+}
+
+// Fetch copies source, a path or an http(s) URL, to dest.
+func Fetch(source, dest string) error {
+	var r io.ReadCloser
+
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		resp, err := http.Get(source)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("unable to download %s: %s", source, resp.Status)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(source)
+		if err != nil {
+			return err
+		}
+		r = f
+	}
+	defer r.Close()
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// verify validates the file at p against checksum, returning its SHA-256
+// sum.
+func verify(p string, checksum io.Reader) (string, error) {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return "", err
+	}
+
+	if err := install.Validate(bytes.NewReader(b), checksum); err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+type archiveFile struct {
+	name string
+	data []byte
+}
+
+// extract finds the plugin executable, and manifest if there is one, in
+// the archive at p. Other files are ignored.
+func extract(p string) (*archiveFile, *Manifest, error) {
+	var files []archiveFile
+	var err error
+
+	switch {
+	case strings.HasSuffix(p, ".zip"):
+		files, err = readZip(p)
+	case strings.HasSuffix(p, ".tar.gz"), strings.HasSuffix(p, ".tgz"):
+		files, err = readTarGz(p)
+	default:
+		return nil, nil, fmt.Errorf("unsupported plugin archive %s, expected .tar.gz, .tgz or .zip", filepath.Base(p))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var bin *archiveFile
+	manifest := &Manifest{}
+
+	for i, f := range files {
+		switch {
+		case f.name == manifestName:
+			if err := json.Unmarshal(f.data, manifest); err != nil {
+				return nil, nil, fmt.Errorf("invalid plugin manifest: %v", err)
+			}
+		case strings.HasPrefix(f.name, PluginPrefix):
+			if bin != nil {
+				return nil, nil, fmt.Errorf("plugin archive contains more than one plugin: %s, %s", bin.name, f.name)
+			}
+			bin = &files[i]
+		}
+	}
+
+	if bin == nil {
+		return nil, nil, fmt.Errorf("plugin archive doesn't contain a %s* executable", PluginPrefix)
+	}
+
+	return bin, manifest, nil
+}
+
+func readTarGz(p string) ([]archiveFile, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var files []archiveFile
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, archiveFile{name: path.Base(hdr.Name), data: data})
+	}
+}
+
+func readZip(p string) ([]archiveFile, error) {
+	zr, err := zip.OpenReader(p)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var files []archiveFile
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+
+		r, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, archiveFile{name: path.Base(zf.Name), data: data})
+	}
+
+	return files, nil
+}
+
+var versionRe = regexp.MustCompile(`-v?(\d+\.\d+\.\d+(?:-(?:alpha|beta|rc)\.?\d*)?)`)
+
+// archiveVersion finds the version in an archive name such as
+// doit-provider-foo-1.2.0-linux-amd64.tar.gz.
+func archiveVersion(name string) string {
+	m := versionRe.FindStringSubmatch(name)
+	if m == nil {
+		return "unknown"
+	}
+
+	return m[1]
+}
+
+type byName []IndexEntry
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byName) Less(i, j int) bool { return b[i].Name < b[j].Name }
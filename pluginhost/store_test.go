@@ -0,0 +1,244 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pluginhost
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTarGz(t *testing.T, path string, files map[string]string) string {
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(body))}))
+		_, err := tw.Write([]byte(body))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+
+	return checksumOf(t, path)
+}
+
+func writeZip(t *testing.T, path string, files map[string]string) string {
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(body))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+
+	return checksumOf(t, path)
+}
+
+func checksumOf(t *testing.T, path string) string {
+	b, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func tempStore(t *testing.T) (*Store, string, func()) {
+	dir, err := ioutil.TempDir("", "doctl-store")
+	assert.NoError(t, err)
+
+	return NewStore(filepath.Join(dir, "plugins")), dir, func() { os.RemoveAll(dir) }
+}
+
+func TestStoreInstallTarGz(t *testing.T) {
+	store, dir, cleanup := tempStore(t)
+	defer cleanup()
+
+	archive := filepath.Join(dir, "doit-provider-foo-1.2.0-linux-amd64.tar.gz")
+	sum := writeTarGz(t, archive, map[string]string{
+		"foo/doit-provider-foo": "#!/bin/sh\n",
+		"foo/README":            "readme",
+	})
+
+	entry, err := store.Install(archive, strings.NewReader(sum+"  "+archive))
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", entry.Name)
+	assert.Equal(t, "1.2.0", entry.Version)
+	assert.Equal(t, sum, entry.Checksum)
+	assert.Equal(t, archive, entry.Source)
+
+	b, err := ioutil.ReadFile(filepath.Join(store.BinDir(), "doit-provider-foo"))
+	assert.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\n", string(b))
+
+	got, err := store.Get("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, entry.Path, got.Path)
+
+	entries, err := store.List()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	_, err = os.Stat(filepath.Join(store.BinDir(), "README"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestStoreInstallZipFromURL(t *testing.T) {
+	store, dir, cleanup := tempStore(t)
+	defer cleanup()
+
+	archive := filepath.Join(dir, "bar.zip")
+	sum := writeZip(t, archive, map[string]string{
+		"doit-provider-bar": "bar",
+		"plugin.json":       `{"name": "baz", "version": "0.3.0"}`,
+	})
+
+	ts := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer ts.Close()
+
+	entry, err := store.Install(ts.URL+"/bar.zip", strings.NewReader(sum))
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", entry.Name)
+	assert.Equal(t, "0.3.0", entry.Version)
+	assert.Equal(t, filepath.Join(store.BinDir(), "doit-provider-baz"), entry.Path)
+
+	_, err = store.Install(ts.URL+"/missing.zip", strings.NewReader(sum))
+	assert.EqualError(t, err, "unable to download "+ts.URL+"/missing.zip: 404 Not Found")
+}
+
+func TestStoreInstallInvalid(t *testing.T) {
+	store, dir, cleanup := tempStore(t)
+	defer cleanup()
+
+	archive := filepath.Join(dir, "foo.tar.gz")
+	sum := writeTarGz(t, archive, map[string]string{"doit-provider-foo": "foo"})
+
+	_, err := store.Install(archive, strings.NewReader(strings.Repeat("0", 64)))
+	assert.EqualError(t, err, "invalid checksum: "+sum+" != "+strings.Repeat("0", 64))
+
+	_, err = store.Install(archive, strings.NewReader(""))
+	assert.EqualError(t, err, "checksum is empty")
+
+	_, err = store.Get("foo")
+	assert.EqualError(t, err, `plugin "foo" is not installed`)
+
+	empty := filepath.Join(dir, "empty.tar.gz")
+	sum = writeTarGz(t, empty, map[string]string{"README": "readme"})
+	_, err = store.Install(empty, strings.NewReader(sum))
+	assert.EqualError(t, err, "plugin archive doesn't contain a doit-provider-* executable")
+
+	plain := filepath.Join(dir, "doit-provider-foo")
+	assert.NoError(t, ioutil.WriteFile(plain, []byte("foo"), 0755))
+	_, err = store.Install(plain, strings.NewReader(checksumOf(t, plain)))
+	assert.EqualError(t, err, "unsupported plugin archive doit-provider-foo, expected .tar.gz, .tgz or .zip")
+}
+
+func TestStoreInstallInvalidName(t *testing.T) {
+	store, dir, cleanup := tempStore(t)
+	defer cleanup()
+
+	for _, name := range []string{"/../../../../.bashrc", "../foo", "Foo", ".foo", "foo/bar", "-foo"} {
+		archive := filepath.Join(dir, "foo.zip")
+		sum := writeZip(t, archive, map[string]string{
+			"doit-provider-foo": "foo",
+			"plugin.json":       `{"name": "` + name + `"}`,
+		})
+
+		_, err := store.Install(archive, strings.NewReader(sum))
+		assert.Error(t, err, name)
+		assert.Contains(t, err.Error(), "invalid plugin name", name)
+	}
+
+	_, err := store.InstallPackage(&Package{Name: "../../evil"})
+	assert.Error(t, err)
+
+	_, err = os.Stat(store.Dir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestOpenPackageWritesNothing(t *testing.T) {
+	store, dir, cleanup := tempStore(t)
+	defer cleanup()
+
+	archive := filepath.Join(dir, "doit-provider-foo-1.0.0.tgz")
+	sum := writeTarGz(t, archive, map[string]string{"doit-provider-foo": "foo"})
+
+	pkg, err := OpenPackage(archive, strings.NewReader(sum))
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", pkg.Name)
+	assert.Equal(t, "1.0.0", pkg.Version)
+	assert.Equal(t, sum, pkg.Checksum)
+
+	_, err = os.Stat(store.Dir)
+	assert.True(t, os.IsNotExist(err))
+
+	entry, err := store.InstallPackage(pkg)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(store.BinDir(), "doit-provider-foo"), entry.Path)
+}
+
+func TestStoreRemove(t *testing.T) {
+	store, dir, cleanup := tempStore(t)
+	defer cleanup()
+
+	archive := filepath.Join(dir, "foo.tgz")
+	sum := writeTarGz(t, archive, map[string]string{"doit-provider-foo": "foo"})
+
+	entry, err := store.Install(archive, strings.NewReader(sum))
+	assert.NoError(t, err)
+	assert.Equal(t, "unknown", entry.Version)
+
+	assert.NoError(t, store.Remove("foo"))
+
+	_, err = os.Stat(entry.Path)
+	assert.True(t, os.IsNotExist(err))
+
+	entries, err := store.List()
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	assert.EqualError(t, store.Remove("foo"), `plugin "foo" is not installed`)
+}
+
+func TestArchiveVersion(t *testing.T) {
+	cases := map[string]string{
+		"doit-provider-foo-1.2.0-linux-amd64.tar.gz": "1.2.0",
+		"foo-v0.1.10.zip":           "0.1.10",
+		"foo-2.0.0-rc.1-darwin.tgz": "2.0.0-rc.1",
+		"foo.tar.gz":                "unknown",
+	}
+
+	for name, want := range cases {
+		assert.Equal(t, want, archiveVersion(name), name)
+	}
+}
@@ -14,41 +14,106 @@ limitations under the License.
 package doit
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
-	"net/http/httputil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/digitalocean/doctl/pkg/har"
 )
 
-// recorder traces http connections. It sends the output to a request and
-// response channels.
+// recorder traces http requests and responses in a readable form, with
+// JSON bodies pretty printed.
 type recorder struct {
-	wrap http.RoundTripper
-	req  chan string
-	resp chan string
+	wrap   http.RoundTripper
+	out    io.Writer
+	redact bool
+
+	mu sync.Mutex
 }
 
-func newRecorder(transport http.RoundTripper) *recorder {
+func newRecorder(transport http.RoundTripper, out io.Writer, redact bool) *recorder {
 	return &recorder{
-		wrap: transport,
-		req:  make(chan string),
-		resp: make(chan string),
+		wrap:   transport,
+		out:    out,
+		redact: redact,
 	}
 }
 
 func (rec *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
-	reqBytes, err := httputil.DumpRequestOut(req, true)
-	if err != nil {
-		return nil, fmt.Errorf("transport.Recorder: dumping request, %v", err)
+	var reqBody []byte
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("transport.Recorder: reading request, %v", err)
+		}
+		reqBody = b
+		req.Body = ioutil.NopCloser(bytes.NewReader(b))
 	}
-	rec.req <- string(reqBytes)
 
+	start := time.Now()
 	resp, rerr := rec.wrap.RoundTrip(req)
 
-	respBytes, err := httputil.DumpResponse(resp, true)
-	if err != nil {
-		return nil, fmt.Errorf("transport.Recorder: dumping response, %v", err)
+	var respBody []byte
+	if resp != nil && resp.Body != nil {
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("transport.Recorder: reading response, %v", err)
+		}
+		respBody = b
+		resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+	}
+	elapsed := time.Since(start)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "-> %s %s\n", req.Method, req.URL)
+	rec.writeMessage(&buf, req.Header, reqBody)
+
+	if rerr != nil {
+		fmt.Fprintf(&buf, "<- error after %v: %v\n\n", elapsed, rerr)
+	} else {
+		fmt.Fprintf(&buf, "<- %s (%v)\n", resp.Status, elapsed)
+		rec.writeMessage(&buf, resp.Header, respBody)
 	}
-	rec.resp <- string(respBytes)
+
+	rec.mu.Lock()
+	buf.WriteTo(rec.out)
+	rec.mu.Unlock()
 
 	return resp, rerr
 }
+
+func (rec *recorder) writeMessage(w io.Writer, h http.Header, body []byte) {
+	if rec.redact {
+		h = har.RedactHeaders(h)
+		body = har.RedactBody(body)
+	}
+
+	var names []string
+	for k := range h {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, k := range names {
+		fmt.Fprintf(w, "   %s: %s\n", k, strings.Join(h[k], ", "))
+	}
+
+	if len(body) > 0 {
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, body, "   ", "  ") == nil {
+			body = pretty.Bytes()
+		}
+		fmt.Fprintf(w, "\n   %s\n", bytes.TrimSpace(body))
+	}
+
+	fmt.Fprintln(w)
+}
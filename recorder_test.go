@@ -0,0 +1,52 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doit
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"droplet":{"id":1}}`))
+	}))
+	defer ts.Close()
+
+	var out bytes.Buffer
+	rec := newRecorder(http.DefaultTransport, &out, true)
+
+	req, err := http.NewRequest("POST", ts.URL+"/v2/droplets", strings.NewReader(`{"name":"web","user_data":"secret"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer abc123")
+
+	resp, err := (&http.Client{Transport: rec}).Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	trace := out.String()
+	assert.Contains(t, trace, "-> POST "+ts.URL+"/v2/droplets\n")
+	assert.Contains(t, trace, "   Authorization: Bearer [REDACTED]\n")
+	assert.Contains(t, trace, `"user_data": "[REDACTED]"`)
+	assert.Contains(t, trace, "<- 200 OK (")
+	assert.Contains(t, trace, "   {\n     \"droplet\": {\n       \"id\": 1\n     }\n   }\n")
+	assert.NotContains(t, trace, "abc123")
+	assert.NotContains(t, trace, "secret")
+}
@@ -34,6 +34,10 @@ if [[ -z $SKIPBUILD ]]; then
   if [[ -n "$label" ]]; then
     ldflags="${ldflags} $baseFlag.Label=${label}"
   fi
+  if [[ -n "$SIGNING_KEY" ]]; then
+    releaseKey=$(gpg --armor --export "$SIGNING_KEY" | base64 | tr -d '\n')
+    ldflags="${ldflags} $baseFlag/install.ReleaseKey=${releaseKey}"
+  fi
 
   xgo \
     --dest $OUTPUT_DIR/stage \
@@ -67,8 +71,9 @@ for f in $STAGE_DIR/*; do
   fi
   
   pushd $STAGE_DIR
-  shasum -a 256 $(basename $distbin) > ${RELEASE_DIR}/$(basename ${f%".exe"}).sha256
+  checksum=${RELEASE_DIR}/$(basename ${f%".exe"}).sha256
+  shasum -a 256 $(basename $distbin) > $checksum
   popd
-  
+
   rm $bin
 done
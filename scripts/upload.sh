@@ -3,23 +3,14 @@
 set -o pipefail
 
 ver=$1
+shift
 
 if [[ -z "$ver" ]]; then
-	echo "usage: $0 <version>"
+	echo "usage: $0 <version> [release-doctl flags]"
 	exit 1
 fi
 
 DIR="$( cd "$( dirname "${BASH_SOURCE[0]}" )" && pwd )"
 OUTPUT_DIR="${DIR}/../builds/${ver}/release"
 
-for r in $(ls ${OUTPUT_DIR}/doctl-${ver}-*); do
-	name=$(basename $r)
-	echo "uploading $name"
-	github-release upload \
-		--user digitalocean \
-		--repo doctl \
-		--tag v${ver} \
-		--name $name \
-		--file $r
-done
-
+go run ${DIR}/../cmd/release-doctl/main.go -ver ${ver} -path ${OUTPUT_DIR} "$@"
@@ -0,0 +1,269 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// UpdateCheckTTL is how long the latest version is cached for.
+	UpdateCheckTTL = 24 * time.Hour
+
+	// UpdateCheckTimeout is how long to wait for the latest version.
+	UpdateCheckTimeout = 2 * time.Second
+
+	// UpdateCheckRetry is how long to wait before trying again after the
+	// latest version couldn't be looked up.
+	UpdateCheckRetry = time.Hour
+)
+
+// LatestVersioner an interface for detecting the latest version.
+type LatestVersioner interface {
+	LatestVersion() (string, error)
+}
+
+// NewLatestVersioner returns a LatestVersioner for source, which is either
+// a release URL, in the format of the GitHub latest release API, or a local
+// file, given as a path or file:// URL. Versions from URLs are cached in
+// cacheDir, unless it's empty.
+func NewLatestVersioner(source, cacheDir string) LatestVersioner {
+	if source == "" {
+		source = LatestReleaseURL
+	}
+
+	if u, err := url.Parse(source); err == nil {
+		switch u.Scheme {
+		case "http", "https":
+			var lv LatestVersioner = &GithubLatestVersioner{URL: source}
+			if cacheDir != "" {
+				lv = &CachedLatestVersioner{
+					Wrap:  lv,
+					Path:  filepath.Join(cacheDir, "latest-version.json"),
+					URL:   source,
+					TTL:   UpdateCheckTTL,
+					Retry: UpdateCheckRetry,
+				}
+			}
+			return lv
+		case "file":
+			return &FileLatestVersioner{Path: u.Path}
+		}
+	}
+
+	return &FileLatestVersioner{Path: source}
+}
+
+// GithubLatestVersioner retrieves the latest version from Github.
+type GithubLatestVersioner struct {
+	// URL is the latest release endpoint. It defaults to LatestReleaseURL.
+	URL string
+
+	// Client defaults to a client which times out after UpdateCheckTimeout.
+	Client *http.Client
+}
+
+var _ LatestVersioner = &GithubLatestVersioner{}
+
+// LatestVersion retrieves the latest version from Github or returns
+// an error.
+func (glv *GithubLatestVersioner) LatestVersion() (string, error) {
+	u := glv.URL
+	if u == "" {
+		u = LatestReleaseURL
+	}
+
+	client := glv.Client
+	if client == nil {
+		client = &http.Client{Timeout: UpdateCheckTimeout}
+	}
+
+	res, err := client.Get(u)
+	if err != nil {
+		return "", err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to retrieve latest release: %s", res.Status)
+	}
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return parseLatestVersion(b)
+}
+
+// FileLatestVersioner reads the latest version from a local file, for
+// environments which mirror releases. The file holds either a release in the
+// format of the GitHub API, or just a version.
+type FileLatestVersioner struct {
+	Path string
+}
+
+var _ LatestVersioner = &FileLatestVersioner{}
+
+// LatestVersion reads the latest version from the file.
+func (flv *FileLatestVersioner) LatestVersion() (string, error) {
+	b, err := ioutil.ReadFile(flv.Path)
+	if err != nil {
+		return "", err
+	}
+
+	return parseLatestVersion(b)
+}
+
+func parseLatestVersion(b []byte) (string, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return "", errors.New("could not find a version")
+	}
+
+	if b[0] != '{' {
+		return strings.TrimPrefix(string(b), "v"), nil
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return "", err
+	}
+
+	tagName, ok := m["tag_name"].(string)
+	if !ok {
+		return "", errors.New("could not find tag name in response")
+	}
+
+	return strings.TrimPrefix(tagName, "v"), nil
+}
+
+// CachedLatestVersioner caches the latest version in a file, so it's only
+// looked up once every TTL. Failed lookups are only retried after Retry, so
+// users who can't reach the release URL don't wait for it every time.
+type CachedLatestVersioner struct {
+	Wrap LatestVersioner
+	Path string
+
+	// URL is stored with the version, so changing the release URL
+	// invalidates the cache.
+	URL   string
+	TTL   time.Duration
+	Retry time.Duration
+}
+
+var _ LatestVersioner = &CachedLatestVersioner{}
+
+type cachedVersion struct {
+	Version string    `json:"version"`
+	URL     string    `json:"url"`
+	Checked time.Time `json:"checked"`
+
+	// Attempted is when the version was last looked up. It's recorded
+	// before looking the version up, as a lookup which times out may never
+	// return.
+	Attempted time.Time `json:"attempted"`
+}
+
+// LatestVersion returns the cached version, or looks it up if the cached
+// version has expired.
+func (clv *CachedLatestVersioner) LatestVersion() (string, error) {
+	var cv cachedVersion
+	if b, err := ioutil.ReadFile(clv.Path); err == nil && json.Unmarshal(b, &cv) == nil && cv.URL == clv.URL {
+		if cv.Version != "" && time.Since(cv.Checked) < clv.TTL {
+			return cv.Version, nil
+		}
+
+		if time.Since(cv.Attempted) < clv.Retry {
+			if cv.Version != "" {
+				return cv.Version, nil
+			}
+			return "", errors.New("latest version lookup failed recently")
+		}
+	} else {
+		cv = cachedVersion{URL: clv.URL}
+	}
+
+	cv.Attempted = time.Now()
+	clv.write(&cv)
+
+	v, err := clv.Wrap.LatestVersion()
+	if err != nil {
+		return "", err
+	}
+
+	cv.Version, cv.Checked = v, time.Now()
+	clv.write(&cv)
+
+	return v, nil
+}
+
+func (clv *CachedLatestVersioner) write(cv *cachedVersion) {
+	if b, err := json.Marshal(cv); err == nil {
+		if os.MkdirAll(filepath.Dir(clv.Path), 0700) == nil {
+			ioutil.WriteFile(clv.Path, b, 0600)
+		}
+	}
+}
+
+// BackgroundLatestVersioner looks up the latest version in the background,
+// giving up after a timeout.
+type BackgroundLatestVersioner struct {
+	result  chan latestVersionResult
+	timeout time.Duration
+}
+
+var _ LatestVersioner = &BackgroundLatestVersioner{}
+
+type latestVersionResult struct {
+	version string
+	err     error
+}
+
+// StartLatestVersion starts looking up the latest version using lv.
+func StartLatestVersion(lv LatestVersioner, timeout time.Duration) *BackgroundLatestVersioner {
+	blv := &BackgroundLatestVersioner{
+		result:  make(chan latestVersionResult, 1),
+		timeout: timeout,
+	}
+
+	go func() {
+		v, err := lv.LatestVersion()
+		blv.result <- latestVersionResult{version: v, err: err}
+	}()
+
+	return blv
+}
+
+// LatestVersion waits for the latest version until the timeout.
+func (blv *BackgroundLatestVersioner) LatestVersion() (string, error) {
+	select {
+	case r := <-blv.result:
+		blv.result <- r
+		return r.version, r.err
+	case <-time.After(blv.timeout):
+		return "", errors.New("timed out retrieving latest version")
+	}
+}
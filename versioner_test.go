@@ -0,0 +1,158 @@
+/*
+Copyright 2016 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doit
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGithubLatestVersioner(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"tag_name":"v1.2.3"}`)
+	}))
+	defer ts.Close()
+
+	v, err := (&GithubLatestVersioner{URL: ts.URL}).LatestVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3", v)
+}
+
+func TestFileLatestVersioner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "versioner")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		contents string
+		version  string
+	}{
+		{contents: `{"tag_name":"v1.2.3"}`, version: "1.2.3"},
+		{contents: "v1.2.4\n", version: "1.2.4"},
+		{contents: "1.2.5", version: "1.2.5"},
+	}
+
+	for _, c := range cases {
+		path := filepath.Join(dir, "latest")
+		assert.NoError(t, ioutil.WriteFile(path, []byte(c.contents), 0600))
+
+		for _, source := range []string{path, "file://" + path} {
+			v, err := NewLatestVersioner(source, dir).LatestVersion()
+			assert.NoError(t, err)
+			assert.Equal(t, c.version, v)
+		}
+	}
+}
+
+type countingVersioner struct {
+	version string
+	err     error
+	calls   int
+}
+
+func (cv *countingVersioner) LatestVersion() (string, error) {
+	cv.calls++
+	return cv.version, cv.err
+}
+
+func TestCachedLatestVersioner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "versioner")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wrap := &countingVersioner{version: "1.2.3"}
+	clv := &CachedLatestVersioner{Wrap: wrap, Path: filepath.Join(dir, "v", "latest.json"), URL: "a", TTL: time.Hour}
+
+	for i := 0; i < 2; i++ {
+		v, err := clv.LatestVersion()
+		assert.NoError(t, err)
+		assert.Equal(t, "1.2.3", v)
+	}
+	assert.Equal(t, 1, wrap.calls)
+
+	clv.URL = "b"
+	clv.LatestVersion()
+	assert.Equal(t, 2, wrap.calls)
+
+	clv.TTL = 0
+	clv.LatestVersion()
+	assert.Equal(t, 3, wrap.calls)
+}
+
+func TestCachedLatestVersionerRetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "versioner")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wrap := &countingVersioner{err: errors.New("offline")}
+	clv := &CachedLatestVersioner{Wrap: wrap, Path: filepath.Join(dir, "latest.json"), URL: "a", TTL: time.Hour, Retry: time.Hour}
+
+	_, err = clv.LatestVersion()
+	assert.EqualError(t, err, "offline")
+
+	// Failures aren't retried until Retry has passed.
+	_, err = clv.LatestVersion()
+	assert.EqualError(t, err, "latest version lookup failed recently")
+	assert.Equal(t, 1, wrap.calls)
+
+	wrap.version, wrap.err = "1.2.3", nil
+	clv.Retry = 0
+	v, err := clv.LatestVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3", v)
+	assert.Equal(t, 2, wrap.calls)
+
+	// Once expired, a failed lookup keeps the last known version.
+	wrap.err = errors.New("offline")
+	clv.TTL = 0
+	_, err = clv.LatestVersion()
+	assert.EqualError(t, err, "offline")
+
+	clv.Retry = time.Hour
+	v, err = clv.LatestVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3", v)
+	assert.Equal(t, 3, wrap.calls)
+}
+
+type blockingVersioner chan struct{}
+
+func (bv blockingVersioner) LatestVersion() (string, error) {
+	<-bv
+	return "", errors.New("unreachable")
+}
+
+func TestBackgroundLatestVersioner(t *testing.T) {
+	blv := StartLatestVersion(&countingVersioner{version: "1.2.3"}, time.Second)
+	for i := 0; i < 2; i++ {
+		v, err := blv.LatestVersion()
+		assert.NoError(t, err)
+		assert.Equal(t, "1.2.3", v)
+	}
+
+	bv := make(blockingVersioner)
+	defer close(bv)
+
+	_, err := StartLatestVersion(bv, time.Millisecond).LatestVersion()
+	assert.Error(t, err)
+}